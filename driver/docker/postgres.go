@@ -7,6 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
+	"sort"
+	"strings"
 	"time"
 
 	dtypes "github.com/docker/docker/api/types"
@@ -24,6 +27,10 @@ import (
 	"github.com/trivigy/migrate/v2/types"
 )
 
+// postgresVolume defines the data directory declared as a volume by the
+// official postgres image.
+const postgresVolume = "/var/lib/postgresql/data"
+
 // Postgres represents a driver for a docker based postgres database.
 type Postgres struct {
 	Name         string `json:"name" yaml:"name"`
@@ -40,6 +47,7 @@ type Postgres struct {
 var _ interface {
 	driver.WithCreate
 	driver.WithDestroy
+	driver.WithSnapshots
 	driver.WithSource
 } = new(Postgres)
 
@@ -89,6 +97,12 @@ func (r Postgres) Create(ctx context.Context, out io.Writer) error {
 		}
 	}
 
+	return r.start(ctx, docker, refStr)
+}
+
+// start creates and starts the database container from the image and waits
+// until the database accepts connections.
+func (r Postgres) start(ctx context.Context, docker *client.Client, image string) error {
 	envVars := make([]string, 0)
 	if r.Password != "" {
 		envVars = append(envVars, "POSTGRES_PASSWORD="+r.Password)
@@ -108,7 +122,7 @@ func (r Postgres) Create(ctx context.Context, out io.Writer) error {
 	if r.PGData != "" {
 		envVars = append(envVars, "PGDATA="+r.PGData)
 	}
-	createCfg := &container.Config{Image: refStr, Tty: true, Env: envVars}
+	createCfg := &container.Config{Image: image, Tty: true, Env: envVars}
 	hostCfg := &container.HostConfig{
 		AutoRemove: true,
 	}
@@ -282,3 +296,155 @@ func (r Postgres) Source(ctx context.Context, out io.Writer) error {
 	}
 	return nil
 }
+
+// Snapshot commits the database container into an image. The postgres image
+// declares its default data directory as a volume which is not captured by a
+// commit, therefore PGData must point elsewhere for snapshots to work.
+func (r Postgres) Snapshot(ctx context.Context, out io.Writer, name string) error {
+	if r.PGData == "" || strings.HasPrefix(path.Clean(r.PGData), postgresVolume) {
+		return fmt.Errorf("snapshots require pgData outside of %q volume", postgresVolume)
+	}
+
+	docker, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithVersion("1.38"),
+	)
+	if err != nil {
+		return err
+	}
+	defer docker.Close()
+
+	info, err := r.container(ctx, docker)
+	if err != nil {
+		return err
+	}
+
+	ref := r.snapshotRef(name)
+	if _, _, err := docker.ImageInspectWithRaw(ctx, ref); err == nil {
+		return fmt.Errorf("snapshot %q exists", name)
+	}
+
+	commitOpts := dtypes.ContainerCommitOptions{Reference: ref, Pause: true}
+	if _, err := docker.ContainerCommit(ctx, info.ID, commitOpts); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Snapshots returns the list of available database snapshots.
+func (r Postgres) Snapshots(ctx context.Context) (types.Snapshots, error) {
+	docker, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithVersion("1.38"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer docker.Close()
+
+	repo := r.snapshotRef("")
+	filter := filters.NewArgs()
+	filter.Add("reference", repo+"*")
+	images, err := docker.ImageList(ctx, dtypes.ImageListOptions{Filters: filter})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(types.Snapshots, 0)
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			if !strings.HasPrefix(tag, repo) {
+				continue
+			}
+
+			snapshots = append(snapshots, types.Snapshot{
+				Name:      strings.TrimPrefix(tag, repo),
+				Timestamp: time.Unix(image.Created, 0),
+			})
+		}
+	}
+	sort.Sort(snapshots)
+	return snapshots, nil
+}
+
+// RestoreSnapshot replaces the database container with one started from the
+// snapshot image.
+func (r Postgres) RestoreSnapshot(ctx context.Context, out io.Writer, name string) error {
+	docker, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithVersion("1.38"),
+	)
+	if err != nil {
+		return err
+	}
+	defer docker.Close()
+
+	ref := r.snapshotRef(name)
+	if _, _, err := docker.ImageInspectWithRaw(ctx, ref); err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("snapshot %q not found", name)
+		}
+		return err
+	}
+
+	info, err := r.container(ctx, docker)
+	if err != nil {
+		return err
+	}
+
+	// containers are started with auto removal so waiting for removal must
+	// begin before the container is killed.
+	waitC, errC := docker.ContainerWait(ctx, info.ID, container.WaitConditionRemoved)
+	if err := docker.ContainerKill(ctx, info.ID, "KILL"); err != nil {
+		return err
+	}
+
+	select {
+	case <-waitC:
+	case err := <-errC:
+		return err
+	}
+	return r.start(ctx, docker, ref)
+}
+
+// DeleteSnapshot removes the snapshot image.
+func (r Postgres) DeleteSnapshot(ctx context.Context, out io.Writer, name string) error {
+	docker, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithVersion("1.38"),
+	)
+	if err != nil {
+		return err
+	}
+	defer docker.Close()
+
+	removeOpts := dtypes.ImageRemoveOptions{PruneChildren: true}
+	if _, err := docker.ImageRemove(ctx, r.snapshotRef(name), removeOpts); err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("snapshot %q not found", name)
+		}
+		return err
+	}
+	return nil
+}
+
+// container returns the running database container.
+func (r Postgres) container(ctx context.Context, docker *client.Client) (*dtypes.Container, error) {
+	filter := filters.NewArgs()
+	filter.Add("name", r.Name)
+	listOpts := dtypes.ContainerListOptions{Filters: filter}
+	containers, err := docker.ContainerList(ctx, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("container %q not found", r.Name)
+	}
+	return &containers[0], nil
+}
+
+// snapshotRef returns the image reference under which the snapshot is kept.
+func (r Postgres) snapshotRef(name string) string {
+	return "migrate-snapshot/" + r.Name + ":" + name
+}
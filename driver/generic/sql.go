@@ -9,6 +9,8 @@ import (
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/internal/retry"
+	"github.com/trivigy/migrate/v2/internal/snapshot"
+	"github.com/trivigy/migrate/v2/types"
 )
 
 // SQL represents an abstract remote sql database driver.
@@ -20,6 +22,7 @@ type SQL struct {
 var _ interface {
 	driver.WithCreate
 	driver.WithDestroy
+	driver.WithSnapshots
 	driver.WithSource
 } = new(SQL)
 
@@ -66,3 +69,39 @@ func (r SQL) Source(ctx context.Context, out io.Writer) error {
 	}
 	return nil
 }
+
+// Snapshot takes a point-in-time copy of the database.
+func (r SQL) Snapshot(ctx context.Context, out io.Writer, name string) error {
+	manager, err := snapshot.New(r.Dialect, r.DataSource)
+	if err != nil {
+		return err
+	}
+	return manager.Create(ctx, name)
+}
+
+// Snapshots returns the list of available database snapshots.
+func (r SQL) Snapshots(ctx context.Context) (types.Snapshots, error) {
+	manager, err := snapshot.New(r.Dialect, r.DataSource)
+	if err != nil {
+		return nil, err
+	}
+	return manager.List(ctx)
+}
+
+// RestoreSnapshot replaces the database with the content of the snapshot.
+func (r SQL) RestoreSnapshot(ctx context.Context, out io.Writer, name string) error {
+	manager, err := snapshot.New(r.Dialect, r.DataSource)
+	if err != nil {
+		return err
+	}
+	return manager.Restore(ctx, name)
+}
+
+// DeleteSnapshot removes the snapshot.
+func (r SQL) DeleteSnapshot(ctx context.Context, out io.Writer, name string) error {
+	manager, err := snapshot.New(r.Dialect, r.DataSource)
+	if err != nil {
+		return err
+	}
+	return manager.Delete(ctx, name)
+}
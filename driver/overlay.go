@@ -0,0 +1,12 @@
+package driver
+
+import (
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// WithOverlay represents the method interface for extracting the overlay
+// applied onto the release manifests. This is likely to be used by a
+// kubernetes driver for adjusting a single base release per environment.
+type WithOverlay interface {
+	Overlay() *types.Overlay
+}
@@ -0,0 +1,12 @@
+package driver
+
+import (
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// WithSeeds represents the method interface for extracting the seeds from a
+// driver. This method is likely to be used by a database driver which
+// populates the database with seed data.
+type WithSeeds interface {
+	Seeds() *types.Seeds
+}
@@ -0,0 +1,17 @@
+package driver
+
+import (
+	"context"
+	"io"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// WithSnapshots represents a driver that is able to take point-in-time copies
+// of its database and later restore the database from them.
+type WithSnapshots interface {
+	Snapshot(ctx context.Context, out io.Writer, name string) error
+	Snapshots(ctx context.Context) (types.Snapshots, error)
+	RestoreSnapshot(ctx context.Context, out io.Writer, name string) error
+	DeleteSnapshot(ctx context.Context, out io.Writer, name string) error
+}
@@ -0,0 +1,14 @@
+package driver
+
+import (
+	"context"
+
+	"github.com/trivigy/migrate/v2/state"
+)
+
+// WithState represents a driver which keeps the bookkeeping records of
+// migrations and releases in a store of its choice rather than in the target
+// database, e.g. a local file or a kubernetes secret.
+type WithState interface {
+	State(ctx context.Context) (state.Store, error)
+}
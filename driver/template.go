@@ -0,0 +1,9 @@
+package driver
+
+// WithTemplate represents a driver which supplies its own scaffolding template
+// for newly generated files. The format argument is the requested file format
+// (e.g. go, sql, yaml) and an empty string indicates the builtin template
+// should be used.
+type WithTemplate interface {
+	Template(format string) string
+}
@@ -0,0 +1,12 @@
+package driver
+
+import (
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// WithValues represents the method interface for extracting the values from
+// which the release manifests are rendered. This is likely to be used by a
+// kubernetes driver for supplying the per environment configuration.
+type WithValues interface {
+	Values() *types.Values
+}
@@ -14,6 +14,22 @@ const (
 	// reference across chained commands.
 	RefRoot key = iota
 
+	// RefEnvironment defines a value to be used as a key for propogating the
+	// name of the selected environment across chained commands.
+	RefEnvironment key = iota
+
+	// RefTracerProvider defines a value to be used as a key for propogating
+	// the opentelemetry tracer provider across chained commands.
+	RefTracerProvider key = iota
+
+	// RefMeterProvider defines a value to be used as a key for propogating
+	// the opentelemetry meter provider across chained commands.
+	RefMeterProvider key = iota
+
+	// RefOutput defines a value to be used as a key for propogating the
+	// selected output format across chained commands.
+	RefOutput key = iota
+
 	// DefaultEnvironment defines the name of a default environment.
 	DefaultEnvironment = "development"
 
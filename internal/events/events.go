@@ -0,0 +1,118 @@
+// Package events implements structured reporting of the steps taken by
+// commands.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/trivigy/migrate/v2/global"
+)
+
+// Output formats selectable with the `--output` flag.
+const (
+	OutputText  = "text"
+	OutputJSONL = "jsonl"
+)
+
+// Event types reported by commands.
+const (
+	TypeMigration = "migration"
+	TypePrimitive = "primitive"
+	TypeManifest  = "manifest"
+	TypeSnapshot  = "snapshot"
+)
+
+// Event statuses reported by commands.
+const (
+	StatusApplied     = "applied"
+	StatusRemoved     = "removed"
+	StatusMarked      = "marked"
+	StatusCreated     = "created"
+	StatusDestroyed   = "destroyed"
+	StatusSourced     = "sourced"
+	StatusInstalled   = "installed"
+	StatusUpdated     = "updated"
+	StatusUninstalled = "uninstalled"
+	StatusPruned      = "pruned"
+	StatusRestored    = "restored"
+	StatusSkipped     = "skipped"
+	StatusFailed      = "failed"
+)
+
+// stderr defines where notices are written when the jsonl output format was
+// selected.
+var stderr io.Writer = os.Stderr
+
+// Event defines a single step reported by a command.
+type Event struct {
+	Type      string        `json:"type"`
+	Resource  string        `json:"resource"`
+	Release   string        `json:"release,omitempty"`
+	Tag       string        `json:"tag,omitempty"`
+	Version   string        `json:"version,omitempty"`
+	Namespace string        `json:"namespace,omitempty"`
+	Direction string        `json:"direction,omitempty"`
+	Status    string        `json:"status"`
+	Duration  time.Duration `json:"-"`
+	Error     string        `json:"error,omitempty"`
+	Output    string        `json:"output,omitempty"`
+}
+
+// MarshalJSON defines custom json marshalling which reports the duration in
+// milliseconds.
+func (r Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	return json.Marshal(struct {
+		event
+		Duration int64 `json:"durationMs"`
+	}{event(r), r.Duration.Milliseconds()})
+}
+
+// Fail sets the failed status and error message of the event when err is
+// non-nil.
+func (r *Event) Fail(err error) {
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+}
+
+// JSONL reports whether the jsonl output format was selected for the context.
+func JSONL(ctx context.Context) bool {
+	output, _ := ctx.Value(global.RefOutput).(string)
+	return output == OutputJSONL
+}
+
+// Emit writes the event as a single json line when the jsonl output format
+// was selected. Otherwise the formatted text, if any, is written instead.
+func Emit(ctx context.Context, out io.Writer, event Event, format string, a ...interface{}) error {
+	if !JSONL(ctx) {
+		if format != "" {
+			fmt.Fprintf(out, format, a...)
+		}
+		return nil
+	}
+
+	rbytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", rbytes)
+	return err
+}
+
+// Notice writes a human readable message which is not a step of its own, e.g.
+// the progress of a long running step. Notices are written to standard error
+// when the jsonl output format was selected so that every line of the output
+// remains a json event.
+func Notice(ctx context.Context, out io.Writer, format string, a ...interface{}) {
+	if JSONL(ctx) {
+		out = stderr
+	}
+	fmt.Fprintf(out, format, a...)
+}
@@ -0,0 +1,106 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/global"
+)
+
+type EventsSuite struct {
+	suite.Suite
+}
+
+func (r *EventsSuite) TestEmit() {
+	testCases := []struct {
+		output string
+		event  Event
+		format string
+		args   []interface{}
+		result string
+	}{
+		{
+			"",
+			Event{Type: TypeMigration, Resource: "users", Status: StatusApplied},
+			"migration %q successfully %s\n",
+			[]interface{}{"users", "applied"},
+			"migration \"users\" successfully applied\n",
+		},
+		{
+			OutputText,
+			Event{Type: TypeManifest, Resource: "Pod/web", Status: StatusInstalled},
+			"",
+			nil,
+			"",
+		},
+		{
+			OutputJSONL,
+			Event{
+				Type:      TypeMigration,
+				Resource:  "users",
+				Tag:       "0.0.1",
+				Direction: "up",
+				Status:    StatusApplied,
+				Duration:  1500 * time.Millisecond,
+			},
+			"migration %q successfully %s\n",
+			[]interface{}{"users", "applied"},
+			`{"type":"migration","resource":"users","tag":"0.0.1","direction":"up","status":"applied","durationMs":1500}` + "\n",
+		},
+		{
+			OutputJSONL,
+			func() Event {
+				event := Event{Type: TypeManifest, Resource: "Pod/web", Release: "web", Version: "1.0.0"}
+				event.Fail(fmt.Errorf("pods \"web\" is forbidden"))
+				return event
+			}(),
+			"",
+			nil,
+			`{"type":"manifest","resource":"Pod/web","release":"web","version":"1.0.0","status":"failed","error":"pods \"web\" is forbidden","durationMs":0}` + "\n",
+		},
+	}
+
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		ctx := context.WithValue(context.Background(), global.RefOutput, testCase.output)
+		buffer := bytes.NewBuffer(nil)
+		err := Emit(ctx, buffer, testCase.event, testCase.format, testCase.args...)
+		assert.Nil(r.T(), err, failMsg)
+		assert.Equal(r.T(), testCase.result, buffer.String(), failMsg)
+	}
+}
+
+func (r *EventsSuite) TestNotice() {
+	testCases := []struct {
+		output string
+		stdout string
+		stderr string
+	}{
+		{"", "batch 1 affected 10 rows\n", ""},
+		{OutputText, "batch 1 affected 10 rows\n", ""},
+		{OutputJSONL, "", "batch 1 affected 10 rows\n"},
+	}
+
+	defer func(w io.Writer) { stderr = w }(stderr)
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		ctx := context.WithValue(context.Background(), global.RefOutput, testCase.output)
+		stdout := bytes.NewBuffer(nil)
+		buffer := bytes.NewBuffer(nil)
+		stderr = buffer
+		Notice(ctx, stdout, "batch %d affected %d rows\n", 1, 10)
+		assert.Equal(r.T(), testCase.stdout, stdout.String(), failMsg)
+		assert.Equal(r.T(), testCase.stderr, buffer.String(), failMsg)
+	}
+}
+
+func TestEventsSuite(t *testing.T) {
+	suite.Run(t, new(EventsSuite))
+}
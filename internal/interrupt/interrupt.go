@@ -0,0 +1,75 @@
+// Package interrupt implements graceful handling of termination signals.
+package interrupt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/trivigy/migrate/v2/internal/events"
+)
+
+// ErrStopped is returned by commands which stopped early because a stop was
+// requested by an interrupt signal.
+var ErrStopped = errors.New("stopped by interrupt")
+
+type contextKey struct{}
+
+// state holds the stop request flag shared by all of the derived contexts.
+type state struct {
+	stopping int32
+}
+
+// Context returns a copy of the parent which handles SIGINT and SIGTERM. The
+// first signal requests the command to stop once the current step, e.g. a
+// migration or a kubernetes object, completes. The second signal cancels the
+// context immediately. Calling cancel releases the signal handlers.
+func Context(parent context.Context, out io.Writer) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	ctx = context.WithValue(ctx, contextKey{}, &state{})
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			events.Notice(ctx, out, "stopping after the current step, interrupt again to abort\n")
+			Stop(ctx)
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Stop requests the commands running with the context to stop once their
+// current step completes. Contexts not created by Context are left unchanged.
+func Stop(ctx context.Context) {
+	if s, ok := ctx.Value(contextKey{}).(*state); ok {
+		atomic.StoreInt32(&s.stopping, 1)
+	}
+}
+
+// Err returns a non-nil error when the command must not proceed with the next
+// step, either because a stop was requested or because the context is done.
+func Err(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if s, ok := ctx.Value(contextKey{}).(*state); ok && atomic.LoadInt32(&s.stopping) == 1 {
+		return ErrStopped
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package interrupt
+
+import (
+	"bytes"
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type InterruptSuite struct {
+	suite.Suite
+}
+
+func (r *InterruptSuite) TestStop() {
+	ctx, cancel := Context(context.Background(), bytes.NewBuffer(nil))
+	defer cancel()
+
+	assert.Nil(r.T(), Err(ctx))
+	Stop(ctx)
+	assert.Equal(r.T(), ErrStopped, Err(ctx))
+	cancel()
+	assert.Equal(r.T(), context.Canceled, Err(ctx))
+}
+
+func (r *InterruptSuite) TestStopUnmanaged() {
+	ctx, cancel := context.WithCancel(context.Background())
+	Stop(ctx)
+	assert.Nil(r.T(), Err(ctx))
+	cancel()
+	assert.Equal(r.T(), context.Canceled, Err(ctx))
+}
+
+func (r *InterruptSuite) TestSignals() {
+	buffer := bytes.NewBuffer(nil)
+	ctx, cancel := Context(context.Background(), buffer)
+	defer cancel()
+
+	assert.Nil(r.T(), syscall.Kill(syscall.Getpid(), syscall.SIGINT))
+	assert.Eventually(r.T(), func() bool {
+		return Err(ctx) == ErrStopped
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(r.T(), "stopping after the current step, interrupt again to abort\n", buffer.String())
+
+	assert.Nil(r.T(), syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+	assert.Eventually(r.T(), func() bool {
+		return Err(ctx) == context.Canceled
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestInterruptSuite(t *testing.T) {
+	suite.Run(t, new(InterruptSuite))
+}
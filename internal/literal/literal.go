@@ -0,0 +1,268 @@
+// Package literal renders in-memory values as Go composite literal source.
+package literal
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	quantityType = reflect.TypeOf(resource.Quantity{})
+	intOrStrType = reflect.TypeOf(intstr.IntOrString{})
+	timeType     = reflect.TypeOf(v1meta.Time{})
+)
+
+// Printer converts values into Go source code while keeping track of the
+// packages which the rendered code needs to import.
+type Printer struct {
+	imports map[string]string
+}
+
+// NewPrinter returns a new printer with no recorded imports.
+func NewPrinter() *Printer {
+	return &Printer{imports: map[string]string{}}
+}
+
+// Imports returns the import paths, keyed by their alias, referenced by the
+// values printed so far.
+func (r *Printer) Imports() map[string]string {
+	imports := make(map[string]string, len(r.imports))
+	for path, alias := range r.imports {
+		imports[alias] = path
+	}
+	return imports
+}
+
+// Print renders the value as a Go expression. The output is not indented and
+// is expected to be passed through format.Source.
+func (r *Printer) Print(value interface{}) (string, error) {
+	b := &strings.Builder{}
+	if err := r.print(b, reflect.ValueOf(value), true); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// print writes the value into the builder. When typed is false the type of
+// composite literals is elided, which is only valid inside of slices and maps
+// whose element type is known.
+func (r *Printer) print(b *strings.Builder, v reflect.Value, typed bool) error {
+	switch v.Type() {
+	case quantityType:
+		q := v.Interface().(resource.Quantity)
+		fmt.Fprintf(b, "%s.MustParse(%q)", r.pkg(quantityType), q.String())
+		return nil
+	case intOrStrType:
+		i := v.Interface().(intstr.IntOrString)
+		if i.Type == intstr.String {
+			fmt.Fprintf(b, "%s.FromString(%q)", r.pkg(intOrStrType), i.StrVal)
+		} else {
+			fmt.Fprintf(b, "%s.FromInt(%d)", r.pkg(intOrStrType), i.IntVal)
+		}
+		return nil
+	case timeType:
+		t := v.Interface().(v1meta.Time)
+		fmt.Fprintf(b, "%s.Unix(%d, 0)", r.pkg(timeType), t.Unix())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return nil
+		}
+		return r.print(b, v.Elem(), true)
+	case reflect.Ptr:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return nil
+		}
+
+		elem := v.Elem()
+		switch elem.Kind() {
+		case reflect.Struct:
+			if typed {
+				b.WriteString("&")
+			}
+			return r.print(b, elem, typed)
+		default:
+			// pointers to basic values use the same single element slice
+			// idiom found throughout the repository.
+			fmt.Fprintf(b, "&[]%s{", r.typeName(elem.Type()))
+			if err := r.print(b, elem, false); err != nil {
+				return err
+			}
+			b.WriteString("}[0]")
+			return nil
+		}
+	case reflect.Struct:
+		if typed {
+			b.WriteString(r.typeName(v.Type()))
+		}
+		b.WriteString("{\n")
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if isZero(v.Field(i)) {
+				continue
+			}
+			if field.PkgPath != "" {
+				return fmt.Errorf("type %s not supported", v.Type())
+			}
+
+			b.WriteString(field.Name + ": ")
+			if err := r.print(b, v.Field(i), true); err != nil {
+				return err
+			}
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+		return nil
+	case reflect.Slice:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return nil
+		}
+
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			name := "[]byte"
+			if v.Type().Name() != "" {
+				name = r.typeName(v.Type())
+			}
+			fmt.Fprintf(b, "%s(%q)", name, v.Bytes())
+			return nil
+		}
+
+		if typed {
+			b.WriteString(r.typeName(v.Type()))
+		}
+		b.WriteString("{\n")
+		for i := 0; i < v.Len(); i++ {
+			if err := r.print(b, v.Index(i), false); err != nil {
+				return err
+			}
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return nil
+		}
+
+		if typed {
+			b.WriteString(r.typeName(v.Type()))
+		}
+		b.WriteString("{\n")
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			if err := r.print(b, key, false); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			if err := r.print(b, v.MapIndex(key), false); err != nil {
+				return err
+			}
+			b.WriteString(",\n")
+		}
+		b.WriteString("}")
+		return nil
+	case reflect.String:
+		// untyped constants are assignable to named types so the conversion
+		// is only needed where the type cannot be inferred.
+		b.WriteString(strconv.Quote(v.String()))
+		return nil
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+		return nil
+	default:
+		return fmt.Errorf("type %s not supported", v.Type())
+	}
+}
+
+// typeName returns the qualified source representation of the type.
+func (r *Printer) typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + r.typeName(t.Elem())
+	case reflect.Slice:
+		if t.Name() == "" && t.Elem().Kind() == reflect.Uint8 && t.Elem().Name() == "uint8" {
+			return "[]byte"
+		}
+		if t.Name() == "" {
+			return "[]" + r.typeName(t.Elem())
+		}
+	case reflect.Map:
+		if t.Name() == "" {
+			return "map[" + r.typeName(t.Key()) + "]" + r.typeName(t.Elem())
+		}
+	}
+
+	if t.PkgPath() == "" {
+		return t.String()
+	}
+	return r.pkg(t) + "." + t.Name()
+}
+
+// pkg returns the alias of the package declaring the type and records the
+// import.
+func (r *Printer) pkg(t reflect.Type) string {
+	alias, ok := r.imports[t.PkgPath()]
+	if !ok {
+		alias = Alias(t.PkgPath())
+		taken := map[string]bool{}
+		for _, other := range r.imports {
+			taken[other] = true
+		}
+		for i := 2; taken[alias]; i++ {
+			alias = fmt.Sprintf("%s%d", Alias(t.PkgPath()), i)
+		}
+		r.imports[t.PkgPath()] = alias
+	}
+	return alias
+}
+
+// Alias returns the import alias used for the package path. Kubernetes api
+// groups are aliased by version followed by group, e.g. `v1apps`, which
+// matches how they are imported throughout the repository.
+func Alias(path string) string {
+	parts := strings.Split(path, "/")
+	switch {
+	case strings.HasPrefix(path, "k8s.io/api/") && len(parts) == 4:
+		return parts[3] + strings.Replace(parts[2], ".", "", -1)
+	case path == "k8s.io/apimachinery/pkg/apis/meta/v1":
+		return "v1meta"
+	default:
+		return strings.Replace(parts[len(parts)-1], "-", "", -1)
+	}
+}
+
+// isZero reports whether the value holds the zero value for its type.
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
@@ -0,0 +1,121 @@
+package literal
+
+import (
+	"fmt"
+	"go/format"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type LiteralSuite struct {
+	suite.Suite
+}
+
+func (r *LiteralSuite) TestPrint() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		value      interface{}
+		output     string
+		imports    map[string]string
+	}{
+		{
+			false, "",
+			&v1core.ConfigMap{
+				ObjectMeta: v1meta.ObjectMeta{Name: "example"},
+				Data:       map[string]string{"b": "2", "a": "1"},
+			},
+			"&v1core.ConfigMap{\n" +
+				"\tObjectMeta: v1meta.ObjectMeta{\n" +
+				"\t\tName: \"example\",\n" +
+				"\t},\n" +
+				"\tData: map[string]string{\n" +
+				"\t\t\"a\": \"1\",\n" +
+				"\t\t\"b\": \"2\",\n" +
+				"\t},\n" +
+				"}",
+			map[string]string{
+				"v1core": "k8s.io/api/core/v1",
+				"v1meta": "k8s.io/apimachinery/pkg/apis/meta/v1",
+			},
+		},
+		{
+			false, "",
+			v1core.ServicePort{
+				Port:       80,
+				Protocol:   v1core.ProtocolTCP,
+				TargetPort: intstr.FromString("http"),
+			},
+			"v1core.ServicePort{\n" +
+				"\tProtocol:   \"TCP\",\n" +
+				"\tPort:       80,\n" +
+				"\tTargetPort: intstr.FromString(\"http\"),\n" +
+				"}",
+			map[string]string{
+				"v1core": "k8s.io/api/core/v1",
+				"intstr": "k8s.io/apimachinery/pkg/util/intstr",
+			},
+		},
+		{
+			false, "",
+			v1core.ResourceList{"cpu": resource.MustParse("100m")},
+			"v1core.ResourceList{\n" +
+				"\t\"cpu\": resource.MustParse(\"100m\"),\n" +
+				"}",
+			map[string]string{
+				"v1core":   "k8s.io/api/core/v1",
+				"resource": "k8s.io/apimachinery/pkg/api/resource",
+			},
+		},
+		{
+			false, "",
+			[]*int32{&[]int32{1}[0]},
+			"[]*int32{\n" +
+				"\t&[]int32{1}[0],\n" +
+				"}",
+			map[string]string{},
+		},
+		{
+			true, "type chan int not supported",
+			make(chan int),
+			"",
+			nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			printer := NewPrinter()
+			output, err := printer.Print(tc.value)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			source, err := format.Source([]byte(output))
+			if err != nil {
+				panic(err.Error())
+			}
+
+			assert.Equal(r.T(), tc.output, string(source))
+			assert.Equal(r.T(), tc.imports, printer.Imports())
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestLiteralSuite(t *testing.T) {
+	suite.Run(t, new(LiteralSuite))
+}
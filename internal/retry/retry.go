@@ -6,16 +6,18 @@ import (
 	"time"
 )
 
-// Do executes a function at a given interval. The context may be used to cancel
-// the execution. For example for timing out a long running repeating task.
+// Do executes a function at a given interval for as long as it reports that
+// it should be retried. A function which stops the retries with an error has
+// its error returned. The context may be used to cancel the execution. For
+// example for timing out a long running repeating task.
 func Do(ctx context.Context, interval time.Duration, fn func() (bool, error)) error {
 	ticker := time.NewTicker(interval)
 	for {
 		select {
 		case <-ticker.C:
-			if cond, err := fn(); !cond && err == nil {
+			if cond, err := fn(); !cond {
 				ticker.Stop()
-				return nil
+				return err
 			}
 		case <-ctx.Done():
 			ticker.Stop()
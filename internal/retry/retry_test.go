@@ -2,6 +2,7 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -28,6 +29,22 @@ func (r *RetrySuite) TestRetry_Do() {
 	assert.True(r.T(), count >= 4)
 }
 
+func (r *RetrySuite) TestRetry_DoError() {
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+
+	count := 0
+	err := Do(ctx, 100*time.Millisecond, func() (bool, error) {
+		count++
+		if count >= 2 {
+			return false, errors.New("failed")
+		}
+		return true, errors.New("retried")
+	})
+	assert.EqualError(r.T(), err, "failed")
+	assert.Equal(r.T(), 2, count)
+}
+
 func TestRouterSuite(t *testing.T) {
 	suite.Run(t, new(RetrySuite))
 }
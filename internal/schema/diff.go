@@ -0,0 +1,287 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Diff computes the statements which transition the database from the current
+// schema into the desired one. The down statements revert the transition.
+func Diff(dialect string, current, desired *Schema) ([]string, []string, error) {
+	up, err := changes(dialect, current, desired)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	down, err := changes(dialect, desired, current)
+	if err != nil {
+		return nil, nil, err
+	}
+	return up, down, nil
+}
+
+// changes returns the statements required to move from one schema to another.
+func changes(dialect string, from, to *Schema) ([]string, error) {
+	if dialect != "postgres" && dialect != "sqlite3" {
+		return nil, fmt.Errorf("dialect %q not supported", dialect)
+	}
+
+	stmts := make([]string, 0)
+	for _, name := range to.TableNames() {
+		if _, ok := from.Tables[name]; ok {
+			continue
+		}
+		stmts = append(stmts, createTable(dialect, name, to.Tables[name]))
+		for _, index := range to.Tables[name].Indexes {
+			stmts = append(stmts, index.Definition)
+		}
+	}
+
+	for _, name := range to.TableNames() {
+		src, ok := from.Tables[name]
+		if !ok {
+			continue
+		}
+		stmts = append(stmts, alterTable(dialect, src, to.Tables[name])...)
+	}
+
+	for _, name := range from.TableNames() {
+		if _, ok := to.Tables[name]; ok {
+			continue
+		}
+		stmts = append(stmts, fmt.Sprintf("DROP TABLE %s", quote(name)))
+	}
+	return stmts, nil
+}
+
+// alterTable returns the statements needed to turn table src into table dst.
+func alterTable(dialect string, src, dst *Table) []string {
+	stmts := make([]string, 0)
+	if dialect == "sqlite3" && requiresRebuild(src, dst) {
+		return rebuildTable(dialect, src, dst)
+	}
+
+	for _, index := range src.Indexes {
+		if other := dst.Index(index.Name); other == nil || other.Definition != index.Definition {
+			stmts = append(stmts, fmt.Sprintf("DROP INDEX %s", quote(index.Name)))
+		}
+	}
+
+	for _, unique := range src.Uniques {
+		if dst.Unique(unique) == nil {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s DROP CONSTRAINT %s",
+				quote(dst.Name), quote(unique.Name),
+			))
+		}
+	}
+
+	if dialect == "postgres" && !equalPrimaryKeys(src, dst) && len(src.PrimaryKey) > 0 {
+		stmts = append(stmts, fmt.Sprintf(
+			"ALTER TABLE %s DROP CONSTRAINT %s",
+			quote(dst.Name), quote(src.PrimaryKeyName),
+		))
+	}
+
+	for _, column := range dst.Columns {
+		if src.Column(column.Name) == nil {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s ADD COLUMN %s",
+				quote(dst.Name), columnDefinition(dialect, column),
+			))
+		}
+	}
+
+	for _, column := range dst.Columns {
+		prev := src.Column(column.Name)
+		if prev == nil {
+			continue
+		}
+
+		prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", quote(dst.Name), quote(column.Name))
+		if prev.Type != column.Type {
+			stmts = append(stmts, fmt.Sprintf("%s TYPE %s", prefix, column.Type))
+		}
+		if prev.NotNull != column.NotNull {
+			if column.NotNull {
+				stmts = append(stmts, prefix+" SET NOT NULL")
+			} else {
+				stmts = append(stmts, prefix+" DROP NOT NULL")
+			}
+		}
+		if !equalDefaults(prev.Default, column.Default) {
+			if column.Default != nil {
+				stmts = append(stmts, fmt.Sprintf("%s SET DEFAULT %s", prefix, *column.Default))
+			} else {
+				stmts = append(stmts, prefix+" DROP DEFAULT")
+			}
+		}
+	}
+
+	for _, column := range src.Columns {
+		if dst.Column(column.Name) == nil {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s DROP COLUMN %s",
+				quote(dst.Name), quote(column.Name),
+			))
+		}
+	}
+
+	if dialect == "postgres" && !equalPrimaryKeys(src, dst) && len(dst.PrimaryKey) > 0 {
+		stmts = append(stmts, fmt.Sprintf(
+			"ALTER TABLE %s ADD %s",
+			quote(dst.Name), primaryKeyDefinition(dst),
+		))
+	}
+
+	for _, unique := range dst.Uniques {
+		if src.Unique(unique) == nil {
+			stmts = append(stmts, fmt.Sprintf(
+				"ALTER TABLE %s ADD %s",
+				quote(dst.Name), uniqueDefinition(unique),
+			))
+		}
+	}
+
+	for _, index := range dst.Indexes {
+		if other := src.Index(index.Name); other == nil || other.Definition != index.Definition {
+			stmts = append(stmts, index.Definition)
+		}
+	}
+	return stmts
+}
+
+// requiresRebuild reports whether sqlite is unable to perform the transition
+// with ALTER TABLE statements. Sqlite is only able to append columns which
+// are nullable or carry a default value and cannot alter table constraints.
+func requiresRebuild(src, dst *Table) bool {
+	if !equalPrimaryKeys(src, dst) || !reflect.DeepEqual(src.Uniques, dst.Uniques) {
+		return true
+	}
+
+	for _, column := range src.Columns {
+		other := dst.Column(column.Name)
+		if other == nil ||
+			other.Type != column.Type ||
+			other.NotNull != column.NotNull ||
+			!equalDefaults(other.Default, column.Default) {
+			return true
+		}
+	}
+
+	for _, column := range dst.Columns {
+		if src.Column(column.Name) == nil && column.NotNull && column.Default == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// rebuildTable recreates the table under a temporary name, copies the shared
+// columns over and swaps the tables.
+func rebuildTable(dialect string, src, dst *Table) []string {
+	tmp := dst.Name + "__migrate_tmp"
+	shared := make([]string, 0)
+	for _, column := range dst.Columns {
+		if src.Column(column.Name) != nil {
+			shared = append(shared, column.Name)
+		}
+	}
+
+	stmts := []string{createTable(dialect, tmp, dst)}
+	if len(shared) > 0 {
+		stmts = append(stmts, fmt.Sprintf(
+			"INSERT INTO %s (%s) SELECT %s FROM %s",
+			quote(tmp), quoteAll(shared), quoteAll(shared), quote(src.Name),
+		))
+	}
+	stmts = append(stmts,
+		fmt.Sprintf("DROP TABLE %s", quote(src.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", quote(tmp), quote(dst.Name)),
+	)
+	for _, index := range dst.Indexes {
+		stmts = append(stmts, index.Definition)
+	}
+	return stmts
+}
+
+// createTable renders the CREATE TABLE statement for the table definition.
+func createTable(dialect, name string, table *Table) string {
+	lines := make([]string, 0, len(table.Columns)+len(table.Uniques)+1)
+	for _, column := range table.Columns {
+		lines = append(lines, "\t"+columnDefinition(dialect, column))
+	}
+	if len(table.PrimaryKey) > 0 {
+		lines = append(lines, "\t"+primaryKeyDefinition(table))
+	}
+	for _, unique := range table.Uniques {
+		lines = append(lines, "\t"+uniqueDefinition(unique))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", quote(name), strings.Join(lines, ",\n"))
+}
+
+// columnDefinition renders a single column definition.
+func columnDefinition(dialect string, column Column) string {
+	kind := column.Type
+	dflt := column.Default
+
+	// postgres reports serial columns as integers backed by a sequence which
+	// does not exist until the column is created, so reverse the expansion.
+	if dialect == "postgres" && dflt != nil && strings.HasPrefix(*dflt, "nextval(") {
+		switch kind {
+		case "smallint":
+			kind, dflt = "smallserial", nil
+		case "integer":
+			kind, dflt = "serial", nil
+		case "bigint":
+			kind, dflt = "bigserial", nil
+		}
+	}
+
+	def := quote(column.Name) + " " + kind
+	if column.NotNull {
+		def += " NOT NULL"
+	}
+	if dflt != nil {
+		def += " DEFAULT " + *dflt
+	}
+	return def
+}
+
+// primaryKeyDefinition renders the primary key constraint of the table.
+func primaryKeyDefinition(table *Table) string {
+	def := fmt.Sprintf("PRIMARY KEY (%s)", quoteAll(table.PrimaryKey))
+	if table.PrimaryKeyName != "" {
+		def = fmt.Sprintf("CONSTRAINT %s %s", quote(table.PrimaryKeyName), def)
+	}
+	return def
+}
+
+// uniqueDefinition renders a single unique constraint.
+func uniqueDefinition(unique Unique) string {
+	def := fmt.Sprintf("UNIQUE (%s)", quoteAll(unique.Columns))
+	if unique.Name != "" {
+		def = fmt.Sprintf("CONSTRAINT %s %s", quote(unique.Name), def)
+	}
+	return def
+}
+
+func equalPrimaryKeys(a, b *Table) bool {
+	return reflect.DeepEqual(a.PrimaryKey, b.PrimaryKey) && a.PrimaryKeyName == b.PrimaryKeyName
+}
+
+func equalDefaults(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
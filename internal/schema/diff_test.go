@@ -0,0 +1,252 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type DiffSuite struct {
+	suite.Suite
+}
+
+func (r *DiffSuite) TestDiff() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		current    string
+		desired    string
+		up         []string
+		down       []string
+	}{
+		{
+			false, "",
+			`CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL)`,
+			`CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL)`,
+			[]string{},
+			[]string{},
+		},
+		{
+			false, "",
+			``,
+			`CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL);
+			CREATE INDEX users_name ON users (name);`,
+			[]string{
+				"CREATE TABLE \"users\" (\n" +
+					"\t\"id\" integer,\n" +
+					"\t\"name\" text NOT NULL,\n" +
+					"\tPRIMARY KEY (\"id\")\n" +
+					")",
+				"CREATE INDEX users_name ON users (name)",
+			},
+			[]string{
+				"DROP TABLE \"users\"",
+			},
+		},
+		{
+			false, "",
+			`CREATE TABLE users (id integer PRIMARY KEY)`,
+			`CREATE TABLE users (id integer PRIMARY KEY, email text DEFAULT '');
+			CREATE UNIQUE INDEX users_email ON users (email);`,
+			[]string{
+				"ALTER TABLE \"users\" ADD COLUMN \"email\" text DEFAULT ''",
+				"CREATE UNIQUE INDEX users_email ON users (email)",
+			},
+			[]string{
+				"CREATE TABLE \"users__migrate_tmp\" (\n" +
+					"\t\"id\" integer,\n" +
+					"\tPRIMARY KEY (\"id\")\n" +
+					")",
+				"INSERT INTO \"users__migrate_tmp\" (\"id\") SELECT \"id\" FROM \"users\"",
+				"DROP TABLE \"users\"",
+				"ALTER TABLE \"users__migrate_tmp\" RENAME TO \"users\"",
+			},
+		},
+		{
+			false, "",
+			`CREATE TABLE users (id integer PRIMARY KEY, email text)`,
+			`CREATE TABLE users (id integer PRIMARY KEY, email text, UNIQUE (email))`,
+			[]string{
+				"CREATE TABLE \"users__migrate_tmp\" (\n" +
+					"\t\"id\" integer,\n" +
+					"\t\"email\" text,\n" +
+					"\tPRIMARY KEY (\"id\"),\n" +
+					"\tUNIQUE (\"email\")\n" +
+					")",
+				"INSERT INTO \"users__migrate_tmp\" (\"id\", \"email\") SELECT \"id\", \"email\" FROM \"users\"",
+				"DROP TABLE \"users\"",
+				"ALTER TABLE \"users__migrate_tmp\" RENAME TO \"users\"",
+			},
+			[]string{
+				"CREATE TABLE \"users__migrate_tmp\" (\n" +
+					"\t\"id\" integer,\n" +
+					"\t\"email\" text,\n" +
+					"\tPRIMARY KEY (\"id\")\n" +
+					")",
+				"INSERT INTO \"users__migrate_tmp\" (\"id\", \"email\") SELECT \"id\", \"email\" FROM \"users\"",
+				"DROP TABLE \"users\"",
+				"ALTER TABLE \"users__migrate_tmp\" RENAME TO \"users\"",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			current := r.inspect(tc.current)
+			desired := r.inspect(tc.desired)
+
+			up, down, err := Diff("sqlite3", current, desired)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			assert.Equal(r.T(), tc.up, up)
+			assert.Equal(r.T(), tc.down, down)
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func (r *DiffSuite) TestDiffPostgres() {
+	serial := "nextval('users_id_seq'::regclass)"
+	schemaOf := func(tables ...*Table) *Schema {
+		schema := &Schema{Tables: map[string]*Table{}}
+		for _, table := range tables {
+			schema.Tables[table.Name] = table
+		}
+		return schema
+	}
+
+	testCases := []struct {
+		current *Schema
+		desired *Schema
+		up      []string
+		down    []string
+	}{
+		{
+			schemaOf(),
+			schemaOf(&Table{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer", NotNull: true, Default: &serial, Position: 1},
+					{Name: "email", Type: "text", Position: 2},
+				},
+				PrimaryKey:     []string{"id"},
+				PrimaryKeyName: "users_pk",
+				Uniques:        []Unique{{Name: "users_email_key", Columns: []string{"email"}}},
+			}),
+			[]string{
+				"CREATE TABLE \"users\" (\n" +
+					"\t\"id\" serial NOT NULL,\n" +
+					"\t\"email\" text,\n" +
+					"\tCONSTRAINT \"users_pk\" PRIMARY KEY (\"id\"),\n" +
+					"\tCONSTRAINT \"users_email_key\" UNIQUE (\"email\")\n" +
+					")",
+			},
+			[]string{
+				"DROP TABLE \"users\"",
+			},
+		},
+		{
+			schemaOf(&Table{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer", NotNull: true, Position: 1},
+					{Name: "email", Type: "text", Position: 2},
+				},
+				PrimaryKey:     []string{"id"},
+				PrimaryKeyName: "users_pk",
+			}),
+			schemaOf(&Table{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "integer", NotNull: true, Position: 1},
+					{Name: "email", Type: "text", NotNull: true, Position: 2},
+				},
+				PrimaryKey:     []string{"id", "email"},
+				PrimaryKeyName: "users_pk",
+				Uniques:        []Unique{{Name: "users_email_key", Columns: []string{"email"}}},
+			}),
+			[]string{
+				"ALTER TABLE \"users\" DROP CONSTRAINT \"users_pk\"",
+				"ALTER TABLE \"users\" ALTER COLUMN \"email\" SET NOT NULL",
+				"ALTER TABLE \"users\" ADD CONSTRAINT \"users_pk\" PRIMARY KEY (\"id\", \"email\")",
+				"ALTER TABLE \"users\" ADD CONSTRAINT \"users_email_key\" UNIQUE (\"email\")",
+			},
+			[]string{
+				"ALTER TABLE \"users\" DROP CONSTRAINT \"users_email_key\"",
+				"ALTER TABLE \"users\" DROP CONSTRAINT \"users_pk\"",
+				"ALTER TABLE \"users\" ALTER COLUMN \"email\" DROP NOT NULL",
+				"ALTER TABLE \"users\" ADD CONSTRAINT \"users_pk\" PRIMARY KEY (\"id\")",
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		up, down, err := Diff("postgres", testCase.current, testCase.desired)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.up, up, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.down, down, "testCase: %d", i)
+	}
+}
+
+func (r *DiffSuite) TestLoadFile() {
+	file, err := ioutil.TempFile(os.TempDir(), "schema-*.sql")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(`
+		CREATE TABLE migrations (tag text);
+		CREATE TABLE users (id integer PRIMARY KEY, name text NOT NULL);
+	`); err != nil {
+		panic(err)
+	}
+	if err := file.Close(); err != nil {
+		panic(err)
+	}
+
+	schema, err := LoadFile(nil, "sqlite3", file.Name(), "migrations")
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), []string{"users"}, schema.TableNames())
+	assert.Equal(r.T(), []string{"id"}, schema.Tables["users"].PrimaryKey)
+	assert.True(r.T(), schema.Tables["users"].Column("name").NotNull)
+}
+
+func (r *DiffSuite) inspect(query string) *Schema {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if query != "" {
+		if _, err := db.Exec(query); err != nil {
+			panic(err)
+		}
+	}
+
+	schema, err := Inspect(db, "sqlite3")
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func TestDiffSuite(t *testing.T) {
+	suite.Run(t, new(DiffSuite))
+}
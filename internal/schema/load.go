@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	// sqlite driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// LoadFile builds the schema described by the sql file found at path. The file
+// is executed inside of a scratch space so that the database reachable through
+// db is never modified. Sqlite files are loaded into an in-memory database
+// while postgres files are loaded into a throwaway schema inside of a
+// transaction which is always rolled back.
+func LoadFile(db *sql.DB, dialect, path string, exclude ...string) (*Schema, error) {
+	rbytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dialect {
+	case "sqlite3":
+		shadow, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			return nil, err
+		}
+		defer shadow.Close()
+
+		// every connection to :memory: opens a separate database.
+		shadow.SetMaxOpenConns(1)
+		if _, err := shadow.Exec(string(rbytes)); err != nil {
+			return nil, fmt.Errorf("failed loading schema file %q (%s)", path, err)
+		}
+		return Inspect(shadow, dialect, exclude...)
+	case "postgres":
+		tx, err := db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		name := fmt.Sprintf("migrate_shadow_%d", time.Now().UnixNano())
+		if _, err := tx.Exec(fmt.Sprintf("CREATE SCHEMA %s", quote(name))); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(fmt.Sprintf("SET LOCAL search_path TO %s", quote(name))); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(string(rbytes)); err != nil {
+			return nil, fmt.Errorf("failed loading schema file %q (%s)", path, err)
+		}
+		return Inspect(tx, dialect, exclude...)
+	default:
+		return nil, fmt.Errorf("dialect %q not supported", dialect)
+	}
+}
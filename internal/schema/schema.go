@@ -0,0 +1,374 @@
+// Package schema implements database schema introspection and comparison.
+package schema
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema defines a snapshot of the tables, columns and indexes found in a
+// database.
+type Schema struct {
+	Tables map[string]*Table `json:"tables" yaml:"tables"`
+}
+
+// Table defines a single database table definition. Sqlite does not keep the
+// names of table constraints therefore those are only known for postgres.
+type Table struct {
+	Name           string   `json:"name" yaml:"name"`
+	Columns        []Column `json:"columns" yaml:"columns"`
+	PrimaryKey     []string `json:"primaryKey,omitempty" yaml:"primaryKey,omitempty"`
+	PrimaryKeyName string   `json:"primaryKeyName,omitempty" yaml:"primaryKeyName,omitempty"`
+	Uniques        []Unique `json:"uniques,omitempty" yaml:"uniques,omitempty"`
+	Indexes        []Index  `json:"indexes,omitempty" yaml:"indexes,omitempty"`
+}
+
+// Column defines a single table column definition.
+type Column struct {
+	Name     string  `json:"name" yaml:"name"`
+	Type     string  `json:"type" yaml:"type"`
+	NotNull  bool    `json:"notNull,omitempty" yaml:"notNull,omitempty"`
+	Default  *string `json:"default,omitempty" yaml:"default,omitempty"`
+	Position int     `json:"position" yaml:"position"`
+}
+
+// Unique defines a single table unique constraint definition.
+type Unique struct {
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Columns []string `json:"columns" yaml:"columns"`
+}
+
+// Index defines a single table index definition.
+type Index struct {
+	Name       string `json:"name" yaml:"name"`
+	Definition string `json:"definition" yaml:"definition"`
+}
+
+// Queryer describes an object capable of running read queries. Both *sql.DB
+// and *sql.Tx satisfy this interface.
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Inspect reads the schema of the database reachable through db. Tables named
+// in exclude are skipped, which is used to hide bookkeeping tables.
+func Inspect(db Queryer, dialect string, exclude ...string) (*Schema, error) {
+	var schema *Schema
+	var err error
+	switch dialect {
+	case "postgres":
+		schema, err = inspectPostgres(db)
+	case "sqlite3":
+		schema, err = inspectSqlite(db)
+	default:
+		return nil, fmt.Errorf("dialect %q not supported", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range exclude {
+		delete(schema.Tables, name)
+	}
+	return schema, nil
+}
+
+// TableNames returns the sorted list of table names in the schema.
+func (r Schema) TableNames() []string {
+	names := make([]string, 0, len(r.Tables))
+	for name := range r.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Column returns the column with the specified name or nil if not found.
+func (r Table) Column(name string) *Column {
+	for i := range r.Columns {
+		if r.Columns[i].Name == name {
+			return &r.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Unique returns the unique constraint equal to the specified one or nil if
+// not found.
+func (r Table) Unique(unique Unique) *Unique {
+	for i := range r.Uniques {
+		if reflect.DeepEqual(r.Uniques[i], unique) {
+			return &r.Uniques[i]
+		}
+	}
+	return nil
+}
+
+// Index returns the index with the specified name or nil if not found.
+func (r Table) Index(name string) *Index {
+	for i := range r.Indexes {
+		if r.Indexes[i].Name == name {
+			return &r.Indexes[i]
+		}
+	}
+	return nil
+}
+
+func inspectPostgres(db Queryer) (*Schema, error) {
+	schema := &Schema{Tables: map[string]*Table{}}
+
+	rows, err := db.Query(`
+		SELECT c.relname, a.attname, format_type(a.atttypid, a.atttypmod),
+			a.attnotnull, pg_get_expr(d.adbin, d.adrelid), a.attnum
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE n.nspname = current_schema() AND c.relkind = 'r'
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table string
+		var column Column
+		var dflt sql.NullString
+		if err := rows.Scan(
+			&table, &column.Name, &column.Type,
+			&column.NotNull, &dflt, &column.Position,
+		); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if dflt.Valid {
+			column.Default = &dflt.String
+		}
+		tbl := tableOf(schema, table)
+		tbl.Columns = append(tbl.Columns, column)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT c.relname, k.conname, k.contype, a.attname
+		FROM pg_constraint k
+		JOIN pg_class c ON c.oid = k.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = ANY(k.conkey)
+		WHERE n.nspname = current_schema() AND k.contype IN ('p', 'u')
+		ORDER BY c.relname, k.conname, array_position(k.conkey, a.attnum)`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table, name, kind, column string
+		if err := rows.Scan(&table, &name, &kind, &column); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tbl := tableOf(schema, table)
+		if kind == "p" {
+			tbl.PrimaryKeyName = name
+			tbl.PrimaryKey = append(tbl.PrimaryKey, column)
+			continue
+		}
+
+		if n := len(tbl.Uniques); n == 0 || tbl.Uniques[n-1].Name != name {
+			tbl.Uniques = append(tbl.Uniques, Unique{Name: name})
+		}
+		unique := &tbl.Uniques[len(tbl.Uniques)-1]
+		unique.Columns = append(unique.Columns, column)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`
+		SELECT tablename, indexname, indexdef, schemaname
+		FROM pg_indexes
+		WHERE schemaname = current_schema()
+			AND indexname NOT IN (
+				SELECT conname FROM pg_constraint WHERE contype IN ('p', 'u')
+			)
+		ORDER BY tablename, indexname`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table, namespace string
+		var index Index
+		if err := rows.Scan(&table, &index.Name, &index.Definition, &namespace); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		// strips the schema qualifier so that definitions compare equally
+		// regardless of which schema they were inspected from.
+		index.Definition = strings.Replace(index.Definition, " "+namespace+".", " ", 1)
+		tbl := tableOf(schema, table)
+		tbl.Indexes = append(tbl.Indexes, index)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func inspectSqlite(db Queryer) (*Schema, error) {
+	schema := &Schema{Tables: map[string]*Table{}}
+
+	rows, err := db.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	for _, name := range tables {
+		tbl := tableOf(schema, name)
+		rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", quote(name)))
+		if err != nil {
+			return nil, err
+		}
+		pks := map[int]string{}
+		for rows.Next() {
+			var column Column
+			var dflt sql.NullString
+			var pk int
+			if err := rows.Scan(
+				&column.Position, &column.Name, &column.Type,
+				&column.NotNull, &dflt, &pk,
+			); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			column.Position++
+			if dflt.Valid {
+				column.Default = &dflt.String
+			}
+			if pk > 0 {
+				pks[pk] = column.Name
+			}
+			tbl.Columns = append(tbl.Columns, column)
+		}
+		if err := closeRows(rows); err != nil {
+			return nil, err
+		}
+		for i := 1; i <= len(pks); i++ {
+			tbl.PrimaryKey = append(tbl.PrimaryKey, pks[i])
+		}
+
+		if tbl.Uniques, err = inspectSqliteUniques(db, name); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err = db.Query(`
+		SELECT tbl_name, name, sql FROM sqlite_master
+		WHERE type = 'index' AND sql IS NOT NULL
+		ORDER BY tbl_name, name`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var table string
+		var index Index
+		if err := rows.Scan(&table, &index.Name, &index.Definition); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tbl := tableOf(schema, table)
+		tbl.Indexes = append(tbl.Indexes, index)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// inspectSqliteUniques reads the unique constraints of the table. Sqlite backs
+// these with automatic indexes which carry generated names only.
+func inspectSqliteUniques(db Queryer, table string) ([]Unique, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", quote(table)))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var seq, unique, partial int
+		var name, origin string
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if origin == "u" {
+			names = append(names, name)
+		}
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var uniques []Unique
+	for _, name := range names {
+		rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", quote(name)))
+		if err != nil {
+			return nil, err
+		}
+		var unique Unique
+		for rows.Next() {
+			var seqno, cid int
+			var column string
+			if err := rows.Scan(&seqno, &cid, &column); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			unique.Columns = append(unique.Columns, column)
+		}
+		if err := closeRows(rows); err != nil {
+			return nil, err
+		}
+		uniques = append(uniques, unique)
+	}
+	return uniques, nil
+}
+
+func tableOf(schema *Schema, name string) *Table {
+	tbl, ok := schema.Tables[name]
+	if !ok {
+		tbl = &Table{Name: name}
+		schema.Tables[name] = tbl
+	}
+	return tbl
+}
+
+func closeRows(rows *sql.Rows) error {
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
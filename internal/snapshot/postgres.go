@@ -0,0 +1,225 @@
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	// postgres driver
+	_ "github.com/lib/pq"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Postgres manages snapshots of a postgres database. Snapshots are sibling
+// databases created with the original database as their template. The
+// creation time is recorded in the comment of the snapshot database.
+type Postgres struct {
+	Source string `json:"source" yaml:"source"`
+}
+
+var _ Manager = new(Postgres)
+
+// Create copies the database into a snapshot with the specified name. Other
+// connections to the database are terminated because postgres refuses to
+// copy a template database which is in use.
+func (r Postgres) Create(ctx context.Context, name string) error {
+	if err := validate(name); err != nil {
+		return err
+	}
+
+	db, dbName, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := terminate(ctx, db, dbName); err != nil {
+		return err
+	}
+
+	snapshot := r.snapshot(dbName, name)
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE DATABASE %s TEMPLATE %s", quote(snapshot), quote(dbName),
+	)); err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf(
+		"COMMENT ON DATABASE %s IS '%s'",
+		quote(snapshot), time.Now().UTC().Format(time.RFC3339),
+	))
+	return err
+}
+
+// List returns all snapshots taken of the database.
+func (r Postgres) List(ctx context.Context) (types.Snapshots, error) {
+	db, dbName, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	prefix := r.snapshot(dbName, "")
+	rows, err := db.QueryContext(ctx, `
+		SELECT datname, COALESCE(shobj_description(oid, 'pg_database'), '')
+		FROM pg_database
+		WHERE left(datname, length($1)) = $1`, prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make(types.Snapshots, 0)
+	for rows.Next() {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			return nil, err
+		}
+
+		timestamp, _ := time.Parse(time.RFC3339, comment)
+		snapshots = append(snapshots, types.Snapshot{
+			Name:      strings.TrimPrefix(name, prefix),
+			Timestamp: timestamp,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Sort(snapshots)
+	return snapshots, nil
+}
+
+// Restore recreates the database from the snapshot. The copy is created under
+// a temporary name first and swapped in by renaming so that the database is
+// never left missing. The replaced database is dropped only once the swap
+// succeeded.
+func (r Postgres) Restore(ctx context.Context, name string) error {
+	db, dbName, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snapshot := r.snapshot(dbName, name)
+	if err := exists(ctx, db, name, snapshot); err != nil {
+		return err
+	}
+
+	// leftovers of a previously failed restore are dropped first.
+	restoring, replaced := dbName+"__restoring", dbName+"__replaced"
+	for _, leftover := range []string{restoring, replaced} {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(
+			"DROP DATABASE IF EXISTS %s", quote(leftover),
+		)); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE DATABASE %s TEMPLATE %s", quote(restoring), quote(snapshot),
+	)); err != nil {
+		return err
+	}
+
+	if err := terminate(ctx, db, dbName); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"ALTER DATABASE %s RENAME TO %s", quote(dbName), quote(replaced),
+	)); err != nil {
+		return err
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"ALTER DATABASE %s RENAME TO %s", quote(restoring), quote(dbName),
+	)); err != nil {
+		if _, rerr := db.ExecContext(ctx, fmt.Sprintf(
+			"ALTER DATABASE %s RENAME TO %s", quote(replaced), quote(dbName),
+		)); rerr != nil {
+			return fmt.Errorf("%s\nfailed renaming %q back to %q (%s)", err, replaced, dbName, rerr)
+		}
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE %s", quote(replaced)))
+	return err
+}
+
+// Delete drops the snapshot database.
+func (r Postgres) Delete(ctx context.Context, name string) error {
+	db, dbName, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	snapshot := r.snapshot(dbName, name)
+	if err := exists(ctx, db, name, snapshot); err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE %s", quote(snapshot)))
+	return err
+}
+
+// open connects to the maintenance database of the server since neither the
+// original database nor the snapshot may have open connections while being
+// copied or dropped.
+func (r Postgres) open() (*sql.DB, string, error) {
+	u, err := url.Parse(r.Source)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dbName := strings.TrimPrefix(u.Path, "/")
+	if dbName == "" {
+		return nil, "", fmt.Errorf("database name missing from source")
+	}
+
+	u.Path = "/postgres"
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return nil, "", err
+	}
+	return db, dbName, nil
+}
+
+func (r Postgres) snapshot(dbName, name string) string {
+	return dbName + "__snapshot_" + name
+}
+
+func exists(ctx context.Context, db *sql.DB, name, snapshot string) error {
+	if err := validate(name); err != nil {
+		return err
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx,
+		"SELECT count(*) FROM pg_database WHERE datname = $1", snapshot,
+	).Scan(&count); err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("snapshot %q not found", name)
+	}
+	return nil
+}
+
+func terminate(ctx context.Context, db *sql.DB, dbName string) error {
+	_, err := db.ExecContext(ctx, `
+		SELECT pg_terminate_backend(pid)
+		FROM pg_stat_activity
+		WHERE datname = $1 AND pid <> pg_backend_pid()`, dbName)
+	return err
+}
+
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
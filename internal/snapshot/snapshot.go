@@ -0,0 +1,53 @@
+// Package snapshot implements point-in-time copies of sql databases.
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Manager describes an object capable of managing the snapshots of a single
+// database.
+type Manager interface {
+	Create(ctx context.Context, name string) error
+	List(ctx context.Context) (types.Snapshots, error)
+	Restore(ctx context.Context, name string) error
+	Delete(ctx context.Context, name string) error
+}
+
+// New returns the snapshot manager for the database reachable through the
+// source connection string.
+func New(dialect, source string) (Manager, error) {
+	switch dialect {
+	case "sqlite3":
+		return Sqlite{Path: strings.TrimPrefix(source, "sqlite3://")}, nil
+	case "postgres":
+		if _, err := url.Parse(source); err != nil {
+			return nil, err
+		}
+		return Postgres{Source: source}, nil
+	default:
+		return nil, fmt.Errorf("snapshots not supported for dialect %q", dialect)
+	}
+}
+
+// validate checks that the snapshot name is safe for use as part of file and
+// database names.
+func validate(name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return fmt.Errorf("invalid snapshot name %q", name)
+		}
+	}
+	return nil
+}
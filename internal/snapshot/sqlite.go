@@ -0,0 +1,112 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Sqlite manages snapshots of a sqlite database file. Snapshots are stored as
+// copies of the database file inside of a directory next to it.
+type Sqlite struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+var _ Manager = new(Sqlite)
+
+// Create copies the database file into a snapshot with the specified name.
+func (r Sqlite) Create(ctx context.Context, name string) error {
+	if err := validate(name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(r.snapshot(name)); err == nil {
+		return fmt.Errorf("snapshot %q exists", name)
+	}
+
+	if err := os.MkdirAll(r.dir(), 0755); err != nil {
+		return err
+	}
+	return copyFile(r.Path, r.snapshot(name))
+}
+
+// List returns all snapshots taken of the database.
+func (r Sqlite) List(ctx context.Context) (types.Snapshots, error) {
+	snapshots := make(types.Snapshots, 0)
+	infos, err := ioutil.ReadDir(r.dir())
+	if os.IsNotExist(err) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		snapshots = append(snapshots, types.Snapshot{
+			Name:      info.Name(),
+			Timestamp: info.ModTime(),
+		})
+	}
+	sort.Sort(snapshots)
+	return snapshots, nil
+}
+
+// Restore replaces the database file with the content of the snapshot.
+func (r Sqlite) Restore(ctx context.Context, name string) error {
+	if err := r.exists(name); err != nil {
+		return err
+	}
+	return copyFile(r.snapshot(name), r.Path)
+}
+
+// Delete removes the snapshot.
+func (r Sqlite) Delete(ctx context.Context, name string) error {
+	if err := r.exists(name); err != nil {
+		return err
+	}
+	return os.Remove(r.snapshot(name))
+}
+
+func (r Sqlite) exists(name string) error {
+	if err := validate(name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(r.snapshot(name)); os.IsNotExist(err) {
+		return fmt.Errorf("snapshot %q not found", name)
+	}
+	return nil
+}
+
+func (r Sqlite) dir() string {
+	return r.Path + ".snapshots"
+}
+
+func (r Sqlite) snapshot(name string) string {
+	return filepath.Join(r.dir(), name)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,281 @@
+// Package sqlsplit implements a dialect aware splitter of sql scripts into
+// individual statements.
+package sqlsplit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Statement defines a single statement of a script.
+type Statement struct {
+	Query string
+	Line  int
+}
+
+// Split breaks the script into statements which are safe to execute one at a
+// time. Delimiters found inside of quoted strings, identifiers, comments,
+// postgres escape strings and dollar-quoted bodies are ignored. Mysql scripts
+// may change the delimiter with `DELIMITER` lines while mssql scripts are
+// split only on `GO` batch separators.
+func Split(dialect, script string) []Statement {
+	s := &splitter{
+		dialect:   dialect,
+		script:    script,
+		delimiter: ";",
+		line:      1,
+	}
+	return s.split()
+}
+
+// splitter holds the scanning state of a single script.
+type splitter struct {
+	dialect    string
+	script     string
+	delimiter  string
+	pos        int
+	line       int
+	start      int
+	startLine  int
+	statements []Statement
+}
+
+// split scans the whole script and returns the statements found.
+func (r *splitter) split() []Statement {
+	r.startLine = r.line
+	for r.pos < len(r.script) {
+		if r.atLineStart() && r.directive() {
+			continue
+		}
+
+		rest := r.script[r.pos:]
+		switch {
+		case strings.HasPrefix(rest, "--"):
+			r.skipUntil("\n", false)
+		case rest[0] == '#' && r.dialect == "mysql":
+			r.skipUntil("\n", false)
+		case strings.HasPrefix(rest, "/*"):
+			r.advance(2)
+			r.skipUntil("*/", true)
+		case r.dialect == "postgres" && r.escapeString():
+			r.advance(2)
+			r.skipQuoted('\'', true)
+		case rest[0] == '\'':
+			r.advance(1)
+			r.skipQuoted('\'', r.dialect == "mysql")
+		case rest[0] == '"':
+			r.advance(1)
+			r.skipQuoted('"', false)
+		case rest[0] == '`' && r.dialect == "mysql":
+			r.advance(1)
+			r.skipQuoted('`', false)
+		case rest[0] == '[' && r.dialect == "mssql":
+			r.advance(1)
+			r.skipQuoted(']', false)
+		case rest[0] == '$' && r.dialect == "postgres" && r.dollarTag() != "":
+			tag := r.dollarTag()
+			r.advance(len(tag))
+			r.skipUntil(tag, true)
+		case r.dialect != "mssql" && strings.HasPrefix(rest, r.delimiter) && !r.inTrigger():
+			r.emit(r.pos)
+			r.advance(len(r.delimiter))
+			r.start, r.startLine = r.pos, r.line
+		default:
+			r.advance(1)
+		}
+	}
+	r.emit(len(r.script))
+	return r.statements
+}
+
+// directive handles line oriented separators. It returns true when the line
+// was consumed.
+func (r *splitter) directive() bool {
+	end := strings.IndexByte(r.script[r.pos:], '\n')
+	if end < 0 {
+		end = len(r.script) - r.pos
+	}
+	line := strings.TrimSpace(r.script[r.pos : r.pos+end])
+	fields := strings.Fields(line)
+
+	switch {
+	case r.dialect == "mssql" && isBatchSeparator(fields):
+		r.emit(r.pos)
+	case r.dialect == "mysql" && len(fields) == 2 &&
+		strings.EqualFold(fields[0], "DELIMITER"):
+		r.emit(r.pos)
+		r.delimiter = fields[1]
+	default:
+		return false
+	}
+
+	r.advance(end)
+	if r.pos < len(r.script) {
+		r.advance(1)
+	}
+	r.start, r.startLine = r.pos, r.line
+	return true
+}
+
+// emit records the statement between the start and the end offsets unless it
+// consists of nothing but whitespace and comments.
+func (r *splitter) emit(end int) {
+	query := r.script[r.start:end]
+	trimmed := strings.TrimLeftFunc(query, unicode.IsSpace)
+	line := r.startLine + strings.Count(query[:len(query)-len(trimmed)], "\n")
+	trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+	if isBlank(r.dialect, trimmed) {
+		return
+	}
+	r.statements = append(r.statements, Statement{Query: trimmed, Line: line})
+}
+
+// inTrigger reports whether the current sqlite statement is a trigger whose
+// body has not been terminated with `END` yet. Trigger bodies contain
+// statements of their own which must not be split apart. The `END` of a
+// `CASE` expression within the body does not terminate it.
+func (r *splitter) inTrigger() bool {
+	if r.dialect != "sqlite3" {
+		return false
+	}
+
+	words := strings.FieldsFunc(strings.ToUpper(r.script[r.start:r.pos]), func(c rune) bool {
+		return !(c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c))
+	})
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	i := 1
+	if words[i] == "TEMP" || words[i] == "TEMPORARY" {
+		i++
+	}
+	if i >= len(words) || words[i] != "TRIGGER" {
+		return false
+	}
+
+	depth := 0
+	for _, word := range words[i+1:] {
+		switch word {
+		case "BEGIN", "CASE":
+			depth++
+		case "END":
+			depth--
+		}
+	}
+	return depth > 0 || words[len(words)-1] != "END"
+}
+
+// escapeString reports whether a postgres escape string constant, e.g.
+// `E'it\'s'`, starts at the current position.
+func (r *splitter) escapeString() bool {
+	rest := r.script[r.pos:]
+	if len(rest) < 2 || (rest[0] != 'E' && rest[0] != 'e') || rest[1] != '\'' {
+		return false
+	}
+	if r.pos == 0 {
+		return true
+	}
+	c := rune(r.script[r.pos-1])
+	return !(c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c))
+}
+
+// dollarTag returns the postgres dollar quote tag, e.g. `$body$`, found at the
+// current position or an empty string.
+func (r *splitter) dollarTag() string {
+	rest := r.script[r.pos:]
+	for i := 1; i < len(rest); i++ {
+		c := rest[i]
+		if c == '$' {
+			return rest[:i+1]
+		}
+		if !(c == '_' || unicode.IsLetter(rune(c)) || (i > 1 && unicode.IsDigit(rune(c)))) {
+			return ""
+		}
+	}
+	return ""
+}
+
+// skipQuoted advances past the closing quote. Doubled quotes are treated as
+// escaped quotes as are quotes preceded by a backslash when backslash escapes
+// are enabled.
+func (r *splitter) skipQuoted(quote byte, backslash bool) {
+	for r.pos < len(r.script) {
+		c := r.script[r.pos]
+		r.advance(1)
+		if c == '\\' && backslash && r.pos < len(r.script) {
+			r.advance(1)
+			continue
+		}
+		if c == quote {
+			if r.pos < len(r.script) && r.script[r.pos] == quote {
+				r.advance(1)
+				continue
+			}
+			return
+		}
+	}
+}
+
+// skipUntil advances up to the terminator and past it when inclusive.
+func (r *splitter) skipUntil(terminator string, inclusive bool) {
+	i := strings.Index(r.script[r.pos:], terminator)
+	if i < 0 {
+		r.advance(len(r.script) - r.pos)
+		return
+	}
+	if inclusive {
+		i += len(terminator)
+	}
+	r.advance(i)
+}
+
+// advance moves the position forward keeping track of the line number.
+func (r *splitter) advance(n int) {
+	r.line += strings.Count(r.script[r.pos:r.pos+n], "\n")
+	r.pos += n
+}
+
+// atLineStart reports whether the position is at the beginning of a line.
+func (r *splitter) atLineStart() bool {
+	return r.pos == 0 || r.script[r.pos-1] == '\n'
+}
+
+// isBlank reports whether the query contains nothing but comments.
+func isBlank(dialect, query string) bool {
+	for {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		switch {
+		case strings.HasPrefix(query, "--"),
+			strings.HasPrefix(query, "#") && dialect == "mysql":
+			i := strings.IndexByte(query, '\n')
+			if i < 0 {
+				return true
+			}
+			query = query[i:]
+		case strings.HasPrefix(query, "/*"):
+			i := strings.Index(query, "*/")
+			if i < 0 {
+				return true
+			}
+			query = query[i+2:]
+		default:
+			return query == ""
+		}
+	}
+}
+
+// isBatchSeparator reports whether the line fields form a mssql `GO` batch
+// separator optionally followed by a repeat count.
+func isBatchSeparator(fields []string) bool {
+	if len(fields) == 0 || len(fields) > 2 || !strings.EqualFold(fields[0], "GO") {
+		return false
+	}
+	if len(fields) == 2 {
+		for _, c := range fields[1] {
+			if !unicode.IsDigit(c) {
+				return false
+			}
+		}
+	}
+	return true
+}
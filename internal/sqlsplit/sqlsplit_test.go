@@ -0,0 +1,162 @@
+package sqlsplit
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SqlsplitSuite struct {
+	suite.Suite
+}
+
+func (r *SqlsplitSuite) TestSplit() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		dialect    string
+		script     string
+		statements []Statement
+	}{
+		{
+			false, "",
+			"sqlite3",
+			"CREATE TABLE unittests (value text)",
+			[]Statement{
+				{Query: "CREATE TABLE unittests (value text)", Line: 1},
+			},
+		},
+		{
+			false, "",
+			"sqlite3",
+			"-- creates the table\n" +
+				"CREATE TABLE unittests (value text);\n" +
+				"INSERT INTO unittests(value) VALUES ('a;b'), (\"c;d\");\n" +
+				"/* trailing; comment */\n",
+			[]Statement{
+				{Query: "-- creates the table\nCREATE TABLE unittests (value text)", Line: 1},
+				{Query: "INSERT INTO unittests(value) VALUES ('a;b'), (\"c;d\")", Line: 3},
+			},
+		},
+		{
+			false, "",
+			"sqlite3",
+			"CREATE TRIGGER audit AFTER INSERT ON users\n" +
+				"BEGIN\n" +
+				"  INSERT INTO logs(value) VALUES ('it''s');\n" +
+				"END;\n" +
+				"DROP TABLE logs;",
+			[]Statement{
+				{Query: "CREATE TRIGGER audit AFTER INSERT ON users\n" +
+					"BEGIN\n" +
+					"  INSERT INTO logs(value) VALUES ('it''s');\n" +
+					"END", Line: 1},
+				{Query: "DROP TABLE logs", Line: 5},
+			},
+		},
+		{
+			false, "",
+			"postgres",
+			"CREATE FUNCTION one() RETURNS int AS $body$\n" +
+				"BEGIN RETURN 1; END;\n" +
+				"$body$ LANGUAGE plpgsql;\n" +
+				"SELECT $1, $$;$$;",
+			[]Statement{
+				{Query: "CREATE FUNCTION one() RETURNS int AS $body$\n" +
+					"BEGIN RETURN 1; END;\n" +
+					"$body$ LANGUAGE plpgsql", Line: 1},
+				{Query: "SELECT $1, $$;$$", Line: 4},
+			},
+		},
+		{
+			false, "",
+			"mysql",
+			"DROP PROCEDURE IF EXISTS one;\n" +
+				"DELIMITER //\n" +
+				"CREATE PROCEDURE one() BEGIN SELECT 'a\\';'; SELECT `b;`; END//\n" +
+				"DELIMITER ;\n" +
+				"CALL one();\n",
+			[]Statement{
+				{Query: "DROP PROCEDURE IF EXISTS one", Line: 1},
+				{Query: "CREATE PROCEDURE one() BEGIN SELECT 'a\\';'; SELECT `b;`; END", Line: 3},
+				{Query: "CALL one()", Line: 5},
+			},
+		},
+		{
+			false, "",
+			"sqlite3",
+			"CREATE TRIGGER grade AFTER INSERT ON users\n" +
+				"BEGIN\n" +
+				"  UPDATE users SET grade = CASE WHEN NEW.score > 50 THEN 'pass' ELSE 'fail' END;\n" +
+				"  UPDATE users SET seen = (CASE NEW.seen WHEN 0 THEN 1 END) WHERE id = NEW.id;\n" +
+				"END;\n" +
+				"DROP TABLE logs;",
+			[]Statement{
+				{Query: "CREATE TRIGGER grade AFTER INSERT ON users\n" +
+					"BEGIN\n" +
+					"  UPDATE users SET grade = CASE WHEN NEW.score > 50 THEN 'pass' ELSE 'fail' END;\n" +
+					"  UPDATE users SET seen = (CASE NEW.seen WHEN 0 THEN 1 END) WHERE id = NEW.id;\n" +
+					"END", Line: 1},
+				{Query: "DROP TABLE logs", Line: 6},
+			},
+		},
+		{
+			false, "",
+			"postgres",
+			"INSERT INTO logs(value) VALUES (E'it\\'s; fine'), (e'\\\\');\n" +
+				"SELECT 'a\\';\n" +
+				"SELECT type'b;';",
+			[]Statement{
+				{Query: "INSERT INTO logs(value) VALUES (E'it\\'s; fine'), (e'\\\\')", Line: 1},
+				{Query: "SELECT 'a\\'", Line: 2},
+				{Query: "SELECT type'b;'", Line: 3},
+			},
+		},
+		{
+			false, "",
+			"mysql",
+			"# creates the table; with a comment\n" +
+				"CREATE TABLE logs (value text); # trailing; comment\n" +
+				"INSERT INTO logs(value) VALUES ('#;');\n" +
+				"# nothing but a comment;\n",
+			[]Statement{
+				{Query: "# creates the table; with a comment\nCREATE TABLE logs (value text)", Line: 1},
+				{Query: "# trailing; comment\nINSERT INTO logs(value) VALUES ('#;')", Line: 2},
+			},
+		},
+		{
+			false, "",
+			"mssql",
+			"CREATE TABLE [a;b] (id int);\n" +
+				"INSERT INTO [a;b] VALUES (1);\n" +
+				"go\n" +
+				"\n" +
+				"CREATE VIEW one AS SELECT 1 AS id;\n" +
+				"GO 2\n",
+			[]Statement{
+				{Query: "CREATE TABLE [a;b] (id int);\nINSERT INTO [a;b] VALUES (1);", Line: 1},
+				{Query: "CREATE VIEW one AS SELECT 1 AS id;", Line: 5},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			assert.Equal(r.T(), tc.statements, Split(tc.dialect, tc.script))
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestSqlsplitSuite(t *testing.T) {
+	suite.Run(t, new(SqlsplitSuite))
+}
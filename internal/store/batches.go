@@ -0,0 +1,107 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"gopkg.in/gorp.v1"
+
+	"github.com/trivigy/migrate/v2/internal/store/model"
+)
+
+const batchesTableName = "migrations_batches"
+
+// Batches defines a wrapper struct for all of the batched operations progress
+// table operations.
+type Batches struct {
+	db      *sql.DB
+	dialect gorp.Dialect
+}
+
+// GetDBMap returns the underlying batches table database model object.
+// Transactions begun on the returned object are able to record progress
+// together with the batch changes.
+func (r Batches) GetDBMap() *gorp.DbMap {
+	dbMap := &gorp.DbMap{Db: r.db, Dialect: r.dialect}
+	t := dbMap.AddTableWithName(model.Batch{}, batchesTableName)
+	t.SetKeys(false, "Key")
+	return dbMap
+}
+
+// CreateTableIfNotExists create batches table if one does not exist.
+func (r Batches) CreateTableIfNotExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.CreateTablesIfNotExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DropTablesIfExists drops a table from the database if already exists.
+func (r Batches) DropTablesIfExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.DropTablesIfExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get returns the progress record of the batched operation or nil when the
+// operation is not in progress.
+func (r Batches) Get(key string) (*model.Batch, error) {
+	dbMap := r.GetDBMap()
+	record, err := dbMap.Get(model.Batch{}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil {
+		return nil, nil
+	}
+	return record.(*model.Batch), nil
+}
+
+// Executor defines the context aware statement execution shared by database
+// connections and transactions.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Save updates or, when missing, inserts the progress record using the
+// executor so that the progress is recorded in the same transaction as the
+// batch itself.
+func (r Batches) Save(ctx context.Context, executor Executor, record *model.Batch) error {
+	table := r.dialect.QuotedTableForQuery("", batchesTableName)
+	result, err := executor.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = %s, %s = %s, %s = %s WHERE %s = %s",
+		table,
+		r.dialect.QuoteField("batch"), r.dialect.BindVar(0),
+		r.dialect.QuoteField("rows"), r.dialect.BindVar(1),
+		r.dialect.QuoteField("timestamp"), r.dialect.BindVar(2),
+		r.dialect.QuoteField("key"), r.dialect.BindVar(3),
+	), record.Batch, record.Rows, record.Timestamp, record.Key)
+	if err != nil {
+		return err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil || affected > 0 {
+		return err
+	}
+
+	_, err = executor.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s, %s, %s, %s) VALUES (%s, %s, %s, %s)",
+		table,
+		r.dialect.QuoteField("batch"), r.dialect.QuoteField("rows"),
+		r.dialect.QuoteField("timestamp"), r.dialect.QuoteField("key"),
+		r.dialect.BindVar(0), r.dialect.BindVar(1), r.dialect.BindVar(2), r.dialect.BindVar(3),
+	), record.Batch, record.Rows, record.Timestamp, record.Key)
+	return err
+}
+
+// Remove deletes the progress record using the executor.
+func (r Batches) Remove(ctx context.Context, executor Executor, key string) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = %s",
+		r.dialect.QuotedTableForQuery("", batchesTableName),
+		r.dialect.QuoteField("key"), r.dialect.BindVar(0),
+	), key)
+	return err
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"reflect"
+	"strings"
 	"time"
 
 	"gopkg.in/gorp.v1"
@@ -33,14 +34,25 @@ var supportedDialects = map[string]gorp.Dialect{
 // available tables and operations.
 type Context struct {
 	db         *sql.DB
+	driver     string
 	dialect    gorp.Dialect
 	Migrations Migrations
+	History    History
+	Batches    Batches
 	Releases   Releases
+	Locks      Locks
+	Seeds      Seeds
 	Unittests  Unittests
 }
 
 // Open initializes the context and creates a database connection.
 func Open(driver, source string) (*Context, error) {
+	// sqlite does not understand url formatted sources so the scheme is
+	// stripped leaving behind the path to the database file.
+	if driver == "sqlite3" {
+		source = strings.TrimPrefix(source, "sqlite3://")
+	}
+
 	db, err := sql.Open(driver, source)
 	if err != nil {
 		return nil, err
@@ -50,7 +62,8 @@ func Open(driver, source string) (*Context, error) {
 		return nil, err
 	}
 
-	dialect := supportedDialects[getDriverName(db.Driver())]
+	driver = getDriverName(db.Driver())
+	dialect := supportedDialects[driver]
 
 	// When using the mysql driver, make sure that the parseTime option is
 	// configured, otherwise it won't map time columns to time.Time. See
@@ -64,19 +77,42 @@ func Open(driver, source string) (*Context, error) {
 
 	context := &Context{
 		db:         db,
+		driver:     driver,
 		dialect:    dialect,
 		Migrations: Migrations{db, dialect},
+		History:    History{db, dialect},
+		Batches:    Batches{db, dialect},
 		Releases:   Releases{db, dialect},
+		Locks:      Locks{db, dialect},
+		Seeds:      Seeds{db, dialect},
 		Unittests:  Unittests{db, dialect},
 	}
 	return context, nil
 }
 
+// Tables returns the names of the bookkeeping tables managed by the store.
+func Tables() []string {
+	return []string{
+		migrationsTableName,
+		historyTableName,
+		batchesTableName,
+		releasesTableName,
+		locksTableName,
+		seedsTableName,
+	}
+}
+
 // GetDBMap returns the global connection database object.
 func (r *Context) GetDBMap() *gorp.DbMap {
 	return &gorp.DbMap{Db: r.db, Dialect: r.dialect}
 }
 
+// Dialect returns the name of the sql driver used by the connection, e.g.
+// `postgres` or `sqlite3`.
+func (r *Context) Dialect() string {
+	return r.driver
+}
+
 // Close terminates the connection to the database and closes context.
 func (r *Context) Close() error {
 	return r.db.Close()
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gopkg.in/gorp.v1"
+
+	"github.com/trivigy/migrate/v2/internal/store/model"
+)
+
+const historyTableName = "migrations_history"
+
+// History defines a wrapper struct for all of the migrations history table
+// operations.
+type History struct {
+	db      *sql.DB
+	dialect gorp.Dialect
+}
+
+// GetDBMap returns the underlying migrations history table database model
+// object.
+func (r History) GetDBMap() *gorp.DbMap {
+	dbMap := &gorp.DbMap{Db: r.db, Dialect: r.dialect}
+	t := dbMap.AddTableWithName(model.History{}, historyTableName)
+	t.SetKeys(true, "ID")
+	return dbMap
+}
+
+// CreateTableIfNotExists create migrations history table if one does not
+// exist.
+func (r History) CreateTableIfNotExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.CreateTablesIfNotExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DropTablesIfExists drops a table from the database if already exists.
+func (r History) DropTablesIfExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.DropTablesIfExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Insert appends history records to the database.
+func (r History) Insert(records ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.Insert(records...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetHistory returns all history records in the order they were recorded.
+func (r History) GetHistory() ([]model.History, error) {
+	dbMap := r.GetDBMap()
+	records := make([]model.History, 0)
+	query := fmt.Sprintf(
+		`SELECT * FROM %s ORDER BY id`,
+		dbMap.Dialect.QuotedTableForQuery("", historyTableName),
+	)
+	if _, err := dbMap.Select(&records, query); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetLatest returns the most recent history record of the migration tag or
+// nil when the migration has no history.
+func (r History) GetLatest(tag string) (*model.History, error) {
+	dbMap := r.GetDBMap()
+	records := make([]model.History, 0)
+	query := fmt.Sprintf(
+		`SELECT * FROM %s WHERE tag = %s ORDER BY id DESC LIMIT 1`,
+		dbMap.Dialect.QuotedTableForQuery("", historyTableName),
+		dbMap.Dialect.BindVar(0),
+	)
+	if _, err := dbMap.Select(&records, query, tag); err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"database/sql"
+
+	"gopkg.in/gorp.v1"
+
+	"github.com/trivigy/migrate/v2/internal/store/model"
+)
+
+const locksTableName = "migrations_locks"
+
+// Locks defines a wrapper struct for all of the locks table operations.
+type Locks struct {
+	db      *sql.DB
+	dialect gorp.Dialect
+}
+
+// GetDBMap returns the underlying locks table database model object.
+func (r Locks) GetDBMap() *gorp.DbMap {
+	dbMap := &gorp.DbMap{Db: r.db, Dialect: r.dialect}
+	t := dbMap.AddTableWithName(model.Lock{}, locksTableName)
+	t.SetKeys(false, "Name")
+	return dbMap
+}
+
+// CreateTableIfNotExists create locks table if one does not exist.
+func (r Locks) CreateTableIfNotExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.CreateTablesIfNotExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DropTablesIfExists drops a table from the database if already exists.
+func (r Locks) DropTablesIfExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.DropTablesIfExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Insert adds a lock record to the database. Inserting fails when the lock
+// is already held.
+func (r Locks) Insert(locks ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.Insert(locks...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete instructs a lock record to be deleted from the database.
+func (r Locks) Delete(locks ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if _, err := dbMap.Delete(locks...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Get returns the record of the named lock or nil when the lock is not held.
+func (r Locks) Get(name string) (*model.Lock, error) {
+	dbMap := r.GetDBMap()
+	record, err := dbMap.Get(model.Lock{}, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil {
+		return nil, nil
+	}
+	return record.(*model.Lock), nil
+}
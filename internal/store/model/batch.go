@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// Batch defines a batched operations progress table record. A record exists
+// only while the batched operation is in progress and allows it to resume
+// after an interruption.
+type Batch struct {
+	Key       string    `db:"key"`
+	Batch     int64     `db:"batch"`
+	Rows      int64     `db:"rows"`
+	Timestamp time.Time `db:"timestamp"`
+}
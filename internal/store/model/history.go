@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// History defines a migrations history table record. Records are only ever
+// appended and describe a single event in the lifecycle of a migration.
+type History struct {
+	ID        int64     `db:"id"`
+	Tag       string    `db:"tag"`
+	Name      string    `db:"name"`
+	Action    string    `db:"action"`
+	Direction string    `db:"direction"`
+	Actor     string    `db:"actor"`
+	Duration  int64     `db:"duration"` // milliseconds
+	Error     string    `db:"error"`
+	Timestamp time.Time `db:"timestamp"`
+}
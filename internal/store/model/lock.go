@@ -0,0 +1,13 @@
+package model
+
+import (
+	"time"
+)
+
+// Lock defines a locks table record. A record exists only while the named
+// lock is held.
+type Lock struct {
+	Name      string    `db:"name"`
+	Holder    string    `db:"holder"`
+	Timestamp time.Time `db:"timestamp"`
+}
@@ -1,11 +1,20 @@
 package model
 
-// Release defines a collection of kubernetes manifests which can be released
-// together as a logical unit.
+import (
+	"time"
+)
+
+// Release defines a single revision of a collection of kubernetes manifests
+// which can be released together as a logical unit. The version field is not
+// called `Version` because gorp maps such fields onto optimistic locking
+// columns.
 type Release struct {
-	Name    string `db:"name"`
-	Version string `db:"version"`
-	Status  string `db:"status"`
+	Name      string    `db:"name"`
+	Revision  int       `db:"revision"`
+	Tag       string    `db:"version"`
+	Status    string    `db:"status"`
+	Digest    string    `db:"digest"`
+	Timestamp time.Time `db:"timestamp"`
 	// Values    map[string]interface{} `db:"values"`
 	// Manifests []interface{}          `db:"manifests"`
 }
@@ -1,9 +1,5 @@
 package model
 
-import (
-	"github.com/blang/semver"
-)
-
 // Releases represents a collection of kubernetes releases.
 type Releases []Release
 
@@ -19,15 +15,6 @@ func (s Releases) Swap(i, j int) {
 
 // Less checks if release at index i is less than release at index j
 func (s Releases) Less(i, j int) bool {
-	iVersion, err := semver.Make(s[i].Version)
-	if err != nil {
-		panic(err)
-	}
-
-	jVersion, err := semver.Make(s[j].Version)
-	if err != nil {
-		panic(err)
-	}
-
-	return s[i].Name < s[j].Name || (s[i].Name == s[j].Name && iVersion.LT(jVersion))
+	return s[i].Name < s[j].Name ||
+		(s[i].Name == s[j].Name && s[i].Revision < s[j].Revision)
 }
@@ -0,0 +1,12 @@
+package model
+
+import (
+	"time"
+)
+
+// Seed defines a seeds table record.
+type Seed struct {
+	Name      string    `db:"name"`
+	Checksum  string    `db:"checksum"`
+	Timestamp time.Time `db:"timestamp"`
+}
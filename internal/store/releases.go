@@ -22,7 +22,7 @@ type Releases struct {
 func (r Releases) GetDBMap() *gorp.DbMap {
 	dbMap := &gorp.DbMap{Db: r.db, Dialect: r.dialect}
 	t := dbMap.AddTableWithName(model.Release{}, releasesTableName)
-	t.SetKeys(false, "Name", "Version")
+	t.SetKeys(false, "Name", "Revision")
 	return dbMap
 }
 
@@ -53,6 +53,15 @@ func (r Releases) Insert(releases ...interface{}) error {
 	return nil
 }
 
+// Update replaces existing release records on the database.
+func (r Releases) Update(releases ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if _, err := dbMap.Update(releases...); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Delete instructs a release record to be deleted from the database.
 func (r Releases) Delete(releases ...interface{}) error {
 	dbMap := r.GetDBMap()
@@ -68,7 +77,7 @@ func (r Releases) GetReleases() ([]model.Release, error) {
 	releases := make([]model.Release, 0)
 	query := fmt.Sprintf(
 		`SELECT * FROM %s`,
-		dbMap.Dialect.QuotedTableForQuery("", migrationsTableName),
+		dbMap.Dialect.QuotedTableForQuery("", releasesTableName),
 	)
 	if _, err := dbMap.Select(&releases, query); err != nil {
 		return nil, err
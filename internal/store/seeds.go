@@ -0,0 +1,90 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gopkg.in/gorp.v1"
+
+	"github.com/trivigy/migrate/v2/internal/store/model"
+)
+
+const seedsTableName = "seeds"
+
+// Seeds defines a wrapper struct for all of the seeds table operations.
+type Seeds struct {
+	db      *sql.DB
+	dialect gorp.Dialect
+}
+
+// GetDBMap returns the underlying seeds table database model object.
+func (r Seeds) GetDBMap() *gorp.DbMap {
+	dbMap := &gorp.DbMap{Db: r.db, Dialect: r.dialect}
+	t := dbMap.AddTableWithName(model.Seed{}, seedsTableName)
+	t.SetKeys(false, "Name")
+	return dbMap
+}
+
+// CreateTableIfNotExists create seeds table if one does not exist.
+func (r Seeds) CreateTableIfNotExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.CreateTablesIfNotExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DropTablesIfExists drops a table from the database if already exists.
+func (r Seeds) DropTablesIfExists() error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.DropTablesIfExists(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Insert adds a seed record to the database.
+func (r Seeds) Insert(seeds ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if err := dbMap.Insert(seeds...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Update replaces an existing seed record in the database.
+func (r Seeds) Update(seeds ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if _, err := dbMap.Update(seeds...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Delete instructs a seed record to be deleted from the database.
+func (r Seeds) Delete(seeds ...interface{}) error {
+	dbMap := r.GetDBMap()
+	if _, err := dbMap.Delete(seeds...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetSeeds returns database seed records keyed by seed name.
+func (r Seeds) GetSeeds() (map[string]model.Seed, error) {
+	dbMap := r.GetDBMap()
+	records := make([]model.Seed, 0)
+	query := fmt.Sprintf(
+		`SELECT * FROM %s`,
+		dbMap.Dialect.QuotedTableForQuery("", seedsTableName),
+	)
+	if _, err := dbMap.Select(&records, query); err != nil {
+		return nil, err
+	}
+
+	seeds := make(map[string]model.Seed, len(records))
+	for _, record := range records {
+		seeds[record.Name] = record
+	}
+	return seeds, nil
+}
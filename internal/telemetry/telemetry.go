@@ -0,0 +1,132 @@
+// Package telemetry implements opentelemetry tracing and metrics of commands,
+// migrations, releases and their individual steps.
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/trivigy/migrate/v2/global"
+)
+
+// instrumentation defines the name of the instrumentation scope.
+const instrumentation = "github.com/trivigy/migrate/v2"
+
+// Attribute keys recorded on spans and metrics.
+const (
+	CommandKey   = attribute.Key("migrate.command")
+	TagKey       = attribute.Key("migrate.tag")
+	NameKey      = attribute.Key("migrate.name")
+	DirectionKey = attribute.Key("migrate.direction")
+	KindKey      = attribute.Key("migrate.kind")
+	NamespaceKey = attribute.Key("migrate.namespace")
+)
+
+// Tracer returns the tracer of the provider found in the context or a no-op
+// tracer when none was provided.
+func Tracer(ctx context.Context) trace.Tracer {
+	provider, ok := ctx.Value(global.RefTracerProvider).(trace.TracerProvider)
+	if !ok {
+		provider = tracenoop.NewTracerProvider()
+	}
+	return provider.Tracer(instrumentation)
+}
+
+// Meter returns the meter of the provider found in the context or a no-op
+// meter when none was provided.
+func Meter(ctx context.Context) metric.Meter {
+	return meterProvider(ctx).Meter(instrumentation)
+}
+
+// meterProvider returns the meter provider found in the context or a no-op
+// provider when none was provided.
+func meterProvider(ctx context.Context) metric.MeterProvider {
+	provider, ok := ctx.Value(global.RefMeterProvider).(metric.MeterProvider)
+	if !ok {
+		provider = metricnoop.NewMeterProvider()
+	}
+	return provider
+}
+
+// instruments defines the metrics recorded for the spans of a single kind.
+// Instruments which failed to be created are nil.
+type instruments struct {
+	duration metric.Float64Histogram
+	failures metric.Int64Counter
+}
+
+// instrumentsKey identifies the instruments of a kind created by a provider.
+type instrumentsKey struct {
+	provider metric.MeterProvider
+	kind     string
+}
+
+// cache holds the instruments created so far so that every provider creates
+// the instruments of a kind only once.
+var cache sync.Map
+
+// lookupInstruments returns the instruments of the kind created by the meter
+// provider found in the context.
+func lookupInstruments(ctx context.Context, kind string) *instruments {
+	key := instrumentsKey{provider: meterProvider(ctx), kind: kind}
+	if cached, ok := cache.Load(key); ok {
+		return cached.(*instruments)
+	}
+
+	meter := key.provider.Meter(instrumentation)
+	created := &instruments{}
+	if duration, err := meter.Float64Histogram(
+		"migrate."+kind+".duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of each "+kind+"."),
+	); err == nil {
+		created.duration = duration
+	}
+
+	if failures, err := meter.Int64Counter(
+		"migrate."+kind+".failures",
+		metric.WithDescription("Number of failed "+kind+" runs."),
+	); err == nil {
+		created.failures = failures
+	}
+
+	cached, _ := cache.LoadOrStore(key, created)
+	return cached.(*instruments)
+}
+
+// Start begins a span named `migrate.<kind>` and returns a function which
+// ends it. Ending the span records its duration in the
+// `migrate.<kind>.duration` histogram and counts failures in the
+// `migrate.<kind>.failures` counter.
+func Start(
+	ctx context.Context,
+	kind string,
+	attrs ...attribute.KeyValue,
+) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := Tracer(ctx).Start(ctx, "migrate."+kind, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		metrics := lookupInstruments(ctx, kind)
+		set := metric.WithAttributes(attrs...)
+		if metrics.duration != nil {
+			metrics.duration.Record(ctx, time.Since(start).Seconds(), set)
+		}
+
+		if err != nil {
+			if metrics.failures != nil {
+				metrics.failures.Add(ctx, 1, set)
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/trivigy/migrate/v2/global"
+)
+
+type TelemetrySuite struct {
+	suite.Suite
+}
+
+// countingProvider implements a no-op meter provider which counts the
+// instruments created by its meters.
+type countingProvider struct {
+	metricnoop.MeterProvider
+	created int
+}
+
+func (r *countingProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return countingMeter{provider: r}
+}
+
+type countingMeter struct {
+	metricnoop.Meter
+	provider *countingProvider
+}
+
+func (r countingMeter) Float64Histogram(name string, opts ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	r.provider.created++
+	return r.Meter.Float64Histogram(name, opts...)
+}
+
+func (r countingMeter) Int64Counter(name string, opts ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	r.provider.created++
+	return r.Meter.Int64Counter(name, opts...)
+}
+
+func (r *TelemetrySuite) TestStartCachesInstruments() {
+	testCases := []struct {
+		kinds   []string
+		created int
+	}{
+		{[]string{"command"}, 2},
+		{[]string{"command", "command", "command"}, 2},
+		{[]string{"command", "migration", "command", "migration"}, 4},
+	}
+
+	for i, testCase := range testCases {
+		provider := &countingProvider{}
+		ctx := context.WithValue(context.Background(), global.RefMeterProvider, metric.MeterProvider(provider))
+		for _, kind := range testCase.kinds {
+			_, end := Start(ctx, kind)
+			end(errors.New("failed"))
+		}
+		assert.Equal(r.T(), testCase.created, provider.created, "testCase: %d", i)
+	}
+}
+
+func TestTelemetrySuite(t *testing.T) {
+	suite.Run(t, new(TelemetrySuite))
+}
@@ -38,6 +38,14 @@ func ExactArgs(args []string, n int) error {
 	return nil
 }
 
+// MinArgs checks that at least n positional arguments were provided.
+func MinArgs(args []string, n int) error {
+	if len(args) < n {
+		return fmt.Errorf("requires at least %d arg(s), received %d", n, len(args))
+	}
+	return nil
+}
+
 // MaxArgs checks that no more than n positional arguments were provided.
 func MaxArgs(args []string, n int) error {
 	if len(args) > n {
@@ -0,0 +1,218 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type BatchSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+const backfillQuery = `UPDATE items SET done = 1 WHERE id IN (
+	SELECT id FROM items WHERE done IS NULL LIMIT 2
+)`
+
+func (r *BatchSuite) SetupTest() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-items-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up: []types.Operation{{Query: "" +
+					"CREATE TABLE items (id int, done int);\n" +
+					"INSERT INTO items(id) VALUES (1), (2), (3), (4), (5);",
+				}},
+				Down: []types.Operation{{Query: `DROP TABLE items`}},
+			},
+			{
+				Name: "backfill-items",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up: []types.Operation{{
+					Query: backfillQuery,
+					Batch: &types.Batch{Pause: time.Millisecond},
+				}},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *BatchSuite) TearDownTest() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *BatchSuite) TestBatchTry() {
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "0", "--try"})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), ""+
+		"==> migration \"0.0.1_create-items-table\" (up)\n"+
+		"-- statement 1 (line 1)\n"+
+		"CREATE TABLE items (id int, done int);\n"+
+		"-- statement 2 (line 2)\n"+
+		"INSERT INTO items(id) VALUES (1), (2), (3), (4), (5);\n"+
+		"==> migration \"0.0.2_backfill-items\" (up)\n"+
+		"-- batch (pause 1ms)\n"+
+		backfillQuery+";\n",
+		buffer.String(),
+	)
+}
+
+func (r *BatchSuite) TestBatchProgress() {
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(r.T(), lines, 5, buffer.String())
+	label := `migration "0\.0\.2_backfill-items" \(up\)`
+	assert.Regexp(r.T(), `^batch 1 of `+label+` affected 2 rows \(2 total, \d+ rows/s\)$`, lines[1])
+	assert.Regexp(r.T(), `^batch 2 of `+label+` affected 2 rows \(4 total, \d+ rows/s\)$`, lines[2])
+	assert.Regexp(r.T(), `^batch 3 of `+label+` affected 1 rows \(5 total, \d+ rows/s\)$`, lines[3])
+	assert.Equal(r.T(), "migration \"0.0.2_backfill-items\" successfully applied (up)", lines[4])
+
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	var count int
+	assert.Nil(r.T(), db.QueryRow(`SELECT count(*) FROM items WHERE done = 1`).Scan(&count))
+	assert.Equal(r.T(), 5, count)
+	assert.Nil(r.T(), db.QueryRow(`SELECT count(*) FROM migrations_batches`).Scan(&count))
+	assert.Equal(r.T(), 0, count)
+}
+
+func (r *BatchSuite) TestBatchResume() {
+	err := Up{Driver: r.Driver}.Execute("up", bytes.NewBuffer(nil), []string{"-l", "1"})
+	assert.Nil(r.T(), err)
+
+	// simulates an interruption after the first two batches were completed.
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE items SET done = 1 WHERE id <= 4`)
+	assert.Nil(r.T(), err)
+	_, err = db.Exec(`CREATE TABLE migrations_batches (key varchar(255), batch integer, rows integer, timestamp datetime)`)
+	assert.Nil(r.T(), err)
+	key := fmt.Sprintf("migration %q (up) %x", "0.0.2_backfill-items", sha256.Sum256([]byte(backfillQuery)))
+	_, err = db.Exec(`INSERT INTO migrations_batches VALUES (?, 2, 4, ?)`, key, time.Now())
+	assert.Nil(r.T(), err)
+
+	buffer := bytes.NewBuffer(nil)
+	err = Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(r.T(), lines, 3, buffer.String())
+	assert.Equal(r.T(), "resuming migration \"0.0.2_backfill-items\" (up) from batch 3 (4 rows)", lines[0])
+	assert.Regexp(r.T(), `^batch 3 of migration "0\.0\.2_backfill-items" \(up\) affected 1 rows \(5 total, \d+ rows/s\)$`, lines[1])
+	assert.Equal(r.T(), "migration \"0.0.2_backfill-items\" successfully applied (up)", lines[2])
+}
+
+func (r *BatchSuite) TestBatchInterrupt() {
+	ctx, cancel := interrupt.Context(context.Background(), ioutil.Discard)
+	defer cancel()
+	interrupt.Stop(ctx)
+
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: r.Driver}.run(ctx, buffer, upOptions{Limit: 0})
+	assert.Equal(r.T(), interrupt.ErrStopped, err)
+	assert.Equal(r.T(), "", buffer.String())
+
+	err = Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+}
+
+func (r *BatchSuite) TestBatchTimeout() {
+	err := Up{Driver: r.Driver}.Execute("up", bytes.NewBuffer(nil), []string{"-l", "1"})
+	assert.Nil(r.T(), err)
+
+	testCases := []struct {
+		operation types.Operation
+		err       string
+		progress  int
+	}{
+		{
+			types.Operation{Query: backfillQuery, Batch: &types.Batch{Pause: time.Hour}},
+			context.DeadlineExceeded.Error(),
+			1,
+		},
+		{
+			types.Operation{
+				Query: backfillQuery + ` AND (
+	WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c
+) > 0`,
+				Batch: &types.Batch{},
+			},
+			`migration query aborted "0.0.2_backfill-items" (up) batch 1 (context deadline exceeded)`,
+			0,
+		},
+	}
+
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		(*r.Driver.Migrations())[1].Up = []types.Operation{testCase.operation}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		start := time.Now()
+		err := Up{Driver: r.Driver}.run(ctx, bytes.NewBuffer(nil), upOptions{})
+		cancel()
+		assert.EqualError(r.T(), err, testCase.err, failMsg)
+		assert.Less(r.T(), int64(time.Since(start)), int64(10*time.Second), failMsg)
+
+		var count int
+		assert.Nil(r.T(), db.QueryRow(`SELECT count(*) FROM migrations_batches`).Scan(&count), failMsg)
+		assert.Equal(r.T(), testCase.progress, count, failMsg)
+		_, err = db.Exec(`DELETE FROM migrations_batches`)
+		assert.Nil(r.T(), err, failMsg)
+	}
+}
+
+func TestBatchSuite(t *testing.T) {
+	suite.Run(t, new(BatchSuite))
+}
@@ -2,28 +2,36 @@
 package migrations
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"sort"
+	"time"
 
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/internal/events"
 	"github.com/trivigy/migrate/v2/internal/store"
 	"github.com/trivigy/migrate/v2/internal/store/model"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
 // GenerateMigrationPlan creates a migration plan based on difference with the
-// current state recorded on the database and direction.
+// current state recorded on the state store and direction.
 func GenerateMigrationPlan(
-	db *store.Context,
+	ctx context.Context,
+	records state.Store,
 	direction types.Direction,
 	migrations *types.Migrations,
 ) ([]*types.Migration, error) {
-	if err := db.Migrations.CreateTableIfNotExists(); err != nil {
-		return nil, err
+	if migrations.HasDependencies() || migrations.HasPhases() {
+		return generateGraphPlan(ctx, records, direction, migrations)
 	}
 
 	sort.Sort(migrations)
 	sortedRegistryMigrations := migrations
-	sortedDatabaseMigrations, err := db.Migrations.GetMigrationsSorted()
+	sortedDatabaseMigrations, err := recordedMigrations(ctx, records)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +85,266 @@ func GenerateMigrationPlan(
 	return plan, nil
 }
 
+// generateGraphPlan creates a migration plan which follows the dependency
+// graph of the migrations. Applying follows topological order while rolling
+// back follows the reverse order, meaning that a migration is never removed
+// before all of the applied migrations which depend on it. Phased migrations
+// are planned the same way since phases apply migrations out of tag order.
+func generateGraphPlan(
+	ctx context.Context,
+	records state.Store,
+	direction types.Direction,
+	migrations *types.Migrations,
+) ([]*types.Migration, error) {
+	order, err := migrations.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(ctx, records, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := make([]*types.Migration, 0)
+	if direction == types.DirectionUp {
+		for _, migration := range order {
+			if _, ok := applied[migration.Tag.String()]; !ok {
+				plan = append(plan, migration)
+			}
+		}
+	} else {
+		for i := len(order) - 1; i >= 0; i-- {
+			if _, ok := applied[order[i].Tag.String()]; ok {
+				plan = append(plan, order[i])
+			}
+		}
+	}
+	return plan, nil
+}
+
+// filterPhase narrows the plan down to the migrations of the phase. The phase
+// is refused while a migration planned ahead of it belongs to an earlier phase
+// and is still pending.
+func filterPhase(plan []*types.Migration, phase types.Phase) ([]*types.Migration, error) {
+	filtered := make([]*types.Migration, 0)
+	for i, migration := range plan {
+		if migration.Phase != phase {
+			continue
+		}
+
+		for _, earlier := range plan[:i] {
+			if earlier.Phase.Rank() < phase.Rank() {
+				return nil, fmt.Errorf(
+					"migration %q must be applied before %q (%s)",
+					earlier.Tag.String()+"_"+earlier.Name,
+					migration.Tag.String()+"_"+migration.Name,
+					phase,
+				)
+			}
+		}
+		filtered = append(filtered, migration)
+	}
+	return filtered, nil
+}
+
+// openState returns the state store selected by the driver. Drivers without a
+// state store of their own keep the records on the database itself.
+func openState(ctx context.Context, d interface{}, db *store.Context) (state.Store, error) {
+	if d, ok := d.(driver.WithState); ok {
+		return d.State(ctx)
+	}
+	return state.NewSQL(db), nil
+}
+
+// recordedMigrations returns the migration records of the state store sorted
+// by tag.
+func recordedMigrations(ctx context.Context, records state.Store) (model.Migrations, error) {
+	migrations, err := records.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make(model.Migrations, 0, len(migrations))
+	for _, migration := range migrations {
+		sorted = append(sorted, model.Migration(migration))
+	}
+	sort.Sort(sorted)
+	return sorted, nil
+}
+
+// appliedMigrations returns the migration records found on the state store
+// keyed by tag. Every record is required to have a matching registry
+// migration.
+func appliedMigrations(
+	ctx context.Context,
+	records state.Store,
+	migrations *types.Migrations,
+) (map[string]model.Migration, error) {
+	recorded, err := recordedMigrations(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(*migrations))
+	for _, migration := range *migrations {
+		known[migration.Tag.String()] = true
+	}
+
+	applied := make(map[string]model.Migration, len(recorded))
+	for _, record := range recorded {
+		if !known[record.Tag] {
+			return nil, fmt.Errorf("migration tags missing %q", record.Tag)
+		}
+		applied[record.Tag] = record
+	}
+	return applied, nil
+}
+
+// lockName defines the name of the state store lock held while migrating.
+const lockName = "migrations"
+
+// Actions recorded in the migrations history.
+const (
+	actionMark    = "mark"
+	actionFailure = "failure"
+	actionRetry   = "retry"
+)
+
+// recorder appends events to the migrations history. Recorded events are kept
+// so that they can be replayed after the database is restored from a snapshot
+// which would otherwise erase them.
+type recorder struct {
+	actor  string
+	events []state.Event
+}
+
+// newRecorder returns a recorder attributing events to the current actor.
+func newRecorder() *recorder {
+	return &recorder{actor: state.Actor()}
+}
+
+// record appends an event for the migration. The action defaults to the
+// direction and becomes a retry when the previous event of the migration was
+// a failure in the same direction.
+func (r *recorder) record(
+	ctx context.Context,
+	records state.HistoryStore,
+	migration *types.Migration,
+	d types.Direction,
+	action string,
+	start time.Time,
+	failure error,
+) error {
+	if action == "" {
+		action = d.String()
+		latest, err := records.LatestEvent(ctx, migration.Tag.String())
+		if err != nil {
+			return err
+		}
+		if latest != nil && latest.Action == actionFailure && latest.Direction == d.String() {
+			action = actionRetry
+		}
+	}
+
+	event := state.Event{
+		Tag:       migration.Tag.String(),
+		Name:      migration.Name,
+		Action:    action,
+		Direction: d.String(),
+		Actor:     r.actor,
+		Duration:  time.Since(start),
+		Timestamp: start,
+	}
+	if failure != nil {
+		event.Error = failure.Error()
+	}
+
+	if err := records.InsertEvent(ctx, event); err != nil {
+		return fmt.Errorf(
+			"failed recording history of migration %q (%s)",
+			migration.Tag.String()+"_"+migration.Name, d,
+		)
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+// replay inserts all of the previously recorded events again.
+func (r *recorder) replay(ctx context.Context, records state.HistoryStore) error {
+	for _, event := range r.events {
+		if err := records.InsertEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startMigration begins the telemetry span of a single migration applied in
+// the direction.
+func startMigration(
+	ctx context.Context,
+	migration *types.Migration,
+	d types.Direction,
+) (context.Context, func(error)) {
+	return telemetry.Start(ctx, "migration",
+		telemetry.TagKey.String(migration.Tag.String()),
+		telemetry.NameKey.String(migration.Name),
+		telemetry.DirectionKey.String(d.String()),
+	)
+}
+
+// report writes the outcome of a single migration. Failures are written only
+// as jsonl events because the error itself is returned to the caller.
+func report(
+	ctx context.Context,
+	out io.Writer,
+	migration *types.Migration,
+	d types.Direction,
+	start time.Time,
+	status string,
+	err error,
+) error {
+	event := events.Event{
+		Type:      events.TypeMigration,
+		Resource:  migration.Name,
+		Tag:       migration.Tag.String(),
+		Direction: d.String(),
+		Status:    status,
+		Duration:  time.Since(start),
+	}
+	event.Fail(err)
+
+	format := ""
+	if err == nil {
+		format = "migration %q successfully %s (%s)\n"
+	}
+	return events.Emit(ctx, out, event, format,
+		migration.Tag.String()+"_"+migration.Name, status, d,
+	)
+}
+
 func max(x, y int) int {
 	if x < y {
 		return y
 	}
 	return x
 }
+
+// reportSnapshot writes the outcome of creating or restoring the snapshot
+// taken before migrating.
+func reportSnapshot(
+	ctx context.Context,
+	out io.Writer,
+	snapshot string,
+	status string,
+	start time.Time,
+) error {
+	event := events.Event{
+		Type:     events.TypeSnapshot,
+		Resource: snapshot,
+		Status:   status,
+		Duration: time.Since(start),
+	}
+	return events.Emit(ctx, out, event, "snapshot %q successfully %s\n", snapshot, status)
+}
@@ -7,14 +7,18 @@ import (
 	"io"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
 	"github.com/trivigy/migrate/v2/internal/store"
-	"github.com/trivigy/migrate/v2/internal/store/model"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
@@ -30,6 +34,7 @@ type Down struct {
 type downOptions struct {
 	Limit int  `json:"limit" yaml:"limit"`
 	Try   bool `json:"dryRun" yaml:"dryRun"`
+	Mark  bool `json:"mark" yaml:"mark"`
 }
 
 var _ interface {
@@ -44,7 +49,10 @@ func (r Down) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Rolls back to the previously applied migrations.",
 		Long:  "Rolls back to the previously applied migrations",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			if patches, ok := r.Driver.(driver.WithPatches); ok {
 				for _, patch := range *patches.Patches(name) {
 					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
@@ -55,8 +63,9 @@ func (r Down) NewCommand(ctx context.Context, name string) *cobra.Command {
 
 			limit, _ := cmd.Flags().GetInt("limit")
 			try, _ := cmd.Flags().GetBool("try")
-			opts := downOptions{Limit: limit, Try: try}
-			return r.run(context.Background(), cmd.OutOrStdout(), opts)
+			mark, _ := cmd.Flags().GetBool("mark")
+			opts := downOptions{Limit: limit, Try: try, Mark: mark}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -75,6 +84,10 @@ func (r Down) NewCommand(ctx context.Context, name string) *cobra.Command {
 		"try", false,
 		"Simulates and prints resource execution parameters.",
 	)
+	flags.Bool(
+		"mark", false,
+		"Records migrations as removed without executing them.",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -116,8 +129,24 @@ func (r Down) run(ctx context.Context, out io.Writer, opts downOptions) error {
 	if err != nil {
 		return err
 	}
+	defer db.Close()
+
+	records, err := openState(ctx, r.Driver, db)
+	if err != nil {
+		return err
+	}
+
+	// the lock is held from planning until the records are written so that
+	// concurrent runs never execute the same plan twice.
+	if !opts.Try {
+		if err := records.Lock(ctx, lockName, state.Actor()); err != nil {
+			return err
+		}
+		// the lock is released even when the context was cancelled.
+		defer records.Unlock(context.Background(), lockName)
+	}
 
-	migrationPlan, err := GenerateMigrationPlan(db, types.DirectionDown, r.Driver.Migrations())
+	migrationPlan, err := GenerateMigrationPlan(ctx, records, types.DirectionDown, r.Driver.Migrations())
 	if err != nil {
 		return err
 	}
@@ -134,33 +163,74 @@ func (r Down) run(ctx context.Context, out io.Writer, opts downOptions) error {
 				types.DirectionDown,
 			)
 			for _, op := range migrationPlan[i].Down {
-				fmt.Fprintf(out, "%s;\n", op.Query)
+				op.Print(out, db.Dialect())
 			}
 		}
 	} else {
+		history := newRecorder()
 		for i := 0; i < steps; i++ {
-			for _, op := range migrationPlan[i].Down {
-				err := op.Execute(db, migrationPlan[i], types.DirectionDown)
-				if err != nil {
-					return err
-				}
+			if err := interrupt.Err(ctx); err != nil {
+				return err
 			}
 
-			if err := db.Migrations.Delete(&model.Migration{
-				Tag: migrationPlan[i].Tag.String(),
-			}); err != nil {
-				return fmt.Errorf(
-					"failed deleting previously applied migration %q (%s)",
-					migrationPlan[i].Tag.String()+"_"+migrationPlan[i].Name,
-					types.DirectionDown,
-				)
+			if err := r.remove(ctx, db, records, history, out, migrationPlan[i], opts.Mark); err != nil {
+				return err
 			}
-
-			fmt.Fprintf(out, "migration %q successfully removed (%s)\n",
-				migrationPlan[i].Tag.String()+"_"+migrationPlan[i].Name,
-				types.DirectionDown,
-			)
 		}
 	}
 	return nil
 }
+
+// remove executes the down operations of a single migration and deletes its
+// record. Marking deletes the record without executing any of the operations.
+func (r Down) remove(
+	ctx context.Context,
+	db *store.Context,
+	records state.Store,
+	history *recorder,
+	out io.Writer,
+	migration *types.Migration,
+	mark bool,
+) (err error) {
+	start := time.Now()
+	ctx, end := startMigration(ctx, migration, types.DirectionDown)
+	defer func() {
+		if err != nil {
+			_ = report(ctx, out, migration, types.DirectionDown, start, "", err)
+		}
+		end(err)
+	}()
+
+	action := ""
+	if mark {
+		action = actionMark
+	} else {
+		for _, op := range migration.Down {
+			err := op.Execute(ctx, db, records, out, migration, types.DirectionDown)
+			if err != nil {
+				if herr := history.record(ctx, records, migration, types.DirectionDown, actionFailure, start, err); herr != nil {
+					return herr
+				}
+				return err
+			}
+		}
+	}
+
+	if err := records.DeleteMigration(ctx, migration.Tag.String()); err != nil {
+		return fmt.Errorf(
+			"failed deleting previously applied migration %q (%s)",
+			migration.Tag.String()+"_"+migration.Name,
+			types.DirectionDown,
+		)
+	}
+
+	if err := history.record(ctx, records, migration, types.DirectionDown, action, start, nil); err != nil {
+		return err
+	}
+
+	status := events.StatusRemoved
+	if mark {
+		status = events.StatusMarked
+	}
+	return report(ctx, out, migration, types.DirectionDown, start, status, nil)
+}
@@ -0,0 +1,148 @@
+package migrations
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type EventsSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *EventsSuite) SetupTest() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-users-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up:   []types.Operation{{Query: `CREATE TABLE users (id int)`}},
+				Down: []types.Operation{{Query: `DROP TABLE users`}},
+			},
+			{
+				Name: "broken-migration",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up:   []types.Operation{{Query: `CREATE TABLE users (id int)`}},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *EventsSuite) TearDownTest() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *EventsSuite) execute(
+	cmd interface {
+		types.Resource
+		types.Command
+	},
+	name string,
+	args []string,
+) ([]map[string]interface{}, error) {
+	ctx := context.WithValue(context.Background(), global.RefRoot, types.Executor{Name: name, Command: cmd})
+	ctx = context.WithValue(ctx, global.RefOutput, events.OutputJSONL)
+	buffer := bytes.NewBuffer(nil)
+	command := cmd.NewCommand(ctx, name)
+	command.SetOut(buffer)
+	command.SetArgs(args)
+	err := command.Execute()
+
+	results := make([]map[string]interface{}, 0)
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		event := make(map[string]interface{})
+		assert.Nil(r.T(), json.Unmarshal(scanner.Bytes(), &event), scanner.Text())
+		assert.Contains(r.T(), event, "durationMs")
+		delete(event, "durationMs")
+		results = append(results, event)
+	}
+	return results, err
+}
+
+func (r *EventsSuite) TestEvents() {
+	results, err := r.execute(Up{Driver: r.Driver}, "up", []string{"-l", "0"})
+	assert.NotNil(r.T(), err)
+	assert.Equal(r.T(), []map[string]interface{}{
+		{
+			"type":      "migration",
+			"resource":  "create-users-table",
+			"tag":       "0.0.1",
+			"direction": "up",
+			"status":    "applied",
+		},
+		{
+			"type":      "migration",
+			"resource":  "broken-migration",
+			"tag":       "0.0.2",
+			"direction": "up",
+			"status":    "failed",
+			"error":     err.Error(),
+		},
+	}, results)
+
+	results, err = r.execute(Down{Driver: r.Driver}, "down", []string{"--mark"})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), []map[string]interface{}{
+		{
+			"type":      "migration",
+			"resource":  "create-users-table",
+			"tag":       "0.0.1",
+			"direction": "down",
+			"status":    "marked",
+		},
+	}, results)
+}
+
+func (r *EventsSuite) TestEventsSnapshot() {
+	results, err := r.execute(Up{Driver: r.Driver}, "up", []string{"-l", "0", "--snapshot"})
+	assert.NotNil(r.T(), err)
+	assert.Len(r.T(), results, 4)
+
+	statuses := make([]string, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result["type"].(string)+" "+result["status"].(string))
+	}
+	assert.Equal(r.T(), []string{
+		"snapshot created",
+		"migration applied",
+		"migration failed",
+		"snapshot restored",
+	}, statuses)
+	assert.Equal(r.T(), results[0]["resource"], results[3]["resource"])
+}
+
+func TestEventsSuite(t *testing.T) {
+	suite.Run(t, new(EventsSuite))
+}
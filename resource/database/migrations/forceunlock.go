@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// ForceUnlock represents the database migration force unlock command object.
+// It releases the migrations lock left behind by a run which crashed before
+// releasing it.
+type ForceUnlock struct {
+	Driver interface {
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(ForceUnlock)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r ForceUnlock) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Releases the migrations lock regardless of its holder.",
+		Long:  "Releases the migrations lock regardless of its holder",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			return r.run(ctx, cmd.OutOrStdout())
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r ForceUnlock) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r ForceUnlock) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the force unlock command.
+func (r ForceUnlock) run(ctx context.Context, out io.Writer) error {
+	source := bytes.NewBuffer(nil)
+	if err := r.Driver.Source(ctx, source); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(source.String())
+	if err != nil {
+		return err
+	}
+
+	db, err := store.Open(u.Scheme, source.String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	records, err := openState(ctx, r.Driver, db)
+	if err != nil {
+		return err
+	}
+
+	if err := records.Unlock(ctx, lockName); err != nil {
+		return err
+	}
+
+	if !events.JSONL(ctx) {
+		fmt.Fprintf(out, "lock %q successfully released\n", lockName)
+	}
+	return nil
+}
@@ -1,13 +1,17 @@
 package migrations
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"go/format"
 	"io"
+	"io/ioutil"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -16,11 +20,16 @@ import (
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/schema"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
 
-const templateContent = `package migrations
+// templates defines the builtin scaffolding templates keyed by file format.
+var templates = map[string]string{
+	"go": `package {{ .Package }}
 
 import (
 	"github.com/blang/semver"
@@ -31,13 +40,36 @@ import (
 func init() {
 	migrate.Registry.Store(&types.Migration{
 		Name: "{{ .Name }}",
-		Tag:  semver.MustParse("{{ .Tag }}"),
-		Up: []types.Operation{},
-		Down: []types.Operation{},
+		Tag:  semver.MustParse("{{ .Tag }}"),{{ if .Description }}
+		Description: {{ printf "%q" .Description }},{{ end }}
+		Up: []types.Operation{ {{- range .Up }}
+			{Query: {{ quote . }}},{{ end }}{{ if .Up }}
+		{{ end }}},
+		Down: []types.Operation{ {{- range .Down }}
+			{Query: {{ quote . }}},{{ end }}{{ if .Down }}
+		{{ end }}},
 	})
 }
+`,
+	"sql": `-- +migrate Name: {{ .Name }}
+-- +migrate Tag: {{ .Tag }}{{ if .Description }}
+-- +migrate Description: {{ .Description }}{{ end }}
 
-`
+-- +migrate Up
+{{ range .Up }}{{ . }};
+{{ end }}
+-- +migrate Down
+{{ range .Down }}{{ . }};
+{{ end }}`,
+	"yaml": `name: {{ .Name }}
+tag: {{ .Tag }}{{ if .Description }}
+description: {{ printf "%q" .Description }}{{ end }}
+up:{{ range .Up }}
+  - query: {{ printf "%q" . }}{{ else }} []{{ end }}
+down:{{ range .Down }}
+  - query: {{ printf "%q" . }}{{ else }} []{{ end }}
+`,
+}
 
 // Generate represents the generate command which allows for generating new
 // templates of the database migrations file.
@@ -50,9 +82,17 @@ type Generate struct {
 
 // generateOptions is used for executing the run() method.
 type generateOptions struct {
-	Dir  string `json:"dir" yaml:"dir"`
-	Name string `json:"name" yaml:"name"`
-	Tag  string `json:"tag" yaml:"tag"`
+	Dir         string   `json:"dir" yaml:"dir"`
+	Name        string   `json:"name" yaml:"name"`
+	Tag         string   `json:"tag" yaml:"tag"`
+	Diff        string   `json:"diff" yaml:"diff"`
+	Format      string   `json:"format" yaml:"format"`
+	Template    string   `json:"template" yaml:"template"`
+	Bump        string   `json:"bump" yaml:"bump"`
+	Description string   `json:"description" yaml:"description"`
+	Package     string   `json:"package" yaml:"package"`
+	Up          []string `json:"up" yaml:"up"`
+	Down        []string `json:"down" yaml:"down"`
 }
 
 var _ interface {
@@ -67,18 +107,61 @@ func (r Generate) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Adds a new blank migration with increasing version.",
 		Long:  "Adds a new blank migration with increasing version",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			dir, err := cmd.Flags().GetString("dir")
 			if err != nil {
 				return err
 			}
 
+			diff, err := cmd.Flags().GetString("diff")
+			if err != nil {
+				return err
+			}
+
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+
+			tpl, err := cmd.Flags().GetString("template")
+			if err != nil {
+				return err
+			}
+
+			bump, err := cmd.Flags().GetString("bump")
+			if err != nil {
+				return err
+			}
+
+			description, err := cmd.Flags().GetString("description")
+			if err != nil {
+				return err
+			}
+
+			pkg, err := cmd.Flags().GetString("package")
+			if err != nil {
+				return err
+			}
+
 			parts := strings.Split(args[0], ":")
 			parts = append(parts, "")
 			name, tag := parts[0], parts[1]
 
-			opts := generateOptions{Dir: dir, Name: name, Tag: tag}
-			return r.run(cmd.OutOrStdout(), opts)
+			opts := generateOptions{
+				Dir:         dir,
+				Name:        name,
+				Tag:         tag,
+				Diff:        diff,
+				Format:      format,
+				Template:    tpl,
+				Bump:        bump,
+				Description: description,
+				Package:     pkg,
+			}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -93,6 +176,30 @@ func (r Generate) NewCommand(ctx context.Context, name string) *cobra.Command {
 		"dir", "d", ".",
 		"Specify directory `PATH` where to generate miration file.",
 	)
+	flags.String(
+		"diff", "",
+		"Generate operations by diffing against `SOURCE` sql file or database.",
+	)
+	flags.StringP(
+		"format", "f", "go",
+		"Generated file `FORMAT` (go, sql or yaml).",
+	)
+	flags.String(
+		"template", "",
+		"Render the file using a custom template found at `PATH`.",
+	)
+	flags.String(
+		"bump", "patch",
+		"Semver `PART` to increment when TAG is omitted (patch, minor or major).",
+	)
+	flags.String(
+		"description", "",
+		"Attach a `TEXT` description to the migration metadata.",
+	)
+	flags.String(
+		"package", "migrations",
+		"Go package `NAME` used by the generated file.",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -129,11 +236,29 @@ func (r Generate) validation(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("invalid argument %q", args[0])
 		}
 	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	if templates[format] == "" {
+		return fmt.Errorf("invalid format %q", format)
+	}
+
+	bump, err := cmd.Flags().GetString("bump")
+	if err != nil {
+		return err
+	}
+	switch bump {
+	case "patch", "minor", "major":
+	default:
+		return fmt.Errorf("invalid bump %q", bump)
+	}
 	return nil
 }
 
 // run is a starting point method for executing the generate command.
-func (r Generate) run(out io.Writer, opts generateOptions) error {
+func (r Generate) run(ctx context.Context, out io.Writer, opts generateOptions) error {
 	base, err := filepath.Abs(opts.Dir)
 	if err != nil {
 		return err
@@ -143,15 +268,29 @@ func (r Generate) run(out io.Writer, opts generateOptions) error {
 		return fmt.Errorf("directory %q not found", opts.Dir)
 	}
 
-	sort.Sort(*r.Driver.Migrations())
-	tags := semver.Versions{semver.Version{}}
+	tags, err := fileTags(base)
+	if err != nil {
+		return err
+	}
+
+	tags = append(tags, semver.Version{})
 	for _, rgMig := range *r.Driver.Migrations() {
 		tags = append(tags, rgMig.Tag)
 	}
+	semver.Sort(tags)
 
 	if opts.Tag == "" {
 		v := tags[len(tags)-1]
-		v.Patch++
+		switch opts.Bump {
+		case "major":
+			v.Major++
+			v.Minor, v.Patch = 0, 0
+		case "minor":
+			v.Minor++
+			v.Patch = 0
+		default:
+			v.Patch++
+		}
 		opts.Tag = v.String()
 	}
 
@@ -161,7 +300,23 @@ func (r Generate) run(out io.Writer, opts generateOptions) error {
 		}
 	}
 
-	filename := fmt.Sprintf("%s_%s.go", opts.Tag, opts.Name)
+	if opts.Diff != "" {
+		if opts.Up, opts.Down, err = r.diff(ctx, opts.Diff); err != nil {
+			return err
+		}
+
+		if len(opts.Up) == 0 {
+			fmt.Fprintf(out, "No schema differences found\n")
+			return nil
+		}
+	}
+
+	content, err := r.render(opts)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("%s_%s.%s", opts.Tag, opts.Name, opts.Format)
 	fullpath := path.Join(base, filename)
 	file, err := os.Create(fullpath)
 	if err != nil {
@@ -169,11 +324,134 @@ func (r Generate) run(out io.Writer, opts generateOptions) error {
 	}
 	defer file.Close()
 
-	tpl := template.Must(template.New("migration").Parse(templateContent))
-	if err := tpl.Execute(file, opts); err != nil {
+	if _, err := file.Write(content); err != nil {
 		return err
 	}
 
 	fmt.Fprintf(out, "Created migration %q\n", fullpath)
 	return nil
 }
+
+// fileTags returns the tags of the migration files previously generated into
+// the directory. Sql and yaml migrations are registered only once loaded,
+// therefore their tags may be missing from the registry.
+func fileTags(dir string) (semver.Versions, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := semver.Versions{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		parts := strings.SplitN(file.Name(), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if tag, err := semver.Parse(parts[0]); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// render executes the scaffolding template for the requested format. A
+// template file takes precedence over a template supplied by the driver which
+// in turn takes precedence over the builtin template.
+func (r Generate) render(opts generateOptions) ([]byte, error) {
+	if opts.Format == "" {
+		opts.Format = "go"
+	}
+	if opts.Package == "" {
+		opts.Package = "migrations"
+	}
+
+	content := templates[opts.Format]
+	if drv, ok := r.Driver.(driver.WithTemplate); ok {
+		if custom := drv.Template(opts.Format); custom != "" {
+			content = custom
+		}
+	}
+
+	if opts.Template != "" {
+		rbytes, err := ioutil.ReadFile(opts.Template)
+		if err != nil {
+			return nil, err
+		}
+		content = string(rbytes)
+	}
+
+	funcs := template.FuncMap{"quote": quote}
+	tpl, err := template.New("migration").Funcs(funcs).Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buffer, opts); err != nil {
+		return nil, err
+	}
+
+	if opts.Format == "go" {
+		return format.Source(buffer.Bytes())
+	}
+	return buffer.Bytes(), nil
+}
+
+// diff compares the live schema with the desired schema and returns the
+// operations which bring the live schema up to date and back.
+func (r Generate) diff(ctx context.Context, desired string) ([]string, []string, error) {
+	source := bytes.NewBuffer(nil)
+	if err := r.Driver.Source(ctx, source); err != nil {
+		return nil, nil, err
+	}
+
+	uri, err := url.Parse(source.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := store.Open(uri.Scheme, source.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	dialect := uri.Scheme
+	live, err := schema.Inspect(db.GetDBMap().Db, dialect, store.Tables()...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var target *schema.Schema
+	if shadow, err := url.Parse(desired); err == nil && shadow.Scheme == dialect {
+		sdb, err := store.Open(shadow.Scheme, desired)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer sdb.Close()
+
+		target, err = schema.Inspect(sdb.GetDBMap().Db, dialect, store.Tables()...)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		target, err = schema.LoadFile(db.GetDBMap().Db, dialect, desired, store.Tables()...)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return schema.Diff(dialect, live, target)
+}
+
+// quote renders a query as a go string literal preferring raw strings.
+func quote(query string) string {
+	if !strings.Contains(query, "`") {
+		return "`" + query + "`"
+	}
+	return strconv.Quote(query)
+}
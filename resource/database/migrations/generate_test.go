@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
+	"testing"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
 
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
 	"github.com/trivigy/migrate/v2/testutils"
 	"github.com/trivigy/migrate/v2/types"
 )
@@ -35,6 +41,13 @@ func (r *MigrationsSuite) TestGenerateCommand() {
 			bytes.NewBuffer(nil),
 			[]string{"example", "-d", dir},
 		},
+		{
+			false, "",
+			Generate{Driver: r.Driver},
+			// r.config,
+			bytes.NewBuffer(nil),
+			[]string{"example", "-d", dir, "-f", "sql", "--bump", "minor"},
+		},
 		{
 			true, "directory \"./not-found\" not found",
 			Generate{Driver: r.Driver},
@@ -50,8 +63,14 @@ func (r *MigrationsSuite) TestGenerateCommand() {
 				"  generate NAME[:TAG] [flags]\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -d, --dir PATH   Specify directory PATH where to generate miration file. (default \".\")\n" +
-				"      --help       Show help information.\n",
+				"  -d, --dir PATH           Specify directory PATH where to generate miration file. (default \".\")\n" +
+				"      --diff SOURCE        Generate operations by diffing against SOURCE sql file or database.\n" +
+				"  -f, --format FORMAT      Generated file FORMAT (go, sql or yaml). (default \"go\")\n" +
+				"      --template PATH      Render the file using a custom template found at PATH.\n" +
+				"      --bump PART          Semver PART to increment when TAG is omitted (patch, minor or major). (default \"patch\")\n" +
+				"      --description TEXT   Attach a TEXT description to the migration metadata.\n" +
+				"      --package NAME       Go package NAME used by the generated file. (default \"migrations\")\n" +
+				"      --help               Show help information.\n",
 			Generate{Driver: r.Driver},
 			// r.config,
 			bytes.NewBuffer(nil),
@@ -65,8 +84,14 @@ func (r *MigrationsSuite) TestGenerateCommand() {
 				"  generate NAME[:TAG] [flags]\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -d, --dir PATH   Specify directory PATH where to generate miration file. (default \".\")\n" +
-				"      --help       Show help information.\n",
+				"  -d, --dir PATH           Specify directory PATH where to generate miration file. (default \".\")\n" +
+				"      --diff SOURCE        Generate operations by diffing against SOURCE sql file or database.\n" +
+				"  -f, --format FORMAT      Generated file FORMAT (go, sql or yaml). (default \"go\")\n" +
+				"      --template PATH      Render the file using a custom template found at PATH.\n" +
+				"      --bump PART          Semver PART to increment when TAG is omitted (patch, minor or major). (default \"patch\")\n" +
+				"      --description TEXT   Attach a TEXT description to the migration metadata.\n" +
+				"      --package NAME       Go package NAME used by the generated file. (default \"migrations\")\n" +
+				"      --help               Show help information.\n",
 			Generate{Driver: r.Driver},
 			// r.config,
 			bytes.NewBuffer(nil),
@@ -80,8 +105,14 @@ func (r *MigrationsSuite) TestGenerateCommand() {
 				"  generate NAME[:TAG] [flags]\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -d, --dir PATH   Specify directory PATH where to generate miration file. (default \".\")\n" +
-				"      --help       Show help information.\n",
+				"  -d, --dir PATH           Specify directory PATH where to generate miration file. (default \".\")\n" +
+				"      --diff SOURCE        Generate operations by diffing against SOURCE sql file or database.\n" +
+				"  -f, --format FORMAT      Generated file FORMAT (go, sql or yaml). (default \"go\")\n" +
+				"      --template PATH      Render the file using a custom template found at PATH.\n" +
+				"      --bump PART          Semver PART to increment when TAG is omitted (patch, minor or major). (default \"patch\")\n" +
+				"      --description TEXT   Attach a TEXT description to the migration metadata.\n" +
+				"      --package NAME       Go package NAME used by the generated file. (default \"migrations\")\n" +
+				"      --help               Show help information.\n",
 			Generate{Driver: r.Driver},
 			// r.config,
 			bytes.NewBuffer(nil),
@@ -114,3 +145,70 @@ func (r *MigrationsSuite) TestGenerateCommand() {
 		}
 	}
 }
+
+type GenerateSuite struct {
+	suite.Suite
+	Dir    string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *GenerateSuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + filepath.Join(dir, "unittest.db"),
+		},
+	}.Build()
+}
+
+func (r *GenerateSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *GenerateSuite) TestGenerateFiles() {
+	testCases := []struct {
+		args     []string
+		filename string
+	}{
+		{[]string{"create-users", "-f", "sql"}, "0.0.1_create-users.sql"},
+		{[]string{"create-posts", "-f", "sql"}, "0.0.2_create-posts.sql"},
+		{[]string{"create-tags", "-f", "yaml", "--bump", "minor"}, "0.1.0_create-tags.yaml"},
+	}
+
+	for i, testCase := range testCases {
+		args := append(testCase.args, "-d", r.Dir)
+		assert.Nil(r.T(), Generate{Driver: r.Driver}.Execute("generate", bytes.NewBuffer(nil), args), "testCase: %d", i)
+		_, err := os.Stat(filepath.Join(r.Dir, testCase.filename))
+		assert.Nil(r.T(), err, "testCase: %d", i)
+	}
+
+	err := Generate{Driver: r.Driver}.Execute("generate", bytes.NewBuffer(nil), []string{"create-users:0.0.2", "-d", r.Dir})
+	assert.EqualError(r.T(), err, `migration tag "0.0.2" exists`)
+
+	registry := types.Registry{}
+	assert.Nil(r.T(), registry.StoreFS(os.DirFS(r.Dir), "*"))
+
+	migrations := types.Migrations{}
+	registry.Collect("*", &migrations)
+	sort.Sort(migrations)
+
+	tags := make([]string, 0, len(migrations))
+	for _, migration := range migrations {
+		tags = append(tags, migration.Tag.String()+"_"+migration.Name)
+	}
+	assert.Equal(r.T(), []string{"0.0.1_create-users", "0.0.2_create-posts", "0.1.0_create-tags"}, tags)
+}
+
+func TestGenerateSuite(t *testing.T) {
+	suite.Run(t, new(GenerateSuite))
+}
@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Graph represents the database migration graph command object.
+type Graph struct {
+	Driver interface {
+		driver.WithMigrations
+	} `json:"driver" yaml:"driver"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Graph)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Graph) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Prints the migrations dependency graph in DOT format.",
+		Long:  "Prints the migrations dependency graph in DOT format",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			return r.run(ctx, cmd.OutOrStdout())
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Graph) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Graph) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the graph command. Edges point
+// from a migration to the migrations which depend on it. Without explicit
+// dependencies every migration depends on the one preceding it by tag.
+func (r Graph) run(ctx context.Context, out io.Writer) error {
+	migrations := r.Driver.Migrations()
+	order, err := migrations.TopologicalSort()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "digraph migrations {")
+	for _, migration := range order {
+		fmt.Fprintf(out, "\t%q [label=%q];\n",
+			migration.Tag.String(),
+			migration.Tag.String()+"_"+migration.Name,
+		)
+	}
+
+	graph := migrations.HasDependencies()
+	for i, migration := range order {
+		if !graph {
+			if i > 0 {
+				fmt.Fprintf(out, "\t%q -> %q;\n", order[i-1].Tag.String(), migration.Tag.String())
+			}
+			continue
+		}
+
+		dependencies := append([]string{}, migration.DependsOn...)
+		sort.Strings(dependencies)
+		for _, tag := range dependencies {
+			fmt.Fprintf(out, "\t%q -> %q;\n", tag, migration.Tag.String())
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return nil
+}
@@ -0,0 +1,202 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type GraphSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *GraphSuite) SetupSuite() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-users-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up:   []types.Operation{{Query: `CREATE TABLE users (id int)`}},
+				Down: []types.Operation{{Query: `DROP TABLE users`}},
+			},
+			{
+				Name:      "create-emails-table",
+				Tag:       semver.Version{Major: 0, Minor: 0, Patch: 2},
+				DependsOn: []string{"0.0.3"},
+				Up:        []types.Operation{{Query: `CREATE TABLE emails (user_id int)`}},
+				Down:      []types.Operation{{Query: `DROP TABLE emails`}},
+			},
+			{
+				Name: "create-accounts-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 3},
+				Up:   []types.Operation{{Query: `CREATE TABLE accounts (id int)`}},
+				Down: []types.Operation{{Query: `DROP TABLE accounts`}},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *GraphSuite) TearDownSuite() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *GraphSuite) TestGraphCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Graph{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"digraph migrations {\n" +
+				"\t\"0.0.1\" [label=\"0.0.1_create-users-table\"];\n" +
+				"\t\"0.0.3\" [label=\"0.0.3_create-accounts-table\"];\n" +
+				"\t\"0.0.2\" [label=\"0.0.2_create-emails-table\"];\n" +
+				"\t\"0.0.3\" -> \"0.0.2\";\n" +
+				"}\n",
+		},
+		{
+			false, "",
+			Graph{Driver: testutils.Database{Migrations: &types.Migrations{
+				{Name: "a", Tag: semver.Version{Major: 0, Minor: 0, Patch: 1}},
+				{Name: "b", Tag: semver.Version{Major: 0, Minor: 0, Patch: 2}},
+			}}.Build()},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"digraph migrations {\n" +
+				"\t\"0.0.1\" [label=\"0.0.1_a\"];\n" +
+				"\t\"0.0.2\" [label=\"0.0.2_b\"];\n" +
+				"\t\"0.0.1\" -> \"0.0.2\";\n" +
+				"}\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("graph", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func (r *GraphSuite) TestGraphPlan() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0"},
+			"migration \"0.0.1_create-users-table\" successfully applied (up)\n" +
+				"migration \"0.0.3_create-accounts-table\" successfully applied (up)\n" +
+				"migration \"0.0.2_create-emails-table\" successfully applied (up)\n",
+		},
+		{
+			false, "",
+			Down{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--try"},
+			"==> migration \"0.0.2_create-emails-table\" (down)\n" +
+				"DROP TABLE emails;\n" +
+				"==> migration \"0.0.3_create-accounts-table\" (down)\n" +
+				"DROP TABLE accounts;\n" +
+				"==> migration \"0.0.1_create-users-table\" (down)\n" +
+				"DROP TABLE users;\n",
+		},
+		{
+			false, "",
+			Down{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "2"},
+			"migration \"0.0.2_create-emails-table\" successfully removed (down)\n" +
+				"migration \"0.0.3_create-accounts-table\" successfully removed (down)\n",
+		},
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--try"},
+			"==> migration \"0.0.3_create-accounts-table\" (up)\n" +
+				"CREATE TABLE accounts (id int);\n" +
+				"==> migration \"0.0.2_create-emails-table\" (up)\n" +
+				"CREATE TABLE emails (user_id int);\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("plan", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestGraphSuite(t *testing.T) {
+	suite.Run(t, new(GraphSuite))
+}
@@ -0,0 +1,240 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// History represents the database migration history command object.
+type History struct {
+	Driver interface {
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// historyOptions is used for executing the run() command.
+type historyOptions struct {
+	Tag    string    `json:"tag" yaml:"tag"`
+	Action string    `json:"action" yaml:"action"`
+	Actor  string    `json:"actor" yaml:"actor"`
+	Since  time.Time `json:"since" yaml:"since"`
+	Format string    `json:"format" yaml:"format"`
+}
+
+// historyEvent is the json representation of a single history record.
+type historyEvent struct {
+	Tag       string    `json:"tag"`
+	Name      string    `json:"name"`
+	Action    string    `json:"action"`
+	Direction string    `json:"direction"`
+	Actor     string    `json:"actor"`
+	Duration  int64     `json:"durationMs"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(History)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r History) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Prints the audit trail of every migration event.",
+		Long:  "Prints the audit trail of every migration event",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			tag, _ := cmd.Flags().GetString("tag")
+			action, _ := cmd.Flags().GetString("action")
+			actor, _ := cmd.Flags().GetString("actor")
+			since, _ := cmd.Flags().GetString("since")
+			format, _ := cmd.Flags().GetString("format")
+			opts := historyOptions{
+				Tag:    tag,
+				Action: action,
+				Actor:  actor,
+				Format: format,
+			}
+			if since != "" {
+				opts.Since, _ = time.Parse(time.RFC3339, since)
+			}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.String(
+		"tag", "",
+		"Only show events of the migration with `TAG`.",
+	)
+	flags.String(
+		"action", "",
+		"Only show events of `ACTION` (up, down, mark, failure or retry).",
+	)
+	flags.String(
+		"actor", "",
+		"Only show events recorded by `NAME`.",
+	)
+	flags.String(
+		"since", "",
+		"Only show events recorded after `TIME` in RFC3339 format.",
+	)
+	flags.StringP(
+		"format", "f", "table",
+		"Output `FORMAT` (table or json).",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r History) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r History) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+
+	if since, _ := cmd.Flags().GetString("since"); since != "" {
+		if _, err := time.Parse(time.RFC3339, since); err != nil {
+			return fmt.Errorf("invalid time %q", since)
+		}
+	}
+
+	switch format, _ := cmd.Flags().GetString("format"); format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+	return nil
+}
+
+// run is a starting point method for executing the history command.
+func (r History) run(ctx context.Context, out io.Writer, opts historyOptions) error {
+	source := bytes.NewBuffer(nil)
+	if err := r.Driver.Source(ctx, source); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(source.String())
+	if err != nil {
+		return err
+	}
+
+	db, err := store.Open(u.Scheme, source.String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	records, err := openState(ctx, r.Driver, db)
+	if err != nil {
+		return err
+	}
+
+	history, err := records.History(ctx)
+	if err != nil {
+		return err
+	}
+
+	filtered := make([]state.Event, 0, len(history))
+	for _, record := range history {
+		if opts.Tag != "" && record.Tag != opts.Tag {
+			continue
+		}
+		if opts.Action != "" && record.Action != opts.Action {
+			continue
+		}
+		if opts.Actor != "" && record.Actor != opts.Actor {
+			continue
+		}
+		if !opts.Since.IsZero() && record.Timestamp.Before(opts.Since) {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+
+	if opts.Format == "json" {
+		events := make([]historyEvent, 0, len(filtered))
+		for _, record := range filtered {
+			events = append(events, historyEvent{
+				Tag:       record.Tag,
+				Name:      record.Name,
+				Action:    record.Action,
+				Direction: record.Direction,
+				Actor:     record.Actor,
+				Duration:  int64(record.Duration / time.Millisecond),
+				Error:     record.Error,
+				Timestamp: record.Timestamp,
+			})
+		}
+
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(events)
+	}
+
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{
+		"Timestamp", "Tag", "Name", "Action",
+		"Direction", "Actor", "Duration", "Error",
+	})
+	table.SetColWidth(60)
+	for _, record := range filtered {
+		table.Append([]string{
+			record.Timestamp.Format(time.RFC3339),
+			record.Tag,
+			record.Name,
+			record.Action,
+			record.Direction,
+			record.Actor,
+			strconv.FormatInt(int64(record.Duration/time.Millisecond), 10) + "ms",
+			record.Error,
+		})
+	}
+
+	if len(filtered) > 0 {
+		table.Render()
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package migrations
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type HistorySuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *HistorySuite) SetupSuite() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+	assert.Nil(r.T(), os.Setenv("MIGRATE_ACTOR", "unittest"))
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-users-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up:   []types.Operation{{Query: `CREATE TABLE users (id int)`}},
+				Down: []types.Operation{{Query: `DROP TABLE users`}},
+			},
+			{
+				Name: "seed-accounts",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up:   []types.Operation{{Query: `INSERT INTO accounts(id) VALUES (1)`}},
+				Down: []types.Operation{{Query: `DELETE FROM accounts`}},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *HistorySuite) TearDownSuite() {
+	assert.Nil(r.T(), os.Unsetenv("MIGRATE_ACTOR"))
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *HistorySuite) TestHistoryCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			true, "migration query failed \"0.0.2_seed-accounts\" (up)\n" +
+				"INSERT INTO accounts(id) VALUES (1)",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0"},
+			"",
+		},
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0"},
+			"migration \"0.0.2_seed-accounts\" successfully applied (up)\n",
+		},
+		{
+			false, "",
+			Down{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"--mark"},
+			"migration \"0.0.2_seed-accounts\" successfully marked (down)\n",
+		},
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"--mark"},
+			"migration \"0.0.2_seed-accounts\" successfully marked (up)\n",
+		},
+		{
+			false, "",
+			Down{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"migration \"0.0.2_seed-accounts\" successfully removed (down)\n",
+		},
+		{
+			false, "",
+			History{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-f", "json"},
+			"0.0.1 up up unittest\n" +
+				"0.0.2 failure up unittest\n" +
+				"0.0.2 retry up unittest\n" +
+				"0.0.2 mark down unittest\n" +
+				"0.0.2 mark up unittest\n" +
+				"0.0.2 down down unittest\n",
+		},
+		{
+			false, "",
+			History{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-f", "json", "--tag", "0.0.2", "--action", "mark"},
+			"0.0.2 mark down unittest\n" +
+				"0.0.2 mark up unittest\n",
+		},
+		{
+			false, "",
+			History{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-f", "json", "--actor", "nobody"},
+			"",
+		},
+		{
+			false, "",
+			History{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-f", "json", "--since", "2999-01-01T00:00:00Z"},
+			"",
+		},
+		{
+			true, "invalid format \"xml\" for \"history\"\n\n" +
+				"Usage:\n" +
+				"  history [flags]\n\n" +
+				"Flags:\n" +
+				"      --tag TAG         Only show events of the migration with TAG.\n" +
+				"      --action ACTION   Only show events of ACTION (up, down, mark, failure or retry).\n" +
+				"      --actor NAME      Only show events recorded by NAME.\n" +
+				"      --since TIME      Only show events recorded after TIME in RFC3339 format.\n" +
+				"  -f, --format FORMAT   Output FORMAT (table or json). (default \"table\")\n" +
+				"      --help            Show help information.\n",
+			History{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-f", "xml"},
+			"",
+		},
+		{
+			true, "invalid time \"yesterday\" for \"history\"\n\n" +
+				"Usage:\n" +
+				"  history [flags]\n\n" +
+				"Flags:\n" +
+				"      --tag TAG         Only show events of the migration with TAG.\n" +
+				"      --action ACTION   Only show events of ACTION (up, down, mark, failure or retry).\n" +
+				"      --actor NAME      Only show events recorded by NAME.\n" +
+				"      --since TIME      Only show events recorded after TIME in RFC3339 format.\n" +
+				"  -f, --format FORMAT   Output FORMAT (table or json). (default \"table\")\n" +
+				"      --help            Show help information.\n",
+			History{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"--since", "yesterday"},
+			"",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("history", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			output := tc.buffer.String()
+			if _, ok := tc.cmd.(History); ok {
+				events := make([]historyEvent, 0)
+				if err := json.Unmarshal(tc.buffer.Bytes(), &events); err != nil {
+					panic(err.Error())
+				}
+
+				summary := make([]string, 0, len(events))
+				for _, event := range events {
+					summary = append(summary, strings.Join([]string{
+						event.Tag, event.Action, event.Direction, event.Actor,
+					}, " ")+"\n")
+				}
+				output = strings.Join(summary, "")
+			}
+
+			if tc.output != output {
+				panic(output)
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+
+		// the second migration succeeds only once the table exists.
+		if i == 0 {
+			db, err := sql.Open("sqlite3", r.File)
+			if err != nil {
+				panic(err)
+			}
+			_, err = db.Exec(`CREATE TABLE accounts (id int)`)
+			assert.Nil(r.T(), err)
+			assert.Nil(r.T(), db.Close())
+		}
+	}
+}
+
+func TestHistorySuite(t *testing.T) {
+	suite.Run(t, new(HistorySuite))
+}
@@ -0,0 +1,175 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type PhaseSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *PhaseSuite) SetupSuite() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name:  "add-email-column",
+				Tag:   semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Phase: types.PhasePreDeploy,
+				Up:    []types.Operation{{Query: `CREATE TABLE users (name text, email text)`}},
+				Down:  []types.Operation{{Query: `DROP TABLE users`}},
+			},
+			{
+				Name:  "drop-name-column",
+				Tag:   semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Phase: types.PhasePostDeploy,
+				Up:    []types.Operation{{Query: `CREATE TABLE emails (email text)`}},
+				Down:  []types.Operation{{Query: `DROP TABLE emails`}},
+			},
+			{
+				Name:  "add-phone-column",
+				Tag:   semver.Version{Major: 0, Minor: 0, Patch: 3},
+				Phase: types.PhasePreDeploy,
+				Up:    []types.Operation{{Query: `CREATE TABLE phones (phone text)`}},
+				Down:  []types.Operation{{Query: `DROP TABLE phones`}},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *PhaseSuite) TearDownSuite() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *PhaseSuite) TestPhaseCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Report{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"+-------+------------------+-------------+---------+\n" +
+				"|  TAG  |       NAME       |    PHASE    | APPLIED |\n" +
+				"+-------+------------------+-------------+---------+\n" +
+				"| 0.0.1 | add-email-column | pre-deploy  | pending |\n" +
+				"| 0.0.2 | drop-name-column | post-deploy | pending |\n" +
+				"| 0.0.3 | add-phone-column | pre-deploy  | pending |\n" +
+				"+-------+------------------+-------------+---------+\n",
+		},
+		{
+			true,
+			"invalid phase \"predeploy\" for \"phase\"\n" +
+				"\n" +
+				"Usage:\n" +
+				"  phase [flags]\n" +
+				"\n" +
+				"Flags:\n" +
+				"  -l, --limit NUMBER   Indicate NUMBER of migrations to apply. Set `0` for all. (default 1)\n" +
+				"      --try            Simulates and prints resource execution parameters.\n" +
+				"      --snapshot       Snapshot the database first and restore it if any migration fails.\n" +
+				"      --mark           Records migrations as applied without executing them.\n" +
+				"      --phase NAME     Apply only migrations of deployment phase NAME.\n" +
+				"      --help           Show help information.\n",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--phase", "predeploy"},
+			"",
+		},
+		{
+			true, "migration \"0.0.1_add-email-column\" must be applied before \"0.0.2_drop-name-column\" (post-deploy)",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--phase", "post-deploy"},
+			"",
+		},
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--phase", "pre-deploy"},
+			"migration \"0.0.1_add-email-column\" successfully applied (up)\n" +
+				"migration \"0.0.3_add-phone-column\" successfully applied (up)\n",
+		},
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--phase", "post-deploy"},
+			"migration \"0.0.2_drop-name-column\" successfully applied (up)\n",
+		},
+		{
+			true, "no pending migrations of phase \"pre-deploy\"",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--phase", "pre-deploy"},
+			"",
+		},
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0"},
+			"",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("phase", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestPhaseSuite(t *testing.T) {
+	suite.Run(t, new(PhaseSuite))
+}
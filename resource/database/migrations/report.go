@@ -17,7 +17,9 @@ import (
 	"github.com/trivigy/migrate/v2/global"
 	"github.com/trivigy/migrate/v2/internal/store"
 	"github.com/trivigy/migrate/v2/internal/store/model"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
@@ -41,8 +43,11 @@ func (r Report) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Prints which migrations were applied and when.",
 		Long:  "Prints which migrations were applied and when",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return r.run(context.Background(), cmd.OutOrStdout())
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			return r.run(ctx, cmd.OutOrStdout())
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -95,19 +100,24 @@ func (r Report) run(ctx context.Context, out io.Writer) error {
 		return err
 	}
 
-	if err := db.Migrations.CreateTableIfNotExists(); err != nil {
+	records, err := openState(ctx, r.Driver, db)
+	if err != nil {
 		return err
 	}
 
+	if r.Driver.Migrations().HasDependencies() || r.Driver.Migrations().HasPhases() {
+		return r.graphReport(ctx, records, out)
+	}
+
 	sort.Sort(r.Driver.Migrations())
 	sortedRegistryMigrations := r.Driver.Migrations()
-	sortedDatabaseMigrations, err := db.Migrations.GetMigrationsSorted()
+	sortedDatabaseMigrations, err := recordedMigrations(ctx, records)
 	if err != nil {
 		return err
 	}
 
 	table := tablewriter.NewWriter(out)
-	table.SetHeader([]string{"Tag", "Name", "Applied"})
+	table.SetHeader([]string{"Tag", "Name", "Phase", "Applied"})
 	table.SetColWidth(60)
 
 	maxSize := max(len(*sortedRegistryMigrations), len(sortedDatabaseMigrations))
@@ -132,9 +142,9 @@ func (r Report) run(ctx context.Context, out io.Writer) error {
 			}
 
 			timestamp := dbMig.Timestamp.Format(time.RFC3339)
-			table.Append([]string{dbMig.Tag, dbMig.Name, timestamp})
+			table.Append([]string{dbMig.Tag, dbMig.Name, string(rgMig.Phase), timestamp})
 		} else if rgMig != nil && dbMig == nil {
-			table.Append([]string{rgMig.Tag.String(), rgMig.Name, "pending"})
+			table.Append([]string{rgMig.Tag.String(), rgMig.Name, string(rgMig.Phase), "pending"})
 		} else if rgMig == nil && dbMig != nil {
 			return fmt.Errorf("migration tags missing %q", dbMig.Tag)
 		}
@@ -146,3 +156,35 @@ func (r Report) run(ctx context.Context, out io.Writer) error {
 
 	return nil
 }
+
+// graphReport prints the migrations in dependency graph order. Unlike linear
+// migrations, any subset of the graph or of the phases may be applied.
+func (r Report) graphReport(ctx context.Context, records state.Store, out io.Writer) error {
+	order, err := r.Driver.Migrations().TopologicalSort()
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(ctx, records, r.Driver.Migrations())
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Tag", "Name", "Phase", "Applied"})
+	table.SetColWidth(60)
+	for _, migration := range order {
+		status := "pending"
+		if record, ok := applied[migration.Tag.String()]; ok {
+			status = record.Timestamp.Format(time.RFC3339)
+		}
+		table.Append([]string{
+			migration.Tag.String(),
+			migration.Name,
+			string(migration.Phase),
+			status,
+		})
+	}
+	table.Render()
+	return nil
+}
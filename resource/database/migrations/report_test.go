@@ -24,13 +24,13 @@ func (r *MigrationsSuite) TestReportCommand() {
 			Report{Driver: r.Driver},
 			bytes.NewBuffer(nil),
 			[]string{},
-			"+-------+-----------------------+---------+\n" +
-				"|  TAG  |         NAME          | APPLIED |\n" +
-				"+-------+-----------------------+---------+\n" +
-				"| 0.0.1 | create-unittest-table | pending |\n" +
-				"| 0.0.2 | seed-dummy-data       | pending |\n" +
-				"| 0.0.3 | seed-more-dummy-data  | pending |\n" +
-				"+-------+-----------------------+---------+\n",
+			"+-------+-----------------------+-------+---------+\n" +
+				"|  TAG  |         NAME          | PHASE | APPLIED |\n" +
+				"+-------+-----------------------+-------+---------+\n" +
+				"| 0.0.1 | create-unittest-table |       | pending |\n" +
+				"| 0.0.2 | seed-dummy-data       |       | pending |\n" +
+				"| 0.0.3 | seed-more-dummy-data  |       | pending |\n" +
+				"+-------+-----------------------+-------+---------+\n",
 		},
 	}
 
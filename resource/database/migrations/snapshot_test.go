@@ -0,0 +1,161 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type SnapshotSuite struct {
+	suite.Suite
+	File string
+}
+
+func (r *SnapshotSuite) SetupTest() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+}
+
+func (r *SnapshotSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.File+".snapshots"))
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *SnapshotSuite) TestUpRestoresSnapshot() {
+	driver := testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-unittest-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up: []types.Operation{
+					{Query: `CREATE TABLE unittests (value text)`},
+				},
+			},
+			{
+				Name: "broken-query",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up: []types.Operation{
+					{Query: `INSERT INTO missing(value) VALUES ('hello')`},
+				},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: driver}.Execute("up", buffer, []string{"-l", "0", "--snapshot"})
+	assert.EqualError(r.T(), err,
+		"migration query failed \"0.0.2_broken-query\" (up)\n"+
+			"INSERT INTO missing(value) VALUES ('hello')",
+	)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(r.T(), lines, 3, buffer.String())
+	assert.Regexp(r.T(), `^snapshot "up-\d{14}" successfully created$`, lines[0])
+	assert.Equal(r.T(), "migration \"0.0.1_create-unittest-table\" successfully applied (up)", lines[1])
+	assert.Regexp(r.T(), `^snapshot "up-\d{14}" successfully restored$`, lines[2])
+
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	var count int
+	assert.Nil(r.T(), db.QueryRow(`SELECT count(*) FROM migrations`).Scan(&count))
+	assert.Equal(r.T(), 0, count)
+	assert.Nil(r.T(), db.QueryRow(
+		`SELECT count(*) FROM sqlite_master WHERE name = 'unittests'`,
+	).Scan(&count))
+	assert.Equal(r.T(), 0, count)
+
+	var actions string
+	assert.Nil(r.T(), db.QueryRow(
+		`SELECT group_concat(action) FROM migrations_history ORDER BY id`,
+	).Scan(&actions))
+	assert.Equal(r.T(), "up,failure", actions)
+}
+
+// cancelAwareSQL fails restoring snapshots with a cancelled context the way a
+// database server driver would.
+type cancelAwareSQL struct {
+	*generic.SQL
+}
+
+func (r cancelAwareSQL) RestoreSnapshot(ctx context.Context, out io.Writer, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.SQL.RestoreSnapshot(ctx, out, name)
+}
+
+func (r *SnapshotSuite) TestUpRestoresSnapshotAfterTimeout() {
+	driver := testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-unittest-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up: []types.Operation{
+					{Query: `CREATE TABLE unittests (value text)`},
+				},
+			},
+			{
+				Name: "slow-query",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up: []types.Operation{
+					{Query: `WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c`},
+				},
+			},
+		},
+		Driver: cancelAwareSQL{&generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		}},
+	}.Build()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: driver}.run(ctx, buffer, upOptions{Snapshot: true})
+	assert.NotNil(r.T(), err)
+	assert.NotContains(r.T(), err.Error(), "failed restoring snapshot")
+	assert.Regexp(r.T(), `snapshot "up-\d{14}" successfully restored\n$`, buffer.String())
+
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	var count int
+	assert.Nil(r.T(), db.QueryRow(
+		`SELECT count(*) FROM sqlite_master WHERE name = 'unittests'`,
+	).Scan(&count))
+	assert.Equal(r.T(), 0, count)
+}
+
+func TestSnapshotSuite(t *testing.T) {
+	suite.Run(t, new(SnapshotSuite))
+}
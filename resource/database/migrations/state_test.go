@@ -0,0 +1,239 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type StateSuite struct {
+	suite.Suite
+	Dir    string
+	State  state.Store
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *StateSuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+	r.State = state.NewFile(filepath.Join(dir, "state.json"))
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-users-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up:   []types.Operation{{Query: `CREATE TABLE users (id int)`}},
+				Down: []types.Operation{{Query: `DROP TABLE users`}},
+			},
+			{
+				Name: "create-items-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up:   []types.Operation{{Query: `CREATE TABLE items (id int)`}},
+				Down: []types.Operation{{Query: `DROP TABLE items`}},
+			},
+		},
+		State: r.State,
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + filepath.Join(dir, "test.db"),
+		},
+	}.Build()
+}
+
+func (r *StateSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *StateSuite) TestState() {
+	ctx := context.Background()
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+
+	migrations, err := r.State.Migrations(ctx)
+	assert.Nil(r.T(), err)
+	assert.Len(r.T(), migrations, 2)
+
+	db, err := store.Open("sqlite3", filepath.Join(r.Dir, "test.db"))
+	assert.Nil(r.T(), err)
+	defer db.Close()
+	assert.Nil(r.T(), db.Migrations.CreateTableIfNotExists())
+	records, err := db.Migrations.GetMigrations()
+	assert.Nil(r.T(), err)
+	assert.Empty(r.T(), records)
+
+	buffer.Reset()
+	err = Report{Driver: r.Driver}.Execute("report", buffer, []string{})
+	assert.Nil(r.T(), err)
+	assert.NotContains(r.T(), buffer.String(), "pending")
+
+	err = Down{Driver: r.Driver}.Execute("down", bytes.NewBuffer(nil), []string{})
+	assert.Nil(r.T(), err)
+
+	migrations, err = r.State.Migrations(ctx)
+	assert.Nil(r.T(), err)
+	assert.Len(r.T(), migrations, 1)
+	assert.Equal(r.T(), "0.0.1", migrations[0].Tag)
+}
+
+func (r *StateSuite) TestStateLocked() {
+	assert.Nil(r.T(), r.State.Lock(context.Background(), "migrations", "someone"))
+
+	err := Up{Driver: r.Driver}.Execute("up", bytes.NewBuffer(nil), []string{"-l", "0"})
+	assert.True(r.T(), errors.Is(err, state.ErrLocked))
+
+	migrations, err := r.State.Migrations(context.Background())
+	assert.Nil(r.T(), err)
+	assert.Empty(r.T(), migrations)
+}
+
+// callsStore implements a state store which records the order in which the
+// migrations lock and records are accessed.
+type callsStore struct {
+	state.Store
+	calls []string
+}
+
+func (r *callsStore) Migrations(ctx context.Context) ([]state.Migration, error) {
+	r.calls = append(r.calls, "migrations")
+	return r.Store.Migrations(ctx)
+}
+
+func (r *callsStore) Lock(ctx context.Context, name, holder string) error {
+	r.calls = append(r.calls, "lock")
+	return r.Store.Lock(ctx, name, holder)
+}
+
+func (r *StateSuite) TestStateLockedPlan() {
+	records := &callsStore{Store: r.State}
+	d := testutils.Database{
+		Migrations: r.Driver.Migrations(),
+		State:      records,
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + filepath.Join(r.Dir, "test.db"),
+		},
+	}.Build()
+
+	testCases := []struct {
+		cmd  types.Command
+		args []string
+	}{
+		{Up{Driver: d}, []string{"-l", "0"}},
+		{Down{Driver: d}, []string{"-l", "0"}},
+	}
+
+	for i, testCase := range testCases {
+		records.calls = nil
+		err := testCase.cmd.Execute("migrate", bytes.NewBuffer(nil), testCase.args)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Equal(r.T(), "lock", records.calls[0], "testCase: %d", i)
+	}
+}
+
+func (r *StateSuite) TestStateBookkeeping() {
+	*r.Driver.Migrations() = append(*r.Driver.Migrations(), &types.Migration{
+		Name: "backfill-users",
+		Tag:  semver.Version{Major: 0, Minor: 0, Patch: 3},
+		Up: []types.Operation{{
+			Query: `DELETE FROM users WHERE id IN (SELECT id FROM users LIMIT 1)`,
+			Batch: &types.Batch{},
+		}},
+		Down: []types.Operation{},
+	})
+
+	err := Up{Driver: r.Driver}.Execute("up", bytes.NewBuffer(nil), []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+
+	migrations, err := r.State.Migrations(context.Background())
+	assert.Nil(r.T(), err)
+	assert.Len(r.T(), migrations, 3)
+
+	db, err := store.Open("sqlite3", filepath.Join(r.Dir, "test.db"))
+	assert.Nil(r.T(), err)
+	defer db.Close()
+
+	tables, err := db.GetDBMap().SelectInt(
+		"SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name LIKE 'migrations%'",
+	)
+	assert.Nil(r.T(), err)
+	assert.Zero(r.T(), tables)
+
+	history, err := r.State.History(context.Background())
+	assert.Nil(r.T(), err)
+	assert.Len(r.T(), history, 3)
+
+	buffer := bytes.NewBuffer(nil)
+	err = History{Driver: r.Driver}.Execute("history", buffer, []string{"--tag", "0.0.3"})
+	assert.Nil(r.T(), err)
+	assert.Contains(r.T(), buffer.String(), "backfill-users")
+}
+
+func (r *StateSuite) TestStateBatchResume() {
+	ctx := context.Background()
+	*r.Driver.Migrations() = append(*r.Driver.Migrations(), &types.Migration{
+		Name: "backfill-users",
+		Tag:  semver.Version{Major: 0, Minor: 0, Patch: 3},
+		Up: []types.Operation{{
+			Query: `DELETE FROM users WHERE id IN (SELECT id FROM users LIMIT 1)`,
+			Batch: &types.Batch{},
+		}},
+		Down: []types.Operation{},
+	})
+
+	migration := (*r.Driver.Migrations())[2]
+	keys := migration.BatchKeys(types.DirectionUp)
+	assert.Len(r.T(), keys, 1)
+	assert.Nil(r.T(), r.State.SaveBatch(ctx, state.Batch{Key: keys[0], Batch: 4, Rows: 4}))
+
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+	assert.Contains(r.T(), buffer.String(), "resuming migration \"0.0.3_backfill-users\" (up) from batch 5 (4 rows)\n")
+
+	batch, err := r.State.Batch(ctx, keys[0])
+	assert.Nil(r.T(), err)
+	assert.Nil(r.T(), batch)
+}
+
+func (r *StateSuite) TestStateForceUnlock() {
+	assert.Nil(r.T(), r.State.Lock(context.Background(), "migrations", "someone"))
+
+	buffer := bytes.NewBuffer(nil)
+	err := ForceUnlock{Driver: r.Driver}.Execute("force-unlock", buffer, []string{})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "lock \"migrations\" successfully released\n", buffer.String())
+
+	err = Up{Driver: r.Driver}.Execute("up", bytes.NewBuffer(nil), []string{"-l", "0"})
+	assert.Nil(r.T(), err)
+
+	migrations, err := r.State.Migrations(context.Background())
+	assert.Nil(r.T(), err)
+	assert.Len(r.T(), migrations, 2)
+}
+
+func TestStateSuite(t *testing.T) {
+	suite.Run(t, new(StateSuite))
+}
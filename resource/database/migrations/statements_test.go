@@ -0,0 +1,138 @@
+package migrations
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type StatementsSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *StatementsSuite) SetupSuite() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-users-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up: []types.Operation{{Query: "" +
+					"CREATE TABLE users (name text);\n" +
+					"INSERT INTO users(name) VALUES ('a;b');\n",
+				}},
+				Down: []types.Operation{{Query: `DROP TABLE users`}},
+			},
+			{
+				Name: "create-emails-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up: []types.Operation{{Query: "" +
+					"CREATE TABLE emails (email text);\n" +
+					"-- the table is missing\n" +
+					"INSERT INTO missing(email) VALUES ('hello');\n",
+				}},
+				Down: []types.Operation{{Query: `DROP TABLE emails`}},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *StatementsSuite) TearDownSuite() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *StatementsSuite) TestStatementsCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0", "--try"},
+			"==> migration \"0.0.1_create-users-table\" (up)\n" +
+				"-- statement 1 (line 1)\n" +
+				"CREATE TABLE users (name text);\n" +
+				"-- statement 2 (line 2)\n" +
+				"INSERT INTO users(name) VALUES ('a;b');\n" +
+				"==> migration \"0.0.2_create-emails-table\" (up)\n" +
+				"-- statement 1 (line 1)\n" +
+				"CREATE TABLE emails (email text);\n" +
+				"-- statement 2 (line 2)\n" +
+				"-- the table is missing\n" +
+				"INSERT INTO missing(email) VALUES ('hello');\n",
+		},
+		{
+			true, "migration query failed \"0.0.2_create-emails-table\" (up) statement 2 (line 2)\n" +
+				"-- the table is missing\n" +
+				"INSERT INTO missing(email) VALUES ('hello')",
+			Up{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0"},
+			"",
+		},
+		{
+			false, "",
+			Down{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"-l", "0"},
+			"migration \"0.0.1_create-users-table\" successfully removed (down)\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("statements", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestStatementsSuite(t *testing.T) {
+	suite.Run(t, new(StatementsSuite))
+}
@@ -0,0 +1,148 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type TelemetrySuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithMigrations
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *TelemetrySuite) SetupTest() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.Driver = testutils.Database{
+		Migrations: &types.Migrations{
+			{
+				Name: "create-users-table",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Up: []types.Operation{
+					{Query: `CREATE TABLE users (id int)`},
+				},
+			},
+			{
+				Name: "broken-migration",
+				Tag:  semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Up: []types.Operation{
+					{Query: `CREATE TABLE users (id int)`},
+				},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+}
+
+func (r *TelemetrySuite) TearDownTest() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *TelemetrySuite) TestTelemetry() {
+	tele := testutils.NewTelemetry()
+	wrap := types.Executor{Name: "up", Command: Up{Driver: r.Driver}}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := Up{Driver: r.Driver}.NewCommand(tele.Context(ctx), "up")
+	cmd.SetOut(bytes.NewBuffer(nil))
+	cmd.SetArgs([]string{"-l", "0"})
+	assert.NotNil(r.T(), cmd.Execute())
+
+	type span struct {
+		name   string
+		failed bool
+		attrs  []attribute.KeyValue
+	}
+
+	spans := make([]span, 0)
+	for _, s := range tele.Spans() {
+		spans = append(spans, span{
+			name:   s.Name(),
+			failed: s.Status().Code == codes.Error,
+			attrs:  s.Attributes(),
+		})
+	}
+
+	migration := func(tag, name string) []attribute.KeyValue {
+		return []attribute.KeyValue{
+			attribute.String("migrate.tag", tag),
+			attribute.String("migrate.name", name),
+			attribute.String("migrate.direction", "up"),
+		}
+	}
+	assert.Equal(r.T(), []span{
+		{"migrate.operation", false, migration("0.0.1", "create-users-table")},
+		{"migrate.migration", false, migration("0.0.1", "create-users-table")},
+		{"migrate.operation", true, migration("0.0.2", "broken-migration")},
+		{"migrate.migration", true, migration("0.0.2", "broken-migration")},
+		{"migrate.command", true, []attribute.KeyValue{attribute.String("migrate.command", "up")}},
+	}, spans)
+
+	rm, err := tele.Metrics(context.Background())
+	assert.Nil(r.T(), err)
+
+	failures := make(map[string]int64)
+	durations := make(map[string]uint64)
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, point := range data.DataPoints {
+					failures[m.Name] += point.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					durations[m.Name] += point.Count
+				}
+			}
+		}
+	}
+	assert.Equal(r.T(), map[string]int64{
+		"migrate.command.failures":   1,
+		"migrate.migration.failures": 1,
+		"migrate.operation.failures": 1,
+	}, failures)
+	assert.Equal(r.T(), map[string]uint64{
+		"migrate.command.duration":   1,
+		"migrate.migration.duration": 2,
+		"migrate.operation.duration": 2,
+	}, durations)
+}
+
+func (r *TelemetrySuite) TestTelemetryNoop() {
+	buffer := bytes.NewBuffer(nil)
+	err := Up{Driver: r.Driver}.Execute("up", buffer, []string{"-l", "1"})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "migration \"0.0.1_create-users-table\" successfully applied (up)\n", buffer.String())
+}
+
+func TestTelemetrySuite(t *testing.T) {
+	suite.Run(t, new(TelemetrySuite))
+}
@@ -14,9 +14,12 @@ import (
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
 	"github.com/trivigy/migrate/v2/internal/store"
-	"github.com/trivigy/migrate/v2/internal/store/model"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
@@ -30,8 +33,11 @@ type Up struct {
 
 // upOptions is used for executing the run() command.
 type upOptions struct {
-	Limit int  `json:"limit" yaml:"limit"`
-	Try   bool `json:"try" yaml:"try"`
+	Limit    int         `json:"limit" yaml:"limit"`
+	Try      bool        `json:"try" yaml:"try"`
+	Snapshot bool        `json:"snapshot" yaml:"snapshot"`
+	Mark     bool        `json:"mark" yaml:"mark"`
+	Phase    types.Phase `json:"phase" yaml:"phase"`
 }
 
 var _ interface {
@@ -46,7 +52,10 @@ func (r Up) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Executes the next queued migration.",
 		Long:  "Executes the next queued migration",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			if patches, ok := r.Driver.(driver.WithPatches); ok {
 				for _, patch := range *patches.Patches(name) {
 					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
@@ -57,8 +66,17 @@ func (r Up) NewCommand(ctx context.Context, name string) *cobra.Command {
 
 			limit, _ := cmd.Flags().GetInt("limit")
 			try, _ := cmd.Flags().GetBool("try")
-			opts := upOptions{Limit: limit, Try: try}
-			return r.run(context.Background(), cmd.OutOrStdout(), opts)
+			snapshot, _ := cmd.Flags().GetBool("snapshot")
+			mark, _ := cmd.Flags().GetBool("mark")
+			phase, _ := cmd.Flags().GetString("phase")
+			opts := upOptions{
+				Limit:    limit,
+				Try:      try,
+				Snapshot: snapshot,
+				Mark:     mark,
+				Phase:    types.Phase(phase),
+			}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -77,6 +95,18 @@ func (r Up) NewCommand(ctx context.Context, name string) *cobra.Command {
 		"try", false,
 		"Simulates and prints resource execution parameters.",
 	)
+	flags.Bool(
+		"snapshot", false,
+		"Snapshot the database first and restore it if any migration fails.",
+	)
+	flags.Bool(
+		"mark", false,
+		"Records migrations as applied without executing them.",
+	)
+	flags.String(
+		"phase", "",
+		"Apply only migrations of deployment phase `NAME`.",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -99,6 +129,12 @@ func (r Up) validation(cmd *cobra.Command, args []string) error {
 	if err := require.NoArgs(args); err != nil {
 		return err
 	}
+
+	if phase, _ := cmd.Flags().GetString("phase"); phase != "" {
+		if !types.Phase(phase).Valid() {
+			return fmt.Errorf("invalid phase %q", phase)
+		}
+	}
 	return nil
 }
 
@@ -119,12 +155,39 @@ func (r Up) run(ctx context.Context, out io.Writer, opts upOptions) error {
 	if err != nil {
 		return err
 	}
+	// the connection is reopened around snapshots therefore the latest one
+	// is closed.
+	defer func() { db.Close() }()
+
+	records, err := openState(ctx, r.Driver, db)
+	if err != nil {
+		return err
+	}
+
+	// the lock is held from planning until the records are written so that
+	// concurrent runs never execute the same plan twice.
+	if !opts.Try {
+		if err := records.Lock(ctx, lockName, state.Actor()); err != nil {
+			return err
+		}
+		// the lock is released even when the context was cancelled.
+		defer func() { records.Unlock(context.Background(), lockName) }()
+	}
 
-	migrationPlan, err := GenerateMigrationPlan(db, types.DirectionUp, r.Driver.Migrations())
+	migrationPlan, err := GenerateMigrationPlan(ctx, records, types.DirectionUp, r.Driver.Migrations())
 	if err != nil {
 		return err
 	}
 
+	if opts.Phase != "" {
+		if migrationPlan, err = filterPhase(migrationPlan, opts.Phase); err != nil {
+			return err
+		}
+		if len(migrationPlan) == 0 {
+			return fmt.Errorf("no pending migrations of phase %q", opts.Phase)
+		}
+	}
+
 	steps := len(migrationPlan)
 	if opts.Limit > 0 && opts.Limit <= steps {
 		steps = opts.Limit
@@ -137,34 +200,160 @@ func (r Up) run(ctx context.Context, out io.Writer, opts upOptions) error {
 				types.DirectionUp,
 			)
 			for _, op := range migrationPlan[i].Up {
-				fmt.Fprintf(out, "%s;\n", op.Query)
+				op.Print(out, db.Dialect())
 			}
 		}
 	} else {
+		var snapshots driver.WithSnapshots
+		var snapshot string
+		if opts.Snapshot && steps > 0 {
+			var ok bool
+			if snapshots, ok = r.Driver.(driver.WithSnapshots); !ok {
+				return fmt.Errorf("driver does not support snapshots")
+			}
+
+			// the connection is closed while copying because some databases
+			// refuse to copy a database which is in use.
+			if err := db.Close(); err != nil {
+				return err
+			}
+
+			start := time.Now()
+			snapshot = "up-" + start.UTC().Format("20060102150405")
+			if err := snapshots.Snapshot(ctx, out, snapshot); err != nil {
+				return err
+			}
+			if err := reportSnapshot(ctx, out, snapshot, events.StatusCreated, start); err != nil {
+				return err
+			}
+
+			if db, err = store.Open(uri.Scheme, source.String()); err != nil {
+				return err
+			}
+			if records, err = openState(ctx, r.Driver, db); err != nil {
+				return err
+			}
+		}
+
+		history := newRecorder()
 		for i := 0; i < steps; i++ {
-			for _, op := range migrationPlan[i].Up {
-				err := op.Execute(db, migrationPlan[i], types.DirectionUp)
-				if err != nil {
-					return err
-				}
+			// interrupts are honoured only between migrations so that a
+			// migration and its bookkeeping record are never separated.
+			if err := interrupt.Err(ctx); err != nil {
+				return err
 			}
 
-			if err := db.Migrations.Insert(&model.Migration{
-				Tag:       migrationPlan[i].Tag.String(),
-				Timestamp: time.Now(),
-			}); err != nil {
-				return fmt.Errorf(
-					"failed recording migration %q (%s)",
-					migrationPlan[i].Tag.String()+"_"+migrationPlan[i].Name,
-					types.DirectionUp,
-				)
+			err := r.apply(ctx, db, records, history, out, migrationPlan[i], opts.Mark)
+			if err == nil {
+				continue
 			}
 
-			fmt.Fprintf(out, "migration %q successfully applied (%s)\n",
-				migrationPlan[i].Tag.String()+"_"+migrationPlan[i].Name,
-				types.DirectionUp,
-			)
+			if snapshot == "" {
+				return err
+			}
+
+			// the restore is the safety net of a failed or interrupted run
+			// therefore it must not be cut short by the cancelled context.
+			ctx := context.WithoutCancel(ctx)
+
+			db.Close()
+			start := time.Now()
+			if rerr := snapshots.RestoreSnapshot(ctx, out, snapshot); rerr != nil {
+				return fmt.Errorf("%s\nfailed restoring snapshot %q (%s)", err, snapshot, rerr)
+			}
+			if rerr := reportSnapshot(ctx, out, snapshot, events.StatusRestored, start); rerr != nil {
+				return rerr
+			}
+
+			// the lock restored together with the database is released
+			// through the new connection.
+			var rerr error
+			if db, rerr = store.Open(uri.Scheme, source.String()); rerr != nil {
+				return rerr
+			}
+			if records, rerr = openState(ctx, r.Driver, db); rerr != nil {
+				return rerr
+			}
+
+			// records kept outside of the database are not restored by the
+			// snapshot therefore the migrations applied so far and the
+			// progress of the failed one are erased. Records kept on the
+			// database are restored, however the history is append-only
+			// therefore events erased by the restore are recorded again.
+			if _, ok := r.Driver.(driver.WithState); ok {
+				for _, migration := range migrationPlan[:i] {
+					if rerr := records.DeleteMigration(ctx, migration.Tag.String()); rerr != nil {
+						return rerr
+					}
+				}
+				for _, key := range migrationPlan[i].BatchKeys(types.DirectionUp) {
+					if rerr := records.DeleteBatch(ctx, key); rerr != nil {
+						return rerr
+					}
+				}
+			} else if rerr := history.replay(ctx, records); rerr != nil {
+				return rerr
+			}
+			return err
 		}
 	}
 	return nil
 }
+
+// apply executes the up operations of a single migration and records it.
+// Marking records the migration without executing any of the operations.
+func (r Up) apply(
+	ctx context.Context,
+	db *store.Context,
+	records state.Store,
+	history *recorder,
+	out io.Writer,
+	migration *types.Migration,
+	mark bool,
+) (err error) {
+	start := time.Now()
+	ctx, end := startMigration(ctx, migration, types.DirectionUp)
+	defer func() {
+		if err != nil {
+			_ = report(ctx, out, migration, types.DirectionUp, start, "", err)
+		}
+		end(err)
+	}()
+
+	action := ""
+	if mark {
+		action = actionMark
+	} else {
+		for _, op := range migration.Up {
+			err := op.Execute(ctx, db, records, out, migration, types.DirectionUp)
+			if err != nil {
+				if herr := history.record(ctx, records, migration, types.DirectionUp, actionFailure, start, err); herr != nil {
+					return herr
+				}
+				return err
+			}
+		}
+	}
+
+	if err := records.InsertMigration(ctx, state.Migration{
+		Tag:       migration.Tag.String(),
+		Name:      migration.Name,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return fmt.Errorf(
+			"failed recording migration %q (%s)",
+			migration.Tag.String()+"_"+migration.Name,
+			types.DirectionUp,
+		)
+	}
+
+	if err := history.record(ctx, records, migration, types.DirectionUp, action, start, nil); err != nil {
+		return err
+	}
+
+	status := events.StatusApplied
+	if mark {
+		status = events.StatusMarked
+	}
+	return report(ctx, out, migration, types.DirectionUp, start, status, nil)
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Seeds represents a database seed data root command.
+type Seeds map[string]types.Resource
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Seeds)
+
+// NewCommand returns a new cobra.Command object.
+func (r Seeds) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:] + " COMMAND",
+		Short: "Manages environment specific database seed data.",
+		Long:  "Manages environment specific database seed data",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+	for key, resource := range r {
+		cmd.AddCommand(resource.NewCommand(ctx, name+"."+key))
+	}
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Seeds) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Seeds) validation(cmd *cobra.Command, args []string) error {
+	if err := require.ExactArgs(args, 1); err != nil {
+		return err
+	}
+	return nil
+}
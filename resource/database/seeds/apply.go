@@ -0,0 +1,171 @@
+package seeds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/store/model"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Apply represents the database seeds apply command object.
+type Apply struct {
+	Driver interface {
+		driver.WithSeeds
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// applyOptions is used for executing the run() command.
+type applyOptions struct {
+	Try bool `json:"try" yaml:"try"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Apply)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Apply) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Applies new or changed seeds of the environment.",
+		Long:  "Applies new or changed seeds of the environment",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			try, _ := cmd.Flags().GetBool("try")
+			opts := applyOptions{Try: try}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool(
+		"try", false,
+		"Simulates and prints resource execution parameters.",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Apply) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Apply) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the apply command.
+func (r Apply) run(ctx context.Context, out io.Writer, opts applyOptions) error {
+	source := bytes.NewBuffer(nil)
+	if err := r.Driver.Source(ctx, source); err != nil {
+		return err
+	}
+
+	uri, err := url.Parse(source.String())
+	if err != nil {
+		return err
+	}
+
+	db, err := store.Open(uri.Scheme, source.String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Seeds.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+
+	records, err := db.Seeds.GetSeeds()
+	if err != nil {
+		return err
+	}
+
+	env := environment(ctx)
+	for _, seed := range filterSeeds(r.Driver.Seeds(), env) {
+		checksum := seed.Checksum()
+		record, applied := records[seed.Name]
+		if applied && record.Checksum == checksum {
+			continue
+		}
+
+		if opts.Try {
+			fmt.Fprintf(out, "==> seed %q (apply)\n", seed.Name)
+			for _, op := range seed.Apply {
+				op.Print(out, db.Dialect())
+			}
+			continue
+		}
+
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		if err := seed.Execute(ctx, db, out, "apply"); err != nil {
+			return err
+		}
+
+		record = model.Seed{
+			Name:      seed.Name,
+			Checksum:  checksum,
+			Timestamp: time.Now(),
+		}
+		if applied {
+			err = db.Seeds.Update(&record)
+		} else {
+			err = db.Seeds.Insert(&record)
+		}
+		if err != nil {
+			return fmt.Errorf("failed recording seed %q (apply)", seed.Name)
+		}
+
+		fmt.Fprintf(out, "seed %q successfully applied (%s)\n", seed.Name, env)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package seeds
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+func (r *SeedsSuite) TestApplyCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Apply{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"--try"},
+			"==> seed \"billing-plans\" (apply)\n" +
+				"INSERT INTO unittests(value) VALUES ('free');\n" +
+				"==> seed \"demo-users\" (apply)\n" +
+				"INSERT INTO unittests(value) VALUES ('hello'), ('world');\n",
+		},
+		{
+			false, "",
+			Apply{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"seed \"billing-plans\" successfully applied (development)\n" +
+				"seed \"demo-users\" successfully applied (development)\n",
+		},
+		{
+			false, "",
+			Apply{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("apply", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func (r *SeedsSuite) TestReapplyCommand() {
+	seed := (*r.Driver.Seeds())[1]
+	original := seed.Apply
+	defer func() { seed.Apply = original }()
+
+	seed.Apply = []types.Operation{
+		{Query: `UPDATE unittests SET value = 'free' WHERE value = 'free'`},
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), Apply{Driver: r.Driver}.Execute("apply", buffer, []string{}))
+	assert.Equal(r.T(), "seed \"billing-plans\" successfully applied (development)\n", buffer.String())
+}
@@ -0,0 +1,35 @@
+// Package seeds implements the database seeds subcommands.
+package seeds
+
+import (
+	"context"
+	"sort"
+
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// environment returns the name of the environment selected by the root
+// command or the default environment when running outside of one.
+func environment(ctx context.Context) string {
+	if env, ok := ctx.Value(global.RefEnvironment).(string); ok && env != "" {
+		return env
+	}
+	return global.DefaultEnvironment
+}
+
+// filterSeeds returns the sorted list of seeds permitted to run in env.
+func filterSeeds(seeds *types.Seeds, env string) types.Seeds {
+	filtered := make(types.Seeds, 0)
+	if seeds == nil {
+		return filtered
+	}
+
+	for _, seed := range *seeds {
+		if seed.Allows(env) {
+			filtered = append(filtered, seed)
+		}
+	}
+	sort.Sort(filtered)
+	return filtered
+}
@@ -0,0 +1,91 @@
+package seeds
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type SeedsSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithSeeds
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *SeedsSuite) SetupSuite() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE unittests (value text)`)
+	assert.Nil(r.T(), err)
+
+	r.Driver = testutils.Database{
+		Seeds: &types.Seeds{
+			{
+				Name:         "demo-users",
+				Environments: []string{"development"},
+				Apply: []types.Operation{
+					{Query: `INSERT INTO unittests(value) VALUES ('hello'), ('world')`},
+				},
+				Reset: []types.Operation{
+					{Query: `DELETE FROM unittests WHERE value in ('hello', 'world')`},
+				},
+			},
+			{
+				Name: "billing-plans",
+				Apply: []types.Operation{
+					{Query: `INSERT INTO unittests(value) VALUES ('free')`},
+				},
+				Reset: []types.Operation{
+					{Query: `DELETE FROM unittests WHERE value = 'free'`},
+				},
+			},
+			{
+				Name:         "production-only",
+				Environments: []string{"production"},
+				Apply: []types.Operation{
+					{Query: `INSERT INTO unittests(value) VALUES ('secret')`},
+				},
+			},
+		},
+		Driver: &generic.SQL{
+			Dialect:    "sqlite3",
+			DataSource: "sqlite3://" + r.File,
+		},
+	}.Build()
+
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), r.Driver.Source(context.Background(), buffer))
+}
+
+func (r *SeedsSuite) TearDownSuite() {
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func TestSeedsSuite(t *testing.T) {
+	suite.Run(t, new(SeedsSuite))
+}
@@ -0,0 +1,161 @@
+package seeds
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/store/model"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Reset represents the database seeds reset command object.
+type Reset struct {
+	Driver interface {
+		driver.WithSeeds
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// resetOptions is used for executing the run() command.
+type resetOptions struct {
+	Try bool `json:"try" yaml:"try"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Reset)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Reset) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Removes previously applied seeds of the environment.",
+		Long:  "Removes previously applied seeds of the environment",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			try, _ := cmd.Flags().GetBool("try")
+			opts := resetOptions{Try: try}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool(
+		"try", false,
+		"Simulates and prints resource execution parameters.",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Reset) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Reset) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the reset command.
+func (r Reset) run(ctx context.Context, out io.Writer, opts resetOptions) error {
+	source := bytes.NewBuffer(nil)
+	if err := r.Driver.Source(ctx, source); err != nil {
+		return err
+	}
+
+	uri, err := url.Parse(source.String())
+	if err != nil {
+		return err
+	}
+
+	db, err := store.Open(uri.Scheme, source.String())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Seeds.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+
+	records, err := db.Seeds.GetSeeds()
+	if err != nil {
+		return err
+	}
+
+	// seeds are reset in reverse order of application.
+	env := environment(ctx)
+	seeds := filterSeeds(r.Driver.Seeds(), env)
+	for i := len(seeds) - 1; i >= 0; i-- {
+		seed := seeds[i]
+		if _, applied := records[seed.Name]; !applied {
+			continue
+		}
+
+		if opts.Try {
+			fmt.Fprintf(out, "==> seed %q (reset)\n", seed.Name)
+			for _, op := range seed.Reset {
+				op.Print(out, db.Dialect())
+			}
+			continue
+		}
+
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		if err := seed.Execute(ctx, db, out, "reset"); err != nil {
+			return err
+		}
+
+		if err := db.Seeds.Delete(&model.Seed{Name: seed.Name}); err != nil {
+			return fmt.Errorf("failed deleting previously applied seed %q (reset)", seed.Name)
+		}
+
+		fmt.Fprintf(out, "seed %q successfully removed (%s)\n", seed.Name, env)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package seeds
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+func (r *SeedsSuite) TestResetCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Reset{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"--try"},
+			"==> seed \"demo-users\" (reset)\n" +
+				"DELETE FROM unittests WHERE value in ('hello', 'world');\n" +
+				"==> seed \"billing-plans\" (reset)\n" +
+				"DELETE FROM unittests WHERE value = 'free';\n",
+		},
+		{
+			false, "",
+			Reset{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"seed \"demo-users\" successfully removed (development)\n" +
+				"seed \"billing-plans\" successfully removed (development)\n",
+		},
+		{
+			false, "",
+			Reset{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("reset", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/resource/database/seeds"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type SeedSuite struct {
+	suite.Suite
+}
+
+func (r *SeedSuite) TestSeedsCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			true,
+			"accepts 1 arg(s), received 0 for \"seeds\"\n" +
+				"\n" +
+				"Usage:\n" +
+				"  seeds COMMAND [flags]\n" +
+				"\n" +
+				"Available Commands:\n" +
+				"  apply       Applies new or changed seeds of the environment.\n" +
+				"  reset       Removes previously applied seeds of the environment.\n" +
+				"\n" +
+				"Flags:\n" +
+				"      --help   Show help information.\n",
+			Seeds{
+				"apply": seeds.Apply{},
+				"reset": seeds.Reset{},
+			},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"",
+		},
+		{
+			false, "",
+			Seeds{
+				"apply": seeds.Apply{},
+				"reset": seeds.Reset{},
+			},
+			bytes.NewBuffer(nil),
+			[]string{"--help"},
+			"Manages environment specific database seed data\n" +
+				"\n" +
+				"Usage:\n" +
+				"  seeds COMMAND [flags]\n" +
+				"\n" +
+				"Available Commands:\n" +
+				"  apply       Applies new or changed seeds of the environment.\n" +
+				"  reset       Removes previously applied seeds of the environment.\n" +
+				"\n" +
+				"Flags:\n" +
+				"      --help   Show help information.\n",
+		},
+	}
+
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		runner := func() {
+			err := testCase.cmd.Execute("seeds", testCase.buffer, testCase.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if testCase.output != testCase.buffer.String() {
+				fmt.Printf("%q\n", testCase.buffer.String())
+				panic(testCase.buffer.String())
+			}
+		}
+
+		if testCase.shouldFail {
+			assert.PanicsWithValue(r.T(), testCase.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestSeedSuite(t *testing.T) {
+	suite.Run(t, new(SeedSuite))
+}
@@ -0,0 +1,72 @@
+package snapshots
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/driver/generic"
+)
+
+type SnapshotsSuite struct {
+	suite.Suite
+	File   string
+	Driver interface {
+		driver.WithSnapshots
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *SnapshotsSuite) SetupSuite() {
+	file, err := ioutil.TempFile(os.TempDir(), "migrate-*.db")
+	if err != nil {
+		panic(err)
+	}
+	r.File = file.Name()
+	assert.Nil(r.T(), file.Close())
+
+	r.exec(`CREATE TABLE unittests (value text)`)
+	r.Driver = generic.SQL{
+		Dialect:    "sqlite3",
+		DataSource: "sqlite3://" + r.File,
+	}
+}
+
+func (r *SnapshotsSuite) TearDownSuite() {
+	assert.Nil(r.T(), os.RemoveAll(r.File+".snapshots"))
+	assert.Nil(r.T(), os.Remove(r.File))
+}
+
+func (r *SnapshotsSuite) exec(query string) {
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(query); err != nil {
+		panic(err)
+	}
+}
+
+func (r *SnapshotsSuite) count() int {
+	db, err := sql.Open("sqlite3", r.File)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM unittests`).Scan(&count); err != nil {
+		panic(err)
+	}
+	return count
+}
+
+func TestSnapshotsSuite(t *testing.T) {
+	suite.Run(t, new(SnapshotsSuite))
+}
@@ -0,0 +1,93 @@
+package snapshots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Delete represents the database snapshots delete command object.
+type Delete struct {
+	Driver interface {
+		driver.WithSnapshots
+	} `json:"driver" yaml:"driver"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Delete)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Delete) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:] + " NAME",
+		Short: "Deletes a database snapshot.",
+		Long:  "Deletes a database snapshot",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			return r.run(ctx, cmd.OutOrStdout(), args[0])
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Delete) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Delete) validation(cmd *cobra.Command, args []string) error {
+	if err := require.ExactArgs(args, 1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the delete command.
+func (r Delete) run(ctx context.Context, out io.Writer, name string) error {
+	if err := r.Driver.DeleteSnapshot(ctx, out, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "snapshot %q successfully deleted\n", name)
+	return nil
+}
@@ -0,0 +1,61 @@
+package snapshots
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+func (r *SnapshotsSuite) TestDeleteCommand() {
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), r.Driver.Snapshot(context.Background(), buffer, "deleted"))
+
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			false, "",
+			Delete{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"deleted"},
+			"snapshot \"deleted\" successfully deleted\n",
+		},
+		{
+			true, "snapshot \"deleted\" not found",
+			Delete{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"deleted"},
+			"",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("delete", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
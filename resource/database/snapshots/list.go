@@ -0,0 +1,105 @@
+// Package snapshots implements the database snapshots subcommands.
+package snapshots
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// List represents the database snapshots list command object.
+type List struct {
+	Driver interface {
+		driver.WithSnapshots
+	} `json:"driver" yaml:"driver"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(List)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r List) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Prints available database snapshots.",
+		Long:  "Prints available database snapshots",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			return r.run(ctx, cmd.OutOrStdout())
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r List) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r List) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the list command.
+func (r List) run(ctx context.Context, out io.Writer) error {
+	snapshots, err := r.Driver.Snapshots(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Created"})
+	table.SetColWidth(60)
+	for _, snapshot := range snapshots {
+		table.Append([]string{snapshot.Name, snapshot.Timestamp.Format(time.RFC3339)})
+	}
+
+	if len(snapshots) > 0 {
+		table.Render()
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package snapshots
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (r *SnapshotsSuite) TestListCommand() {
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), List{Driver: r.Driver}.Execute("list", buffer, []string{}))
+	assert.Equal(r.T(), "", buffer.String())
+
+	assert.Nil(r.T(), r.Driver.Snapshot(context.Background(), buffer, "listed"))
+	defer r.Driver.DeleteSnapshot(context.Background(), buffer, "listed")
+
+	buffer.Reset()
+	assert.Nil(r.T(), List{Driver: r.Driver}.Execute("list", buffer, []string{}))
+	assert.True(r.T(), strings.Contains(buffer.String(), "| listed "), buffer.String())
+}
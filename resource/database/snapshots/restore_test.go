@@ -0,0 +1,64 @@
+package snapshots
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+func (r *SnapshotsSuite) TestRestoreCommand() {
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), r.Driver.Snapshot(context.Background(), buffer, "restored"))
+	defer r.Driver.DeleteSnapshot(context.Background(), buffer, "restored")
+	r.exec(`INSERT INTO unittests(value) VALUES ('hello')`)
+
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			true, "snapshot \"not-found\" not found",
+			Restore{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"not-found"},
+			"",
+		},
+		{
+			false, "",
+			Restore{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"restored"},
+			"snapshot \"restored\" successfully restored\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			err := tc.cmd.Execute("restore", tc.buffer, tc.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if tc.output != tc.buffer.String() {
+				panic(tc.buffer.String())
+			}
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+	assert.Equal(r.T(), 0, r.count())
+}
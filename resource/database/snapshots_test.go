@@ -0,0 +1,99 @@
+package database
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/trivigy/migrate/v2/resource/database/snapshots"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type SnapshotSuite struct {
+	suite.Suite
+}
+
+func (r *SnapshotSuite) TestSnapshotsCommand() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		cmd        types.Command
+		buffer     *bytes.Buffer
+		args       []string
+		output     string
+	}{
+		{
+			true,
+			"accepts 1 arg(s), received 0 for \"snapshot\"\n" +
+				"\n" +
+				"Usage:\n" +
+				"  snapshot COMMAND [flags]\n" +
+				"\n" +
+				"Available Commands:\n" +
+				"  delete      Deletes a database snapshot.\n" +
+				"  list        Prints available database snapshots.\n" +
+				"  restore     Restores the database from a snapshot.\n" +
+				"\n" +
+				"Flags:\n" +
+				"      --help   Show help information.\n",
+			Snapshots{
+				"list":    snapshots.List{},
+				"restore": snapshots.Restore{},
+				"delete":  snapshots.Delete{},
+			},
+			bytes.NewBuffer(nil),
+			[]string{},
+			"",
+		},
+		{
+			false, "",
+			Snapshots{
+				"list":    snapshots.List{},
+				"restore": snapshots.Restore{},
+				"delete":  snapshots.Delete{},
+			},
+			bytes.NewBuffer(nil),
+			[]string{"--help"},
+			"Manages point-in-time copies of the database\n" +
+				"\n" +
+				"Usage:\n" +
+				"  snapshot COMMAND [flags]\n" +
+				"\n" +
+				"Available Commands:\n" +
+				"  delete      Deletes a database snapshot.\n" +
+				"  list        Prints available database snapshots.\n" +
+				"  restore     Restores the database from a snapshot.\n" +
+				"\n" +
+				"Flags:\n" +
+				"      --help   Show help information.\n",
+		},
+	}
+
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		runner := func() {
+			err := testCase.cmd.Execute("snapshot", testCase.buffer, testCase.args)
+			if err != nil {
+				panic(err.Error())
+			}
+
+			if testCase.output != testCase.buffer.String() {
+				fmt.Printf("%q\n", testCase.buffer.String())
+				panic(testCase.buffer.String())
+			}
+		}
+
+		if testCase.shouldFail {
+			assert.PanicsWithValue(r.T(), testCase.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestSnapshotSuite(t *testing.T) {
+	suite.Run(t, new(SnapshotSuite))
+}
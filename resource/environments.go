@@ -5,13 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
@@ -40,7 +44,9 @@ func (r Environments) NewCommand(ctx context.Context, name string) *cobra.Comman
 			out := cmd.OutOrStdout()
 			args = append(args, tail...)
 			env, _ := cmd.Flags().GetString("env")
-			return r.run(ctx, out, env, name, args)
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			output, _ := cmd.Flags().GetString("output")
+			return r.run(ctx, out, env, timeout, output, name, args)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -55,6 +61,14 @@ func (r Environments) NewCommand(ctx context.Context, name string) *cobra.Comman
 		"env", "e", global.DefaultEnvironment,
 		"Run with env `ENV` configurations.",
 	)
+	flags.Duration(
+		"timeout", 0,
+		"Abort the command once `DURATION` elapses.",
+	)
+	flags.StringP(
+		"output", "o", events.OutputText,
+		"Report progress in `FORMAT` (text or jsonl).",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -72,6 +86,9 @@ func (r Environments) Execute(name string, out io.Writer, args []string) error {
 	flags := flag.NewFlagSet("", flag.ContinueOnError)
 	flags.String("env", global.DefaultEnvironment, "")
 	flags.String("e", global.DefaultEnvironment, "")
+	flags.Duration("timeout", 0, "")
+	flags.String("output", events.OutputText, "")
+	flags.String("o", events.OutputText, "")
 	flags.Bool("help", false, "")
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -103,10 +120,37 @@ func (r Environments) validation(cmd *cobra.Command, args []string) error {
 		r.helpFunc(nil)(cmd, args)
 		return err
 	}
+
+	switch output, _ := cmd.Flags().GetString("output"); output {
+	case events.OutputText, events.OutputJSONL:
+	default:
+		return fmt.Errorf("invalid output %q", output)
+	}
 	return nil
 }
 
-func (r Environments) run(ctx context.Context, out io.Writer, env, name string, args []string) error {
+func (r Environments) run(
+	ctx context.Context,
+	out io.Writer,
+	env string,
+	timeout time.Duration,
+	output string,
+	name string,
+	args []string,
+) error {
+	ctx = context.WithValue(ctx, global.RefEnvironment, env)
+	ctx = context.WithValue(ctx, global.RefOutput, output)
+
+	// the environment is the root of every resource therefore signals and
+	// timeouts are handled here for all of the databases, docker containers
+	// and kubernetes clusters alike.
+	ctx, cancel := interrupt.Context(ctx, out)
+	defer cancel()
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	cmd := r[env].NewCommand(ctx, name)
 	cmd.SetOut(out)
 	cmd.SetArgs(args)
@@ -103,8 +103,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  developmentKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 			Command,
 			bytes.NewBuffer(nil),
 			[]string{},
@@ -124,8 +126,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  developmentKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 		},
 		{
 			true,
@@ -140,8 +144,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  stagingKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 			Command,
 			bytes.NewBuffer(nil),
 			[]string{"-e", "staging"},
@@ -161,8 +167,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  stagingKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 		},
 		{
 			false, "",
@@ -178,8 +186,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  stagingKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 		},
 		{
 			false, "",
@@ -195,8 +205,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  developmentKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 		},
 		{
 			false, "",
@@ -212,8 +224,10 @@ func (r *EnvironmentsSuite) TestEnvironmentsCommand() {
 				"  developmentKubernetes Kubernetes cluster release and deployment controller.\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -e, --env ENV   Run with env ENV configurations. (default \"development\")\n" +
-				"      --help      Show help information.\n",
+				"  -e, --env ENV            Run with env ENV configurations. (default \"development\")\n" +
+				"      --timeout DURATION   Abort the command once DURATION elapses.\n" +
+				"  -o, --output FORMAT      Report progress in FORMAT (text or jsonl). (default \"text\")\n" +
+				"      --help               Show help information.\n",
 		},
 		{
 			false, "",
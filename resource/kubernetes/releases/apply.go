@@ -0,0 +1,392 @@
+package releases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/spf13/cobra"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// FieldManager defines the name of the field manager owning the fields of
+// the applied manifest objects.
+const FieldManager = "migrate"
+
+// Apply represents the cluster release apply command object.
+type Apply struct {
+	Driver interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// applyOptions is used for executing the run() command.
+type applyOptions struct {
+	Name           string         `json:"name" yaml:"name"`
+	Version        semver.Version `json:"version" yaml:"version"`
+	Resource       string         `json:"resource" yaml:"resource"`
+	ForceConflicts bool           `json:"forceConflicts" yaml:"forceConflicts"`
+	Wait           bool           `json:"wait" yaml:"wait"`
+	WaitTimeout    time.Duration  `json:"waitTimeout" yaml:"waitTimeout"`
+	Prune          bool           `json:"prune" yaml:"prune"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Apply)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Apply) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:] + " [NAME[:VERSION]] [RESOURCE]",
+		Short: "Creates or updates release resources on running cluster.",
+		Long:  "Creates or updates release resources on running cluster",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			files, _ := cmd.Flags().GetStringArray("values")
+			sets, _ := cmd.Flags().GetStringArray("set")
+			releases, err := renderReleases(r.Driver, files, sets)
+			if err != nil {
+				return err
+			}
+
+			var name string
+			var version semver.Version
+			if len(args) > 0 {
+				parts := strings.Split(args[0], ":")
+				parts = append(parts, "")
+				name = parts[0]
+
+				if parts[1] != "" {
+					var err error
+					version, err = semver.Parse(parts[1])
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			var resource string
+			if len(args) > 1 {
+				resource = args[1]
+			}
+
+			forceConflicts, _ := cmd.Flags().GetBool("force-conflicts")
+			wait, _ := cmd.Flags().GetBool("wait")
+			waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+			prune, _ := cmd.Flags().GetBool("prune")
+			opts := applyOptions{
+				Name:           name,
+				Version:        version,
+				Resource:       resource,
+				ForceConflicts: forceConflicts,
+				Wait:           wait,
+				WaitTimeout:    waitTimeout,
+				Prune:          prune,
+			}
+
+			if try, _ := cmd.Flags().GetBool("try"); try {
+				if err := tryRender(cmd.OutOrStdout(), releases, opts); err != nil {
+					return err
+				}
+
+				if opts.Prune {
+					return tryPrune(ctx, cmd.OutOrStdout(), r.Driver, releases, opts)
+				}
+				return nil
+			}
+
+			return r.run(ctx, cmd.OutOrStdout(), releases, opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool(
+		"try", false,
+		"Simulates and prints the rendered release manifests.",
+	)
+	flags.Bool(
+		"force-conflicts", false,
+		"Takes ownership of fields managed by other field managers.",
+	)
+	flags.Bool(
+		"prune", false,
+		"Deletes objects of the release missing from its manifests.",
+	)
+	flags.Bool(
+		"wait", false,
+		"Waits until the release resources are ready.",
+	)
+	flags.Duration(
+		"wait-timeout", 5*time.Minute,
+		"Gives up waiting once `DURATION` elapses.",
+	)
+	flags.StringArray(
+		"values", nil,
+		"Reads release values from a yaml `FILE`.",
+	)
+	flags.StringArray(
+		"set", nil,
+		"Overrides a single release value with `KEY=VALUE`.",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Apply) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Apply) validation(cmd *cobra.Command, args []string) error {
+	if err := require.MaxArgs(args, 2); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the cluster release apply
+// command.
+func (r Apply) run(ctx context.Context, out io.Writer, releases types.Releases, opts applyOptions) error {
+	return applyReleases(ctx, out, r.Driver, releases, opts, events.StatusApplied)
+}
+
+// applyReleases applies the manifests of the selected releases and records a
+// revision for every release which was applied as a whole. When pruning, the
+// objects no longer part of the newest selected version of a release are
+// deleted. When waiting, a release which does not become ready in time is
+// recorded as failed.
+func applyReleases(
+	ctx context.Context,
+	out io.Writer,
+	d interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	},
+	releases types.Releases,
+	opts applyOptions,
+	status string,
+) error {
+	// the namespace is ensured first since the release records and the
+	// lock may be kept in secrets of the namespace.
+	cluster, err := GetCluster(ctx, d, *d.Namespace())
+	if err != nil {
+		return err
+	}
+
+	records, err := openReleases(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	// the lock is held until the revisions are recorded so that concurrent
+	// changes never record revisions which do not match the cluster.
+	unlock, err := lockReleases(ctx, records)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// the overlay moves the namespaced objects into a namespace which may
+	// differ from the driver namespace therefore it is ensured as well.
+	if o, ok := d.(driver.WithOverlay); ok && o.Overlay() != nil && o.Overlay().Namespace != "" {
+		if err := ensureNamespace(ctx, cluster, o.Overlay().Namespace); err != nil {
+			return err
+		}
+	}
+
+	sort.Sort(releases)
+	latest := latestReleases(releases, opts)
+	for _, rel := range releases {
+		if opts.Name != "" && rel.Name != opts.Name ||
+			(!opts.Version.EQ(semver.Version{}) &&
+				!rel.Version.Equals(opts.Version)) {
+			continue
+		}
+
+		err := applyRelease(ctx, out, cluster, rel, opts, status)
+		if err == nil && opts.Prune && latest[rel.Name] == rel {
+			err = pruneRelease(ctx, out, cluster, d, releases, rel, opts)
+		}
+		if err == nil && opts.Wait {
+			err = waitRelease(ctx, out, cluster, rel, opts)
+		}
+
+		// a revision is recorded only when the whole release was processed
+		// rather than a single resource of it.
+		if opts.Resource == "" {
+			status := state.StatusDeployed
+			if err != nil {
+				status = state.StatusFailed
+			}
+			if rerr := recordRelease(ctx, records, rel, status); rerr != nil {
+				return rerr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRelease applies the manifests of a single release in the order of
+// their kind priorities.
+func applyRelease(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	rel *types.Release,
+	opts applyOptions,
+	status string,
+) (err error) {
+	ctx, end := startRelease(ctx, rel)
+	defer func() { end(err) }()
+
+	manifests, err := sortManifests(rel.Manifests)
+	if err != nil {
+		return err
+	}
+
+	for _, manifest := range manifests {
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		if m, ok := manifest.(runtime.Object); ok && opts.Resource != "" {
+			resource := m.GetObjectKind().GroupVersionKind().Kind
+			if !strings.EqualFold(resource, opts.Resource) {
+				continue
+			}
+		}
+
+		start := time.Now()
+		changed, err := applyManifest(ctx, cluster, rel, manifest, opts.ForceConflicts)
+		if rerr := report(ctx, out, rel, manifest, status, changed, start, err); rerr != nil {
+			return rerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyManifest creates or updates a single manifest object using server-side
+// apply. The object is labelled as managed by the release and reported as
+// changed unless the apply was a no-op.
+func applyManifest(
+	ctx context.Context,
+	cluster *Cluster,
+	rel *types.Release,
+	manifest runtime.Object,
+	force bool,
+) (changed bool, err error) {
+	ctx, end := startManifest(ctx, manifest, cluster.Namespace)
+	defer func() { end(err) }()
+
+	resource, obj, err := cluster.Resource(manifest)
+	if err != nil {
+		return false, err
+	}
+	if err := labelObject(obj, rel); err != nil {
+		return false, err
+	}
+
+	var resourceVersion string
+	instance, err := resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+	if err == nil {
+		resourceVersion = instance.GetResourceVersion()
+	} else if !v1err.IsNotFound(err) {
+		return false, err
+	}
+
+	rbytes, err := json.Marshal(obj)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := resource.Patch(ctx, obj.GetName(), k8stypes.ApplyPatchType, rbytes, v1meta.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return false, conflictError(obj, err)
+	}
+	return result.GetResourceVersion() != resourceVersion, nil
+}
+
+// conflictError describes the fields of the object which are owned by other
+// field managers. Any other error is returned unchanged.
+func conflictError(obj *unstructured.Unstructured, err error) error {
+	status, ok := err.(v1err.APIStatus)
+	if !ok || !v1err.IsConflict(err) {
+		return err
+	}
+
+	conflicts := make([]string, 0)
+	if details := status.Status().Details; details != nil {
+		for _, cause := range details.Causes {
+			if cause.Type == v1meta.CauseTypeFieldManagerConflict {
+				conflicts = append(conflicts, fmt.Sprintf("%s (%s)", cause.Field, cause.Message))
+			}
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return err
+	}
+
+	return fmt.Errorf(
+		"%s %q has fields owned by other managers: %s (use --force-conflicts to take ownership)",
+		obj.GetKind(), obj.GetName(), strings.Join(conflicts, ", "),
+	)
+}
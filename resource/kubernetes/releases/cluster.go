@@ -0,0 +1,162 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/trivigy/migrate/v2/driver"
+)
+
+// Cluster represents a connection to kubernetes capable of operating on
+// manifest objects of any kind, including custom resources. Kinds are
+// resolved onto api resources through the rest mapper.
+type Cluster struct {
+	Client    dynamic.Interface
+	Discovery discovery.DiscoveryInterface
+	Mapper    meta.RESTMapper
+	Namespace string
+}
+
+// GetCluster defines a function which generates a new dynamic connection to
+// kubernetes and ensures that the namespace exists.
+func GetCluster(ctx context.Context, driver driver.WithSource, namespace string) (*Cluster, error) {
+	cluster, err := LookupCluster(ctx, driver, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureNamespace(ctx, cluster, cluster.Namespace); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// ensureNamespace creates the namespace with the specified name unless it
+// already exists.
+func ensureNamespace(ctx context.Context, cluster *Cluster, name string) error {
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(name)
+
+	resource, obj, err := cluster.Resource(ns)
+	if err != nil {
+		return err
+	}
+
+	_, err = resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+	if v1err.IsNotFound(err) {
+		if _, err := resource.Create(ctx, obj, v1meta.CreateOptions{}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LookupCluster defines a function which generates a new dynamic connection
+// to kubernetes without changing the cluster in any way. It is used by the
+// read-only commands for which a missing namespace simply holds no objects.
+func LookupCluster(ctx context.Context, driver driver.WithSource, namespace string) (*Cluster, error) {
+	output := bytes.NewBuffer(nil)
+	if err := driver.Source(ctx, output); err != nil {
+		return nil, err
+	}
+
+	kubeConfig, err := clientcmd.RESTConfigFromKubeConfig(output.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := memory.NewMemCacheClient(discoveryClient)
+	return &Cluster{
+		Client:    client,
+		Discovery: cached,
+		Mapper:    restmapper.NewDeferredDiscoveryRESTMapper(cached),
+		Namespace: FallBackNS(namespace, ""),
+	}, nil
+}
+
+// Resource converts the manifest object into its unstructured form and
+// returns it together with the client of the api resource it belongs to.
+// Namespaced objects without a namespace fall back onto the cluster namespace.
+func (r Cluster) Resource(manifest runtime.Object) (dynamic.ResourceInterface, *unstructured.Unstructured, error) {
+	obj, err := toUnstructured(manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gvk := obj.GroupVersionKind()
+	mapping, err := r.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if mapper, ok := r.Mapper.(interface{ Reset() }); ok && meta.IsNoMatchError(err) {
+		// the discovery cache never expires by itself therefore kinds of
+		// custom resource definitions applied after it was populated, e.g.
+		// earlier in the same release, are found only once it is reset.
+		mapper.Reset()
+		mapping, err = r.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		obj.SetNamespace("")
+		return r.Client.Resource(mapping.Resource), obj, nil
+	}
+
+	namespace := FallBackNS(obj.GetNamespace(), r.Namespace)
+	obj.SetNamespace(namespace)
+	return r.Client.Resource(mapping.Resource).Namespace(namespace), obj, nil
+}
+
+// toUnstructured converts the manifest object into its unstructured form.
+// Typed objects without type meta are resolved through the client scheme.
+// Fields which are populated by the server, such as the status, are dropped.
+func toUnstructured(manifest runtime.Object) (*unstructured.Unstructured, error) {
+	if obj, ok := manifest.(*unstructured.Unstructured); ok {
+		obj = obj.DeepCopy()
+		delete(obj.Object, "status")
+		return obj, nil
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{Object: object}
+	if obj.GetKind() == "" {
+		gvks, _, err := scheme.Scheme.ObjectKinds(manifest)
+		if err != nil {
+			return nil, err
+		}
+		obj.SetGroupVersionKind(gvks[0])
+	}
+
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	delete(obj.Object, "status")
+	return obj, nil
+}
@@ -0,0 +1,567 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type ClusterSuite struct {
+	suite.Suite
+	Cluster *Cluster
+	Release *types.Release
+	Driver  interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *ClusterSuite) SetupTest() {
+	r.Cluster = newFakeCluster()
+
+	widget := &unstructured.Unstructured{}
+	widget.SetAPIVersion("example.com/v1")
+	widget.SetKind("Widget")
+	widget.SetName("unittest")
+	widget.SetNamespace("other")
+	widget.Object["spec"] = map[string]interface{}{"size": int64(1)}
+
+	r.Release = &types.Release{
+		Name:    "unittest",
+		Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+		Manifests: []runtime.Object{
+			&v1core.Namespace{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "other"},
+			},
+			&v1core.Service{
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+				Spec: v1core.ServiceSpec{
+					Ports: []v1core.ServicePort{{Port: 80}},
+				},
+			},
+			&v1core.PersistentVolumeClaim{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+			},
+			&v1apps.Deployment{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+				Spec:       v1apps.DeploymentSpec{Replicas: &[]int32{1}[0]},
+			},
+			widget,
+		},
+	}
+
+	r.Driver = testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases:  &types.Releases{r.Release},
+	}.Build()
+}
+
+func (r *ClusterSuite) get(manifest runtime.Object) (*unstructured.Unstructured, error) {
+	resource, obj, err := r.Cluster.Resource(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Get(context.Background(), obj.GetName(), v1meta.GetOptions{})
+}
+
+func (r *ClusterSuite) TestResource() {
+	testCases := []struct {
+		manifest  runtime.Object
+		namespace string
+		kind      string
+	}{
+		{r.Release.Manifests[0], "", "Namespace"},
+		{r.Release.Manifests[1], "unittest", "Service"},
+		{r.Release.Manifests[2], "unittest", "PersistentVolumeClaim"},
+		{r.Release.Manifests[3], "unittest", "Deployment"},
+		{r.Release.Manifests[4], "other", "Widget"},
+	}
+
+	for i, testCase := range testCases {
+		_, obj, err := r.Cluster.Resource(testCase.manifest)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.namespace, obj.GetNamespace(), "testCase: %d", i)
+		assert.Equal(r.T(), testCase.kind, obj.GetKind(), "testCase: %d", i)
+		assert.NotContains(r.T(), obj.Object, "status", "testCase: %d", i)
+	}
+
+	_, _, err := r.Cluster.Resource(&v1apps.StatefulSet{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+	})
+	assert.True(r.T(), meta.IsNoMatchError(err))
+}
+
+func (r *ClusterSuite) TestResourceDiscovered() {
+	fake := &k8stesting.Fake{Resources: []*v1meta.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []v1meta.APIResource{
+				{Name: "services", Namespaced: true, Kind: "Service"},
+			},
+		},
+	}}
+	cached := memory.NewMemCacheClient(&discoveryfake.FakeDiscovery{Fake: fake})
+	cluster := &Cluster{
+		Client:    newFakeClient(),
+		Discovery: cached,
+		Mapper:    restmapper.NewDeferredDiscoveryRESTMapper(cached),
+		Namespace: "unittest",
+	}
+
+	_, _, err := cluster.Resource(r.Release.Manifests[1])
+	assert.Nil(r.T(), err)
+
+	fake.Resources = append(fake.Resources, &v1meta.APIResourceList{
+		GroupVersion: "example.com/v1",
+		APIResources: []v1meta.APIResource{
+			{Name: "widgets", Namespaced: true, Kind: "Widget"},
+		},
+	})
+
+	_, obj, err := cluster.Resource(r.Release.Manifests[4])
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "Widget", obj.GetKind())
+
+	_, _, err = cluster.Resource(&v1apps.StatefulSet{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+	})
+	assert.True(r.T(), meta.IsNoMatchError(err))
+}
+
+func (r *ClusterSuite) TestLifecycle() {
+	ctx := context.Background()
+	buffer := bytes.NewBuffer(nil)
+
+	opts := applyOptions{}
+	assert.Nil(r.T(), applyRelease(ctx, buffer, r.Cluster, r.Release, opts, events.StatusInstalled))
+	for _, manifest := range r.Release.Manifests {
+		_, err := r.get(manifest)
+		assert.Nil(r.T(), err)
+	}
+
+	changed, err := applyManifest(ctx, r.Cluster, r.Release, r.Release.Manifests[4], false)
+	assert.Nil(r.T(), err)
+	assert.False(r.T(), changed)
+
+	service := r.Release.Manifests[1].DeepCopyObject().(*v1core.Service)
+	service.Spec.Ports[0].Port = 8080
+	changed, err = applyManifest(ctx, r.Cluster, r.Release, service, false)
+	assert.Nil(r.T(), err)
+	assert.True(r.T(), changed)
+
+	result, err := r.get(service)
+	assert.Nil(r.T(), err)
+	ports, _, _ := unstructured.NestedSlice(result.Object, "spec", "ports")
+	assert.Equal(r.T(), int64(8080), ports[0].(map[string]interface{})["port"])
+
+	uninstall := Uninstall{Driver: r.Driver}
+	assert.Nil(r.T(), uninstall.uninstallRelease(ctx, buffer, r.Cluster, r.Release, uninstallOptions{}))
+	for _, manifest := range r.Release.Manifests {
+		_, err := r.get(manifest)
+		assert.True(r.T(), v1err.IsNotFound(err))
+	}
+
+	changed, err = uninstall.uninstallManifest(ctx, r.Cluster, service)
+	assert.Nil(r.T(), err)
+	assert.False(r.T(), changed)
+}
+
+func (r *ClusterSuite) TestConflicts() {
+	ctx := context.Background()
+	service := r.Release.Manifests[1].DeepCopyObject().(*v1core.Service)
+	service.Annotations = map[string]string{fakeConflictAnnotation: "kubectl"}
+	changed, err := applyManifest(ctx, r.Cluster, r.Release, service, false)
+	assert.Nil(r.T(), err)
+	assert.True(r.T(), changed)
+
+	_, err = applyManifest(ctx, r.Cluster, r.Release, r.Release.Manifests[1], false)
+	assert.EqualError(r.T(), err, `Service "unittest" has fields owned by other managers: `+
+		`.spec (conflict with "kubectl") (use --force-conflicts to take ownership)`)
+
+	err = conflictError(&unstructured.Unstructured{}, v1err.NewConflict(schema.GroupResource{}, "unittest", nil))
+	assert.True(r.T(), v1err.IsConflict(err))
+}
+
+func (r *ClusterSuite) TestPrinters() {
+	ctx := context.Background()
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Release, applyOptions{}, events.StatusInstalled))
+
+	testCases := []struct {
+		manifest runtime.Object
+		header   []string
+		row      []string
+	}{
+		{
+			r.Release.Manifests[1],
+			[]string{"NAMESPACE", "NAME", "TYPE", "CLUSTER-IP", "EXTERNAL-IP", "PORT(s)", "AGE"},
+			[]string{"unittest", "unittest", "", "", "<none>", "80/"},
+		},
+		{
+			r.Release.Manifests[3],
+			[]string{"NAMESPACE", "NAME", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"},
+			[]string{"unittest", "unittest", "0/0", "0", "0"},
+		},
+		{
+			r.Release.Manifests[4],
+			[]string{"NAMESPACE", "NAME", "AGE"},
+			[]string{"other", "unittest"},
+		},
+		{
+			r.Release.Manifests[0],
+			[]string{"NAME", "STATUS", "AGE"},
+			[]string{"other", ""},
+		},
+	}
+
+	for i, testCase := range testCases {
+		result, err := r.get(testCase.manifest)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		columns := lookupPrinter(result.GroupVersionKind().GroupKind(), result.GetNamespace() != "")
+		assert.Equal(r.T(), testCase.header, columns.Header, "testCase: %d", i)
+
+		rows, err := columns.render(result)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Len(r.T(), rows, 1, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.row, rows[0][:len(rows[0])-1], "testCase: %d", i)
+	}
+}
+
+func (r *ClusterSuite) TestReadOnly() {
+	server, changes := newFakeAPIServer()
+	defer server.Close()
+
+	rel := &types.Release{
+		Name:    "unittest",
+		Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+		Manifests: []runtime.Object{
+			&v1core.ConfigMap{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+			},
+		},
+	}
+	d := testutils.Kubernetes{
+		Namespace: &[]string{"missing"}[0],
+		Releases:  &types.Releases{rel},
+		Driver:    apiServerDriver{URL: server.URL},
+	}.Build()
+
+	testCases := []struct {
+		cmd  types.Command
+		args []string
+		err  string
+	}{
+		{Diff{Driver: d}, []string{}, ErrDifferences.Error()},
+		{Describe{Driver: d}, []string{}, ""},
+		{History{Driver: d}, []string{"unittest"}, `release "unittest" not found`},
+		{Apply{Driver: d}, []string{"--try", "--prune"}, ""},
+	}
+
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		err := testCase.cmd.Execute("command", bytes.NewBuffer(nil), testCase.args)
+		if testCase.err == "" {
+			assert.Nil(r.T(), err, failMsg)
+		} else {
+			assert.EqualError(r.T(), err, testCase.err, failMsg)
+		}
+		assert.Empty(r.T(), *changes, failMsg)
+	}
+
+	_, err := GetCluster(context.Background(), d, "missing")
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), []string{"POST /api/v1/namespaces"}, *changes)
+}
+
+func (r *ClusterSuite) TestLocked() {
+	server, changes := newFakeAPIServer()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	records := state.NewFile(filepath.Join(dir, "state.json"))
+	assert.Nil(r.T(), records.Lock(context.Background(), "releases", "someone"))
+	d := testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases:  &types.Releases{r.Release},
+		State:     records,
+		Driver:    apiServerDriver{URL: server.URL},
+	}.Build()
+
+	testCases := []struct {
+		cmd  types.Command
+		args []string
+	}{
+		{Install{Driver: d}, []string{}},
+		{Update{Driver: d}, []string{}},
+		{Apply{Driver: d}, []string{}},
+		{Uninstall{Driver: d}, []string{}},
+		{Rollback{Driver: d}, []string{"unittest"}},
+	}
+
+	for i, testCase := range testCases {
+		failMsg := fmt.Sprintf("testCase: %d %v", i, testCase)
+		*changes = (*changes)[:0]
+		err := testCase.cmd.Execute("command", bytes.NewBuffer(nil), testCase.args)
+		assert.True(r.T(), errors.Is(err, state.ErrLocked), failMsg)
+		assert.Equal(r.T(), []string{"POST /api/v1/namespaces"}, *changes, failMsg)
+	}
+
+	releases, err := records.Releases(context.Background())
+	assert.Nil(r.T(), err)
+	assert.Empty(r.T(), releases)
+}
+
+func (r *ClusterSuite) TestOverlayNamespace() {
+	server, changes := newFakeAPIServer()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rel := &types.Release{
+		Name:    "unittest",
+		Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+		Manifests: []runtime.Object{
+			&v1core.ConfigMap{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+			},
+		},
+	}
+	d := testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases:  &types.Releases{rel},
+		Overlay:   &types.Overlay{Namespace: "staging"},
+		State:     state.NewFile(filepath.Join(dir, "state.json")),
+		Driver:    apiServerDriver{URL: server.URL},
+	}.Build()
+
+	err = Install{Driver: d}.Execute("install", bytes.NewBuffer(nil), []string{})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), []string{
+		"POST /api/v1/namespaces",
+		"POST /api/v1/namespaces",
+		"PATCH /api/v1/namespaces/staging/configmaps/unittest",
+	}, *changes)
+}
+
+func TestClusterSuite(t *testing.T) {
+	suite.Run(t, new(ClusterSuite))
+}
+
+// newFakeCluster returns a cluster backed by the fake dynamic and discovery
+// clients which know about a handful of kinds including a custom resource.
+func newFakeCluster() *Cluster {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Service"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeNamespace)
+
+	verbs := v1meta.Verbs{"get", "list", "create", "update", "patch", "delete"}
+	discovery := &discoveryfake.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*v1meta.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []v1meta.APIResource{
+				{Name: "namespaces", Kind: "Namespace", Verbs: verbs},
+				{Name: "services", Namespaced: true, Kind: "Service", Verbs: verbs},
+				{Name: "services/status", Namespaced: true, Kind: "Service", Verbs: v1meta.Verbs{"get"}},
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: verbs},
+				{Name: "persistentvolumeclaims", Namespaced: true, Kind: "PersistentVolumeClaim", Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []v1meta.APIResource{
+				{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: verbs},
+			},
+		},
+		{
+			GroupVersion: "example.com/v1",
+			APIResources: []v1meta.APIResource{
+				{Name: "widgets", Namespaced: true, Kind: "Widget", Verbs: verbs},
+			},
+		},
+	}}}
+
+	return &Cluster{
+		Client:    newFakeClient(),
+		Discovery: discovery,
+		Mapper:    mapper,
+		Namespace: "unittest",
+	}
+}
+
+// fakeConflictAnnotation marks objects of the fake client whose fields are
+// owned by another field manager.
+const fakeConflictAnnotation = "unittest/manager"
+
+// newFakeClient returns a fake dynamic client which emulates server-side
+// apply. The resource version is bumped whenever an apply changes an object
+// and applying onto an object marked with the conflict annotation fails.
+func newFakeClient() *dynamicfake.FakeDynamicClient {
+	scheme := runtime.NewScheme()
+	tracker := k8stesting.NewObjectTracker(scheme, serializer.NewCodecFactory(scheme).UniversalDecoder())
+	client := dynamicfake.NewSimpleDynamicClient(scheme)
+	client.ReactionChain = nil
+	client.AddReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != k8stypes.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+
+		gvr := action.GetResource()
+		existing, err := tracker.Get(gvr, action.GetNamespace(), patch.GetName())
+		if v1err.IsNotFound(err) {
+			obj.SetResourceVersion("1")
+			return true, obj, tracker.Create(gvr, obj, action.GetNamespace())
+		} else if err != nil {
+			return true, nil, err
+		}
+
+		instance := existing.(*unstructured.Unstructured)
+		if manager, ok := instance.GetAnnotations()[fakeConflictAnnotation]; ok {
+			return true, nil, v1err.NewApplyConflict([]v1meta.StatusCause{{
+				Type:    v1meta.CauseTypeFieldManagerConflict,
+				Message: fmt.Sprintf("conflict with %q", manager),
+				Field:   ".spec",
+			}}, "Apply failed with 1 conflict")
+		}
+
+		obj.SetResourceVersion(instance.GetResourceVersion())
+		if reflect.DeepEqual(obj.Object, instance.Object) {
+			return true, instance, nil
+		}
+
+		version, _ := strconv.Atoi(instance.GetResourceVersion())
+		obj.SetResourceVersion(strconv.Itoa(version + 1))
+		return true, obj, tracker.Update(gvr, obj, action.GetNamespace())
+	})
+	client.AddReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	return client
+}
+
+// apiServerDriver implements a driver sourcing the kubeconfig of a fake api
+// server.
+type apiServerDriver struct {
+	testutils.Driver
+	URL string
+}
+
+// Source writes the kubeconfig connecting to the fake api server.
+func (r apiServerDriver) Source(ctx context.Context, out io.Writer) error {
+	_, err := fmt.Fprintf(out, `apiVersion: v1
+kind: Config
+clusters:
+- name: unittest
+  cluster:
+    server: %s
+contexts:
+- name: unittest
+  context:
+    cluster: unittest
+    user: unittest
+current-context: unittest
+users:
+- name: unittest
+  user: {}
+`, r.URL)
+	return err
+}
+
+// newFakeAPIServer returns an api server without any objects which serves
+// config maps, secrets and namespaces. Every request which would change the
+// cluster is recorded and answered as if it succeeded.
+func newFakeAPIServer() (*httptest.Server, *[]string) {
+	changes := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if req.Method != http.MethodGet {
+			if _, ok := req.URL.Query()["dryRun"]; !ok {
+				changes = append(changes, req.Method+" "+req.URL.Path)
+			}
+			rbytes, _ := ioutil.ReadAll(req.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(rbytes)
+			return
+		}
+
+		switch path := req.URL.Path; {
+		case path == "/api":
+			fmt.Fprint(w, `{"kind":"APIVersions","versions":["v1"]}`)
+		case path == "/apis":
+			fmt.Fprint(w, `{"kind":"APIGroupList","groups":[]}`)
+		case path == "/api/v1":
+			fmt.Fprint(w, `{"kind":"APIResourceList","groupVersion":"v1","resources":[`+
+				`{"name":"namespaces","kind":"Namespace","namespaced":false,"verbs":["get","list","create"]},`+
+				`{"name":"configmaps","kind":"ConfigMap","namespaced":true,"verbs":["get","list","create"]},`+
+				`{"name":"secrets","kind":"Secret","namespaced":true,"verbs":["get","list","create"]}]}`)
+		case strings.HasSuffix(path, "/configmaps"):
+			fmt.Fprint(w, `{"kind":"ConfigMapList","apiVersion":"v1","items":[]}`)
+		case strings.HasSuffix(path, "/secrets"):
+			fmt.Fprint(w, `{"kind":"SecretList","apiVersion":"v1","items":[]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`)
+		}
+	}))
+	return server, &changes
+}
@@ -4,11 +4,19 @@ package releases
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"reflect"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/tidwall/gjson"
+	"go.opentelemetry.io/otel/attribute"
 	v1core "k8s.io/api/core/v1"
 	v1err "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -16,22 +24,16 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/types"
 )
 
 // GetK8sClientset defines a function which generates a new client connection
-// to kubernetes.
+// to kubernetes and ensures that the namespace exists.
 func GetK8sClientset(ctx context.Context, driver driver.WithSource, namespace string) (*kubernetes.Clientset, error) {
-	output := bytes.NewBuffer(nil)
-	if err := driver.Source(ctx, output); err != nil {
-		return nil, err
-	}
-
-	kubeConfig, err := clientcmd.RESTConfigFromKubeConfig(output.Bytes())
-	if err != nil {
-		return nil, err
-	}
-
-	kubectl, err := kubernetes.NewForConfig(kubeConfig)
+	kubectl, err := LookupK8sClientset(ctx, driver)
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +66,21 @@ func GetK8sClientset(ctx context.Context, driver driver.WithSource, namespace st
 	return kubectl, nil
 }
 
+// LookupK8sClientset defines a function which generates a new client
+// connection to kubernetes without changing the cluster in any way.
+func LookupK8sClientset(ctx context.Context, driver driver.WithSource) (*kubernetes.Clientset, error) {
+	output := bytes.NewBuffer(nil)
+	if err := driver.Source(ctx, output); err != nil {
+		return nil, err
+	}
+
+	kubeConfig, err := clientcmd.RESTConfigFromKubeConfig(output.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(kubeConfig)
+}
+
 // FallBackNS defines a helper function for ensuring namespace fallback.
 func FallBackNS(namespace, fallback string) string {
 	if namespace != "" {
@@ -77,6 +94,172 @@ func FallBackNS(namespace, fallback string) string {
 	return "default"
 }
 
+// startRelease begins the telemetry span of a single release.
+func startRelease(ctx context.Context, rel *types.Release) (context.Context, func(error)) {
+	return telemetry.Start(ctx, "release",
+		telemetry.NameKey.String(rel.Name),
+		telemetry.TagKey.String(rel.Version.String()),
+	)
+}
+
+// startManifest begins the telemetry span of a single manifest object.
+func startManifest(
+	ctx context.Context,
+	manifest runtime.Object,
+	fallback string,
+) (context.Context, func(error)) {
+	kind, name, namespace := describeManifest(manifest, fallback)
+	attrs := []attribute.KeyValue{
+		telemetry.KindKey.String(kind),
+		telemetry.NameKey.String(name),
+	}
+	if namespace != "" {
+		attrs = append(attrs, telemetry.NamespaceKey.String(namespace))
+	}
+	return telemetry.Start(ctx, "manifest", attrs...)
+}
+
+// report writes the outcome of a single manifest object as a jsonl event.
+// Manifest objects are not reported with the text output.
+func report(
+	ctx context.Context,
+	out io.Writer,
+	rel *types.Release,
+	manifest runtime.Object,
+	status string,
+	changed bool,
+	start time.Time,
+	err error,
+) error {
+	if !changed {
+		status = events.StatusSkipped
+	}
+
+	kind, name, namespace := describeManifest(manifest, "")
+	event := events.Event{
+		Type:      events.TypeManifest,
+		Resource:  kind + "/" + name,
+		Release:   rel.Name,
+		Version:   rel.Version.String(),
+		Namespace: namespace,
+		Status:    status,
+		Duration:  time.Since(start),
+	}
+	event.Fail(err)
+	return events.Emit(ctx, out, event, "")
+}
+
+// describeManifest returns the kind, name and namespace of the manifest
+// object. Objects without a namespace fall back onto the fallback namespace
+// unless the object itself is a namespace.
+func describeManifest(manifest runtime.Object, fallback string) (string, string, string) {
+	kind := manifest.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		kind = reflect.Indirect(reflect.ValueOf(manifest)).Type().Name()
+	}
+
+	accessor, err := meta.Accessor(manifest)
+	if err != nil {
+		return kind, "", ""
+	}
+
+	if kind == "Namespace" {
+		return kind, accessor.GetName(), ""
+	}
+	return kind, accessor.GetName(), FallBackNS(accessor.GetNamespace(), fallback)
+}
+
+// openReleases returns the release store selected by the driver. Drivers
+// without a state store of their own keep the release revisions in secrets
+// of the driver namespace. Opening the store leaves the cluster unchanged
+// since the namespace is created by the commands which apply manifests.
+func openReleases(ctx context.Context, d interface {
+	driver.WithNamespace
+	driver.WithSource
+}) (state.ReleaseStore, error) {
+	if d, ok := d.(driver.WithState); ok {
+		return d.State(ctx)
+	}
+
+	namespace := FallBackNS(*d.Namespace(), "")
+	kubectl, err := LookupK8sClientset(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	return state.NewReleaseSecrets(kubectl, namespace), nil
+}
+
+// lockName defines the name of the release store lock held while releases
+// are changed and their revisions recorded.
+const lockName = "releases"
+
+// lockReleases acquires the release store lock. The returned function
+// releases it even when the context was cancelled.
+func lockReleases(ctx context.Context, records state.ReleaseStore) (func(), error) {
+	if err := records.Lock(ctx, lockName, state.Actor()); err != nil {
+		return nil, err
+	}
+	return func() { records.Unlock(context.Background(), lockName) }, nil
+}
+
+// recordRelease appends a new revision of the release with status. Deploying
+// or uninstalling the release supersedes the previously deployed revisions.
+func recordRelease(
+	ctx context.Context,
+	records state.ReleaseStore,
+	rel *types.Release,
+	status string,
+) error {
+	digest, err := manifestDigest(rel)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := records.Releases(ctx)
+	if err != nil {
+		return err
+	}
+
+	revision := 0
+	for _, record := range revisions {
+		if record.Name != rel.Name {
+			continue
+		}
+		if record.Revision > revision {
+			revision = record.Revision
+		}
+
+		if status != state.StatusFailed && record.Status == state.StatusDeployed {
+			record.Status = state.StatusSuperseded
+			if err := records.UpdateRelease(ctx, record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return records.InsertRelease(ctx, state.Release{
+		Name:      rel.Name,
+		Revision:  revision + 1,
+		Version:   rel.Version.String(),
+		Status:    status,
+		Digest:    digest,
+		Timestamp: time.Now(),
+	})
+}
+
+// manifestDigest returns the digest of the release manifests.
+func manifestDigest(rel *types.Release) (string, error) {
+	hash := sha256.New()
+	for _, manifest := range rel.Manifests {
+		rbytes, err := json.Marshal(manifest)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(rbytes)
+	}
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // EmbeddedTable represents an in memory data aggregator for a single kube
 // manifest kind.
 type EmbeddedTable struct {
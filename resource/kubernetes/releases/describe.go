@@ -5,24 +5,18 @@ import (
 	"fmt"
 	"io"
 	"sort"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/blang/semver"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	v1apps "k8s.io/api/apps/v1"
-	v1core "k8s.io/api/core/v1"
-	v1ext "k8s.io/api/extensions/v1beta1"
-	v1policy "k8s.io/api/policy/v1beta1"
-	v1rbac "k8s.io/api/rbac/v1"
 	v1err "k8s.io/apimachinery/pkg/api/errors"
 	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
@@ -56,7 +50,17 @@ func (r Describe) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Describe registered releases with states information.",
 		Long:  "Describe registered releases with states information",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			files, _ := cmd.Flags().GetStringArray("values")
+			sets, _ := cmd.Flags().GetStringArray("set")
+			releases, err := renderReleases(r.Driver, files, sets)
+			if err != nil {
+				return err
+			}
+
 			var name string
 			var version semver.Version
 			if len(args) > 0 {
@@ -89,7 +93,7 @@ func (r Describe) NewCommand(ctx context.Context, name string) *cobra.Command {
 				Resource: resource,
 				Filter:   filter,
 			}
-			return r.run(ctx, cmd.OutOrStdout(), opts)
+			return r.run(ctx, cmd.OutOrStdout(), releases, opts)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -100,6 +104,14 @@ func (r Describe) NewCommand(ctx context.Context, name string) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.SortFlags = false
+	flags.StringArray(
+		"values", nil,
+		"Reads release values from a yaml `FILE`.",
+	)
+	flags.StringArray(
+		"set", nil,
+		"Overrides a single release value with `KEY=VALUE`.",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -126,8 +138,8 @@ func (r Describe) validation(cmd *cobra.Command, args []string) error {
 }
 
 // run is a starting point method for executing the create command.
-func (r Describe) run(ctx context.Context, out io.Writer, opts describeOptions) error {
-	kubectl, err := GetK8sClientset(ctx, r.Driver, *r.Driver.Namespace())
+func (r Describe) run(ctx context.Context, out io.Writer, releases types.Releases, opts describeOptions) error {
+	cluster, err := LookupCluster(ctx, r.Driver, *r.Driver.Namespace())
 	if err != nil {
 		return err
 	}
@@ -137,8 +149,8 @@ func (r Describe) run(ctx context.Context, out io.Writer, opts describeOptions)
 	table.SetHeader([]string{"Name", "Version", "Kind", "Results"})
 	table.SetAutoWrapText(false)
 
-	sort.Sort(*r.Driver.Releases())
-	for _, rel := range *r.Driver.Releases() {
+	sort.Sort(releases)
+	for _, rel := range releases {
 		if opts.Name != "" && rel.Name != opts.Name ||
 			(!opts.Version.EQ(semver.Version{}) &&
 				!rel.Version.Equals(opts.Version)) {
@@ -154,538 +166,45 @@ func (r Describe) run(ctx context.Context, out io.Writer, opts describeOptions)
 				}
 			}
 
-			switch manifest := manifest.(type) {
-			case *v1core.Namespace:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAME", "STATUS", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				result, err := kubectl.CoreV1().
-					Namespaces().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Name,
-					string(result.Status.Phase),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1core.Pod:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "READY", "STATUS", "RESTARTS", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.CoreV1().
-					Pods(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				readyCount := 0
-				restartCount := 0
-				for _, stat := range result.Status.ContainerStatuses {
-					restartCount += int(stat.RestartCount)
-					if stat.Ready {
-						readyCount++
-					}
-				}
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%d/%d", readyCount, len(result.Status.ContainerStatuses)),
-					string(result.Status.Phase),
-					fmt.Sprintf("%d", restartCount),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1core.ServiceAccount:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "SECRETS", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.CoreV1().
-					ServiceAccounts(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%d", len(result.Secrets)),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1core.ConfigMap:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "DATA", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.CoreV1().
-					ConfigMaps(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				var rbytes []byte
-				for _, data := range result.Data {
-					rbytes = append(rbytes, []byte(data)...)
-				}
-				for _, binary := range result.BinaryData {
-					rbytes = append(rbytes, binary...)
-				}
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%dB", len(rbytes)),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1core.Endpoints:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "ENDPOINTS", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.CoreV1().
-					Endpoints(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				endpoints := make([]string, 0)
-				for _, subset := range result.Subsets {
-					for _, address := range subset.Addresses {
-						for _, ports := range subset.Ports {
-							endpoints = append(endpoints, fmt.Sprintf("%s:%d", address.IP, ports.Port))
-						}
-					}
-				}
-				if len(endpoints) > 3 {
-					endpoints = append(endpoints[:3], fmt.Sprintf("+ %d more...", len(endpoints[3:])))
-				}
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					strings.Join(endpoints, ","),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1core.Service:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "TYPE", "CLUSTER-IP", "EXTERNAL-IP", "PORT(s)", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.CoreV1().
-					Services(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				externalIP := "<none>"
-				if len(result.Status.LoadBalancer.Ingress) > 0 {
-					externalIP = result.Status.LoadBalancer.Ingress[0].IP
-				}
-
-				var ports []string
-				for _, port := range result.Spec.Ports {
-					portStr := fmt.Sprintf("%d", port.Port)
-					if port.NodePort != 0 {
-						portStr += fmt.Sprintf(":%d", port.NodePort)
-					}
-					portStr += "/" + string(port.Protocol)
-					ports = append(ports, portStr)
-				}
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					string(result.Spec.Type),
-					result.Spec.ClusterIP,
-					externalIP,
-					strings.Join(ports, ","),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1core.Secret:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "TYPE", "DATA", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.CoreV1().
-					Secrets(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				var rbytes []byte
-				for _, data := range result.StringData {
-					rbytes = append(rbytes, []byte(data)...)
-				}
-				for _, binary := range result.Data {
-					rbytes = append(rbytes, binary...)
-				}
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					string(result.Type),
-					fmt.Sprintf("%dB", len(rbytes)),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1rbac.Role:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.RbacV1().
-					Roles(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1rbac.RoleBinding:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.RbacV1().
-					RoleBindings(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1rbac.ClusterRole:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAME", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				result, err := kubectl.RbacV1().
-					ClusterRoles().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1rbac.ClusterRoleBinding:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAME", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				result, err := kubectl.RbacV1().
-					ClusterRoleBindings().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1policy.PodSecurityPolicy:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAME", "PRIV", "CAPS", "VOLUMES"})
-					tables[manifest.Kind] = tbl
-				}
-
-				result, err := kubectl.PolicyV1beta1().
-					PodSecurityPolicies().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				var caps []string
-				for _, capability := range result.Spec.AllowedCapabilities {
-					caps = append(caps, string(capability))
-				}
-
-				var volumes []string
-				for _, volume := range result.Spec.Volumes {
-					volumes = append(volumes, string(volume))
-				}
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Name,
-					strconv.FormatBool(result.Spec.Privileged),
-					strings.Join(caps, ","),
-					strings.Join(volumes, ","),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1apps.DaemonSet:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "DESIRED", "CURRENT", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.AppsV1().
-					DaemonSets(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%d", result.Status.DesiredNumberScheduled),
-					fmt.Sprintf("%d", result.Status.CurrentNumberScheduled),
-					fmt.Sprintf("%d", result.Status.NumberReady),
-					fmt.Sprintf("%d", result.Status.UpdatedNumberScheduled),
-					fmt.Sprintf("%d", result.Status.NumberAvailable),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1apps.Deployment:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "READY", "STATUS", "RESTARTS", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
-
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.AppsV1().
-					Deployments(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
-
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%d/%d", result.Status.ReadyReplicas, result.Status.Replicas),
-					fmt.Sprintf("%d", result.Status.UpdatedReplicas),
-					fmt.Sprintf("%d", result.Status.AvailableReplicas),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1apps.StatefulSet:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "DESIRED", "CURRENT", "AGE"})
-					tables[manifest.Kind] = tbl
-				}
+			kind, _, _ := describeManifest(manifest, "")
+			resource, obj, err := cluster.Resource(manifest)
+			if err != nil {
+				return err
+			}
 
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.AppsV1().
-					StatefulSets(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
+			namespaced := obj.GetNamespace() != ""
+			columns := lookupPrinter(obj.GroupVersionKind().GroupKind(), namespaced)
+			tbl, ok := tables[kind]
+			if !ok {
+				tbl = NewEmbeddedTable()
+				tbl.Table.SetHeader(append([]string{""}, columns.Header...))
+				tables[kind] = tbl
+			}
 
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				tbl.Table.Append([]string{
-					"✓",
-					result.ObjectMeta.Namespace,
-					result.ObjectMeta.Name,
-					fmt.Sprintf("%d", result.Status.Replicas),
-					fmt.Sprintf("%d", result.Status.ReadyReplicas),
-					fmt.Sprintf("%.2fm", diffTime.Minutes()),
-				})
-			case *v1ext.Ingress:
-				tbl, ok := tables[manifest.Kind]
-				if !ok {
-					tbl = NewEmbeddedTable()
-					tbl.Table.SetHeader([]string{"", "NAMESPACE", "NAME", "HOST", "PATH", "PORT", "AGE"})
-					tables[manifest.Kind] = tbl
+			result, err := resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+			if v1err.IsNotFound(err) {
+				if namespaced {
+					tbl.Table.Append([]string{"✗", obj.GetNamespace(), obj.GetName(), ""})
+				} else {
+					tbl.Table.Append([]string{"✗", obj.GetName(), ""})
 				}
+				continue
+			} else if err != nil {
+				return err
+			}
+			tbl.Results = append(tbl.Results, result)
 
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				result, err := kubectl.ExtensionsV1beta1().
-					Ingresses(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					tbl.Table.Append([]string{"✗", namespace, manifest.Name, ""})
-					break
-				} else if err != nil {
-					return err
-				}
-				tbl.Results = append(tbl.Results, result)
+			rows, err := columns.render(result)
+			if err != nil {
+				return err
+			}
 
-				diffTime := time.Since(result.ObjectMeta.CreationTimestamp.Time)
-				name := result.ObjectMeta.Name
-				age := fmt.Sprintf("%.2fm", diffTime.Minutes())
+			for i, row := range rows {
 				check := "✓"
-				for i := range result.Spec.Rules {
-					if i != 0 {
-						check = ""
-						namespace = ""
-						name = ""
-						age = ""
-					}
-					host := result.Spec.Rules[i].Host
-					for j := range result.Spec.Rules[i].HTTP.Paths {
-						if j != 0 {
-							host = ""
-						}
-						path := result.Spec.Rules[i].HTTP.Paths[j].Path
-						if path == "" {
-							path = "/"
-						}
-						port := result.Spec.Rules[i].HTTP.Paths[j].Backend.ServicePort.String()
-						tbl.Table.Append([]string{check, namespace, name, host, path, port, age})
-					}
+				if i != 0 {
+					check = ""
 				}
-			default:
-				return fmt.Errorf("unsupported manifest type %T", manifest)
+				tbl.Table.Append(append([]string{check}, row...))
 			}
 		}
 
@@ -731,7 +250,7 @@ func (r Describe) run(ctx context.Context, out io.Writer, opts describeOptions)
 		}
 	}
 
-	if len(*r.Driver.Releases()) > 0 {
+	if len(releases) > 0 {
 		if opts.Filter != "" {
 			fmt.Fprintf(out, "%s", render)
 		} else {
@@ -0,0 +1,400 @@
+package releases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// ErrDifferences is returned by the diff command when the releases differ
+// from the live cluster state.
+var ErrDifferences = errors.New("releases differ from the cluster")
+
+// Diff represents the cluster release diff command object.
+type Diff struct {
+	Driver interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// diffOptions is used for executing the run() command.
+type diffOptions struct {
+	Name     string         `json:"name" yaml:"name"`
+	Version  semver.Version `json:"version" yaml:"version"`
+	Resource string         `json:"resource" yaml:"resource"`
+}
+
+// Diff statuses of a single manifest object.
+const (
+	diffCreated = "created"
+	diffChanged = "changed"
+	diffDeleted = "deleted"
+)
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Diff)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Diff) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:] + " [NAME[:VERSION]] [RESOURCE]",
+		Short: "Shows the changes a release would make on running cluster.",
+		Long:  "Shows the changes a release would make on running cluster",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			files, _ := cmd.Flags().GetStringArray("values")
+			sets, _ := cmd.Flags().GetStringArray("set")
+			releases, err := renderReleases(r.Driver, files, sets)
+			if err != nil {
+				return err
+			}
+
+			var name string
+			var version semver.Version
+			if len(args) > 0 {
+				parts := strings.Split(args[0], ":")
+				parts = append(parts, "")
+				name = parts[0]
+
+				if parts[1] != "" {
+					var err error
+					version, err = semver.Parse(parts[1])
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			var resource string
+			if len(args) > 1 {
+				resource = args[1]
+			}
+
+			opts := diffOptions{
+				Name:     name,
+				Version:  version,
+				Resource: resource,
+			}
+			return r.run(ctx, cmd.OutOrStdout(), releases, opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.StringArray(
+		"values", nil,
+		"Reads release values from a yaml `FILE`.",
+	)
+	flags.StringArray(
+		"set", nil,
+		"Overrides a single release value with `KEY=VALUE`.",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Diff) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Diff) validation(cmd *cobra.Command, args []string) error {
+	if err := require.MaxArgs(args, 2); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the cluster release diff
+// command.
+func (r Diff) run(ctx context.Context, out io.Writer, releases types.Releases, opts diffOptions) error {
+	cluster, err := LookupCluster(ctx, r.Driver, *r.Driver.Namespace())
+	if err != nil {
+		return err
+	}
+
+	records, err := openReleases(ctx, r.Driver)
+	if err != nil {
+		return err
+	}
+	return r.diff(ctx, out, cluster, records, releases, opts)
+}
+
+// diff writes the differences between the selected releases and the live
+// cluster state. Without a version only the newest version of every release
+// is compared since older versions always differ once a newer one is
+// deployed. Objects of the deployed version of a release which are missing
+// from the compared version are reported as deleted.
+func (r Diff) diff(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	records state.ReleaseStore,
+	releases types.Releases,
+	opts diffOptions,
+) error {
+	revisions, err := records.Releases(ctx)
+	if err != nil {
+		return err
+	}
+
+	latest := latestReleases(releases, applyOptions{Name: opts.Name, Version: opts.Version})
+
+	differs := false
+	sort.Sort(releases)
+	for _, rel := range releases {
+		if latest[rel.Name] != rel {
+			continue
+		}
+
+		wanted := make(map[string]bool)
+		for _, manifest := range rel.Manifests {
+			wanted[manifestKey(manifest, cluster.Namespace)] = true
+			if !matchResource(manifest, opts.Resource) {
+				continue
+			}
+
+			changed, err := diffManifest(ctx, out, cluster, rel, manifest, false)
+			if err != nil {
+				return err
+			}
+			differs = differs || changed
+		}
+
+		deployed := deployedRevision(revisions, rel.Name)
+		if deployed == nil || deployed.Version == rel.Version.String() {
+			continue
+		}
+
+		for _, current := range releases {
+			if current.Name != rel.Name || current.Version.String() != deployed.Version {
+				continue
+			}
+
+			for _, manifest := range current.Manifests {
+				if wanted[manifestKey(manifest, cluster.Namespace)] ||
+					!matchResource(manifest, opts.Resource) {
+					continue
+				}
+
+				changed, err := diffManifest(ctx, out, cluster, current, manifest, true)
+				if err != nil {
+					return err
+				}
+				differs = differs || changed
+			}
+		}
+	}
+
+	if differs {
+		return ErrDifferences
+	}
+	return nil
+}
+
+// diffManifest writes the unified yaml diff between the live object and the
+// manifest object. The manifest is normalized through a server-side dry-run
+// apply so that defaulted fields do not show up as differences. Deleted
+// objects are compared against nothing instead.
+func diffManifest(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	rel *types.Release,
+	manifest runtime.Object,
+	deleted bool,
+) (bool, error) {
+	resource, obj, err := cluster.Resource(manifest)
+	if err != nil {
+		return false, err
+	}
+	if err := labelObject(obj, rel); err != nil {
+		return false, err
+	}
+
+	live, err := resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+	if v1err.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return false, err
+	}
+
+	status := diffChanged
+	var wanted *unstructured.Unstructured
+	if deleted {
+		if live == nil {
+			return false, nil
+		}
+		status = diffDeleted
+	} else {
+		if live == nil {
+			status = diffCreated
+		}
+
+		rbytes, err := json.Marshal(obj)
+		if err != nil {
+			return false, err
+		}
+
+		force := true
+		wanted, err = resource.Patch(ctx, obj.GetName(), k8stypes.ApplyPatchType, rbytes, v1meta.PatchOptions{
+			FieldManager: FieldManager,
+			Force:        &force,
+			DryRun:       []string{v1meta.DryRunAll},
+		})
+		if err != nil {
+			return false, conflictError(obj, err)
+		}
+	}
+
+	before, err := normalizeObject(live)
+	if err != nil {
+		return false, err
+	}
+
+	after, err := normalizeObject(wanted)
+	if err != nil {
+		return false, err
+	}
+
+	if before == after {
+		return false, nil
+	}
+
+	path := obj.GetKind() + "/" + obj.GetName()
+	if obj.GetNamespace() != "" {
+		path = obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	}
+
+	text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: "live/" + path,
+		ToFile:   "release/" + path,
+		Context:  3,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Fprintf(out, "==> %s (%s)\n%s", path, status, text)
+	return true, nil
+}
+
+// normalizeObject renders the object as yaml without the fields which are
+// maintained by the server or derived from the manifest.
+func normalizeObject(obj *unstructured.Unstructured) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+
+	obj = obj.DeepCopy()
+	delete(obj.Object, "status")
+	for _, field := range []string{
+		"creationTimestamp",
+		"generation",
+		"managedFields",
+		"resourceVersion",
+		"selfLink",
+		"uid",
+	} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	// the manifest hash changes along with the manifest itself and would
+	// only add noise to the differences.
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", ManifestHashAnnotation)
+	if len(obj.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+
+	rbytes, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", err
+	}
+	return string(rbytes), nil
+}
+
+// splitLines splits the text into lines which keep their line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchResource reports whether the manifest object is of the resource kind.
+// Every object matches an empty resource.
+func matchResource(manifest runtime.Object, resource string) bool {
+	if resource == "" {
+		return true
+	}
+	kind := manifest.GetObjectKind().GroupVersionKind().Kind
+	return strings.EqualFold(kind, resource)
+}
+
+// deployedRevision returns the latest deployed revision of the release or nil
+// when the release is not deployed.
+func deployedRevision(revisions []state.Release, name string) *state.Release {
+	var deployed *state.Release
+	for i := range revisions {
+		if revisions[i].Name != name || revisions[i].Status != state.StatusDeployed {
+			continue
+		}
+		if deployed == nil || revisions[i].Revision > deployed.Revision {
+			deployed = &revisions[i]
+		}
+	}
+	return deployed
+}
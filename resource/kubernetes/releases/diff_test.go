@@ -0,0 +1,166 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type DiffSuite struct {
+	suite.Suite
+	Dir     string
+	State   state.Store
+	Cluster *Cluster
+	Driver  interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *DiffSuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+	r.State = state.NewFile(filepath.Join(dir, "state.json"))
+	r.Cluster = newFakeCluster()
+
+	r.Driver = testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases: &types.Releases{
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: []runtime.Object{
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "settings"},
+						Data:       map[string]string{"level": "info"},
+					},
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "legacy"},
+					},
+				},
+			},
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Manifests: []runtime.Object{
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "settings"},
+						Data:       map[string]string{"level": "debug"},
+					},
+					&v1core.Service{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+					},
+				},
+			},
+		},
+		State: r.State,
+	}.Build()
+}
+
+func (r *DiffSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *DiffSuite) TestDiff() {
+	ctx := context.Background()
+	releases := *r.Driver.Releases()
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, releases[0], applyOptions{}, events.StatusInstalled))
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[0], state.StatusDeployed))
+
+	cmd := Diff{Driver: r.Driver}
+	buffer := bytes.NewBuffer(nil)
+	err := cmd.diff(ctx, buffer, r.Cluster, r.State, releases, diffOptions{Name: "unittest", Version: releases[0].Version})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "", buffer.String())
+
+	buffer = bytes.NewBuffer(nil)
+	err = cmd.diff(ctx, buffer, r.Cluster, r.State, releases, diffOptions{Name: "unittest", Version: releases[1].Version})
+	assert.Equal(r.T(), ErrDifferences, err)
+	assert.Equal(r.T(), ""+
+		"==> ConfigMap/unittest/settings (changed)\n"+
+		"--- live/ConfigMap/unittest/settings\n"+
+		"+++ release/ConfigMap/unittest/settings\n"+
+		"@@ -1,11 +1,11 @@\n"+
+		" apiVersion: v1\n"+
+		" data:\n"+
+		"-  level: info\n"+
+		"+  level: debug\n"+
+		" kind: ConfigMap\n"+
+		" metadata:\n"+
+		"   labels:\n"+
+		"     app.kubernetes.io/instance: unittest\n"+
+		"     app.kubernetes.io/managed-by: migrate\n"+
+		"-    app.kubernetes.io/version: 0.0.1\n"+
+		"+    app.kubernetes.io/version: 0.0.2\n"+
+		"   name: settings\n"+
+		"   namespace: unittest\n"+
+		"==> Service/unittest/unittest (created)\n"+
+		"--- live/Service/unittest/unittest\n"+
+		"+++ release/Service/unittest/unittest\n"+
+		"@@ -0,0 +1,10 @@\n"+
+		"+apiVersion: v1\n"+
+		"+kind: Service\n"+
+		"+metadata:\n"+
+		"+  labels:\n"+
+		"+    app.kubernetes.io/instance: unittest\n"+
+		"+    app.kubernetes.io/managed-by: migrate\n"+
+		"+    app.kubernetes.io/version: 0.0.2\n"+
+		"+  name: unittest\n"+
+		"+  namespace: unittest\n"+
+		"+spec: {}\n"+
+		"==> ConfigMap/unittest/legacy (deleted)\n"+
+		"--- live/ConfigMap/unittest/legacy\n"+
+		"+++ release/ConfigMap/unittest/legacy\n"+
+		"@@ -1,9 +0,0 @@\n"+
+		"-apiVersion: v1\n"+
+		"-kind: ConfigMap\n"+
+		"-metadata:\n"+
+		"-  labels:\n"+
+		"-    app.kubernetes.io/instance: unittest\n"+
+		"-    app.kubernetes.io/managed-by: migrate\n"+
+		"-    app.kubernetes.io/version: 0.0.1\n"+
+		"-  name: legacy\n"+
+		"-  namespace: unittest\n",
+		buffer.String())
+
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, releases[1], applyOptions{}, events.StatusUpdated))
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[1], state.StatusDeployed))
+
+	buffer = bytes.NewBuffer(nil)
+	err = cmd.diff(ctx, buffer, r.Cluster, r.State, releases, diffOptions{Name: "unittest"})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "", buffer.String())
+
+	buffer = bytes.NewBuffer(nil)
+	err = cmd.diff(ctx, buffer, r.Cluster, r.State, releases, diffOptions{Name: "missing"})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "", buffer.String())
+}
+
+func TestDiffSuite(t *testing.T) {
+	suite.Run(t, new(DiffSuite))
+}
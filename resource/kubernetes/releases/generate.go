@@ -1,42 +1,53 @@
 package releases
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"go/format"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
-	"sort"
 	"strings"
 	"text/template"
 
 	"github.com/blang/semver"
 	"github.com/spf13/cobra"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/literal"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
 
-const templateContent = `
-package migrations
+const templateContent = `package migrations
 
 import (
+{{- range $alias, $path := .Imports }}
+	{{ if ne $alias (base $path) }}{{ $alias }} {{ end }}"{{ $path }}"
+{{- end }}
 	"github.com/blang/semver"
-	"github.com/trivigy/migrate"
-	"github.com/trivigy/migrate/database"
+
+	"github.com/trivigy/migrate/v2"
+	"github.com/trivigy/migrate/v2/types"
 )
 
 func init() {
-	tag := "{{ .Tag }}"
-	name := "{{ .Name }}"
-	migrate.Registry.Store(database.Path(name, tag), database.Migration{
-		Name: name,		
-		Tag: semver.MustParse(tag),
-		Up: []database.Operation{},
-		Down: []database.Operation{},
+	migrate.Registry.Store(&types.Release{
+		Name:    "{{ .Name }}",
+		Version: semver.MustParse("{{ .Tag }}"),
+		Manifests: {{ .Manifests }},
 	})
 }
 `
@@ -55,6 +66,10 @@ type GenerateOptions struct {
 	Dir  string `json:"dir" yaml:"dir"`
 	Name string `json:"name" yaml:"name"`
 	Tag  string `json:"tag" yaml:"tag"`
+
+	// FromYAML lists manifest files which are converted into typed objects
+	// instead of using the sample manifests.
+	FromYAML []string `json:"fromYAML" yaml:"fromYAML"`
 }
 
 var _ interface {
@@ -75,11 +90,21 @@ func (r Generate) NewCommand(ctx context.Context, name string) *cobra.Command {
 				return err
 			}
 
+			fromYAML, err := cmd.Flags().GetStringSlice("from-yaml")
+			if err != nil {
+				return err
+			}
+
 			parts := strings.Split(args[0], ":")
 			parts = append(parts, "")
 			name, tag := parts[0], parts[1]
 
-			opts := GenerateOptions{Dir: dir, Name: name, Tag: tag}
+			opts := GenerateOptions{
+				Dir:      dir,
+				Name:     name,
+				Tag:      tag,
+				FromYAML: fromYAML,
+			}
 			return r.run(cmd.OutOrStdout(), opts)
 		},
 		SilenceErrors: true,
@@ -95,6 +120,10 @@ func (r Generate) NewCommand(ctx context.Context, name string) *cobra.Command {
 		"dir", "d", ".",
 		"Specify directory `PATH` where to generate miration file.",
 	)
+	flags.StringSlice(
+		"from-yaml", nil,
+		"Convert manifests found in YAML `FILE` into typed objects (repeatable).",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -145,11 +174,16 @@ func (r Generate) run(out io.Writer, opts GenerateOptions) error {
 		return fmt.Errorf("directory %q not found", opts.Dir)
 	}
 
-	sort.Sort(*r.Driver.Releases())
-	tags := semver.Versions{semver.Version{}}
+	tags, err := fileTags(base)
+	if err != nil {
+		return err
+	}
+
+	tags = append(tags, semver.Version{})
 	for _, rgMig := range *r.Driver.Releases() {
 		tags = append(tags, rgMig.Version)
 	}
+	semver.Sort(tags)
 
 	if opts.Tag == "" {
 		v := tags[len(tags)-1]
@@ -163,19 +197,192 @@ func (r Generate) run(out io.Writer, opts GenerateOptions) error {
 		}
 	}
 
-	filename := fmt.Sprintf("%s_%s.go", opts.Tag, opts.Name)
-	fullpath := path.Join(base, filename)
-	file, err := os.Create(fullpath)
+	manifests := sampleManifests(opts.Name)
+	if len(opts.FromYAML) > 0 {
+		manifests = nil
+		for _, filename := range opts.FromYAML {
+			objects, err := loadManifests(filename)
+			if err != nil {
+				return err
+			}
+			manifests = append(manifests, objects...)
+		}
+	}
+
+	printer := literal.NewPrinter()
+	data := struct {
+		GenerateOptions
+		Imports   map[string]string
+		Manifests string
+	}{GenerateOptions: opts}
+	if data.Manifests, err = printer.Print(manifests); err != nil {
+		return err
+	}
+	data.Imports = printer.Imports()
+
+	buffer := bytes.NewBuffer(nil)
+	tpl := template.Must(template.New("releases").
+		Funcs(template.FuncMap{"base": path.Base}).
+		Parse(templateContent))
+	if err := tpl.Execute(buffer, data); err != nil {
+		return err
+	}
+
+	source, err := format.Source(buffer.Bytes())
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	tpl := template.Must(template.New("releases").Parse(templateContent))
-	if err := tpl.Execute(file, opts); err != nil {
+	filename := fmt.Sprintf("%s_%s.go", opts.Tag, opts.Name)
+	fullpath := path.Join(base, filename)
+	if err := ioutil.WriteFile(fullpath, source, 0644); err != nil {
 		return err
 	}
 
 	fmt.Fprintf(out, "Created release %q\n", fullpath)
 	return nil
 }
+
+// fileTags returns the tags of the release files previously generated into
+// the directory. Releases are registered only once the generated files are
+// compiled, therefore their tags may be missing from the registry.
+func fileTags(dir string) (semver.Versions, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := semver.Versions{}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		parts := strings.SplitN(file.Name(), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if tag, err := semver.Parse(parts[0]); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// loadManifests decodes every yaml document found in the file into a typed
+// kubernetes object.
+func loadManifests(filename string) ([]runtime.Object, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	manifests := make([]runtime.Object, 0)
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	reader := yaml.NewYAMLReader(bufio.NewReader(file))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, gvk, err := decode(doc, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding %q (%s)", filename, err)
+		}
+		obj.GetObjectKind().SetGroupVersionKind(*gvk)
+		manifests = append(manifests, obj)
+	}
+	return manifests, nil
+}
+
+// sampleManifests returns the manifests used to populate a new release when
+// no yaml files are provided.
+func sampleManifests(name string) []runtime.Object {
+	labels := map[string]string{"app": name}
+	return []runtime.Object{
+		&v1core.ConfigMap{
+			TypeMeta: v1meta.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+			Data: map[string]string{
+				"LOG_LEVEL": "info",
+			},
+		},
+		&v1core.Service{
+			TypeMeta: v1meta.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Service",
+			},
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+			Spec: v1core.ServiceSpec{
+				Ports: []v1core.ServicePort{
+					{
+						Port:       80,
+						TargetPort: intstr.FromInt(80),
+					},
+				},
+				Selector: labels,
+			},
+		},
+		&v1apps.Deployment{
+			TypeMeta: v1meta.TypeMeta{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+			},
+			ObjectMeta: v1meta.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+			Spec: v1apps.DeploymentSpec{
+				Replicas: &[]int32{1}[0],
+				Selector: &v1meta.LabelSelector{
+					MatchLabels: labels,
+				},
+				Template: v1core.PodTemplateSpec{
+					ObjectMeta: v1meta.ObjectMeta{
+						Labels: labels,
+					},
+					Spec: v1core.PodSpec{
+						Containers: []v1core.Container{
+							{
+								Name:  name,
+								Image: "nginx:latest",
+								Ports: []v1core.ContainerPort{
+									{
+										ContainerPort: 80,
+									},
+								},
+								EnvFrom: []v1core.EnvFromSource{
+									{
+										ConfigMapRef: &v1core.ConfigMapEnvSource{
+											LocalObjectReference: v1core.LocalObjectReference{
+												Name: name,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
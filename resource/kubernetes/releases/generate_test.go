@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/stretchr/testify/assert"
@@ -20,6 +21,18 @@ func (r *ReleasesSuite) TestGenerateCommand() {
 	}
 	defer os.RemoveAll(dir)
 
+	manifest := filepath.Join(dir, "manifest.yaml")
+	if err := ioutil.WriteFile(manifest, []byte(
+		"apiVersion: v1\n"+
+			"kind: ConfigMap\n"+
+			"metadata:\n"+
+			"  name: unittest\n"+
+			"data:\n"+
+			"  key: value\n",
+	), 0644); err != nil {
+		panic(err)
+	}
+
 	testCases := []struct {
 		shouldFail bool
 		onFail     string
@@ -33,6 +46,24 @@ func (r *ReleasesSuite) TestGenerateCommand() {
 			bytes.NewBuffer(nil),
 			[]string{"example", "-d", dir},
 		},
+		{
+			false, "",
+			Generate{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"converted", "-d", dir, "--from-yaml", manifest},
+		},
+		{
+			true, "release tag \"0.0.3\" exists",
+			Generate{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"duplicate:0.0.3", "-d", dir},
+		},
+		{
+			true, "open ./not-found.yaml: no such file or directory",
+			Generate{Driver: r.Driver},
+			bytes.NewBuffer(nil),
+			[]string{"missing", "-d", dir, "--from-yaml", "./not-found.yaml"},
+		},
 		{
 			true, "directory \"./not-found\" not found",
 			Generate{Driver: r.Driver},
@@ -47,8 +78,9 @@ func (r *ReleasesSuite) TestGenerateCommand() {
 				"  generate NAME[:TAG] [flags]\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -d, --dir PATH   Specify directory PATH where to generate miration file. (default \".\")\n" +
-				"      --help       Show help information.\n",
+				"  -d, --dir PATH         Specify directory PATH where to generate miration file. (default \".\")\n" +
+				"      --from-yaml FILE   Convert manifests found in YAML FILE into typed objects (repeatable).\n" +
+				"      --help             Show help information.\n",
 			Generate{Driver: r.Driver},
 			bytes.NewBuffer(nil),
 			[]string{},
@@ -61,8 +93,9 @@ func (r *ReleasesSuite) TestGenerateCommand() {
 				"  generate NAME[:TAG] [flags]\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -d, --dir PATH   Specify directory PATH where to generate miration file. (default \".\")\n" +
-				"      --help       Show help information.\n",
+				"  -d, --dir PATH         Specify directory PATH where to generate miration file. (default \".\")\n" +
+				"      --from-yaml FILE   Convert manifests found in YAML FILE into typed objects (repeatable).\n" +
+				"      --help             Show help information.\n",
 			Generate{Driver: r.Driver},
 			bytes.NewBuffer(nil),
 			[]string{"name:wrong"},
@@ -75,8 +108,9 @@ func (r *ReleasesSuite) TestGenerateCommand() {
 				"  generate NAME[:TAG] [flags]\n" +
 				"\n" +
 				"Flags:\n" +
-				"  -d, --dir PATH   Specify directory PATH where to generate miration file. (default \".\")\n" +
-				"      --help       Show help information.\n",
+				"  -d, --dir PATH         Specify directory PATH where to generate miration file. (default \".\")\n" +
+				"      --from-yaml FILE   Convert manifests found in YAML FILE into typed objects (repeatable).\n" +
+				"      --help             Show help information.\n",
 			Generate{Driver: r.Driver},
 			bytes.NewBuffer(nil),
 			[]string{"name:0.0.0-alpha.2+001"},
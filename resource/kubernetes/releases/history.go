@@ -0,0 +1,153 @@
+package releases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// History represents the cluster release history command object.
+type History struct {
+	Driver interface {
+		driver.WithNamespace
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// historyOptions is used for executing the run() command.
+type historyOptions struct {
+	Name   string `json:"name" yaml:"name"`
+	Format string `json:"format" yaml:"format"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(History)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r History) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:] + " NAME",
+		Short: "Prints the recorded revisions of a release.",
+		Long:  "Prints the recorded revisions of a release",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			format, _ := cmd.Flags().GetString("format")
+			opts := historyOptions{
+				Name:   args[0],
+				Format: format,
+			}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.StringP(
+		"format", "f", "table",
+		"Output `FORMAT` (table or json).",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r History) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r History) validation(cmd *cobra.Command, args []string) error {
+	if err := require.ExactArgs(args, 1); err != nil {
+		return err
+	}
+
+	switch format, _ := cmd.Flags().GetString("format"); format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+	return nil
+}
+
+// run is a starting point method for executing the cluster release history
+// command.
+func (r History) run(ctx context.Context, out io.Writer, opts historyOptions) error {
+	records, err := openReleases(ctx, r.Driver)
+	if err != nil {
+		return err
+	}
+
+	releases, err := records.Releases(ctx)
+	if err != nil {
+		return err
+	}
+
+	revisions := make([]state.Release, 0)
+	for _, release := range releases {
+		if release.Name == opts.Name {
+			revisions = append(revisions, release)
+		}
+	}
+
+	if len(revisions) == 0 {
+		return fmt.Errorf("release %q not found", opts.Name)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(revisions)
+	}
+
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Revision", "Version", "Status", "Digest", "Timestamp"})
+	table.SetColWidth(80)
+	for _, revision := range revisions {
+		table.Append([]string{
+			strconv.Itoa(revision.Revision),
+			revision.Version,
+			revision.Status,
+			revision.Digest,
+			revision.Timestamp.Format(time.RFC3339),
+		})
+	}
+	table.Render()
+	return nil
+}
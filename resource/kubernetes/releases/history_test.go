@@ -0,0 +1,156 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type HistorySuite struct {
+	suite.Suite
+	Dir    string
+	State  state.Store
+	Driver interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *HistorySuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+	r.State = state.NewFile(filepath.Join(dir, "state.json"))
+
+	r.Driver = testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases: &types.Releases{
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: []runtime.Object{
+					&v1core.Service{
+						TypeMeta: v1meta.TypeMeta{
+							APIVersion: "v1",
+							Kind:       "Service",
+						},
+						ObjectMeta: v1meta.ObjectMeta{
+							Name: "unittest",
+						},
+					},
+				},
+			},
+		},
+		State: r.State,
+	}.Build()
+}
+
+func (r *HistorySuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *HistorySuite) TestRecordRelease() {
+	ctx := context.Background()
+	rel := (*r.Driver.Releases())[0]
+
+	steps := []string{
+		state.StatusDeployed,
+		state.StatusDeployed,
+		state.StatusFailed,
+		state.StatusUninstalled,
+	}
+	for _, status := range steps {
+		assert.Nil(r.T(), recordRelease(ctx, r.State, rel, status))
+	}
+
+	digest, err := manifestDigest(rel)
+	assert.Nil(r.T(), err)
+
+	releases, err := r.State.Releases(ctx)
+	assert.Nil(r.T(), err)
+
+	statuses := make(map[int]string)
+	for _, release := range releases {
+		assert.Equal(r.T(), "unittest", release.Name)
+		assert.Equal(r.T(), "0.0.1", release.Version)
+		assert.Equal(r.T(), digest, release.Digest)
+		statuses[release.Revision] = release.Status
+	}
+	assert.Equal(r.T(), map[int]string{
+		1: state.StatusSuperseded,
+		2: state.StatusSuperseded,
+		3: state.StatusFailed,
+		4: state.StatusUninstalled,
+	}, statuses)
+}
+
+func (r *HistorySuite) TestHistory() {
+	ctx := context.Background()
+	rel := (*r.Driver.Releases())[0]
+	assert.Nil(r.T(), recordRelease(ctx, r.State, rel, state.StatusDeployed))
+	assert.Nil(r.T(), recordRelease(ctx, r.State, rel, state.StatusFailed))
+
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		args       []string
+	}{
+		{true, `release "missing" not found`, []string{"missing"}},
+		{true, `invalid format "yaml"`, []string{"unittest", "-f", "yaml"}},
+		{true, "accepts 1 arg(s), received 0", []string{}},
+	}
+
+	for i, testCase := range testCases {
+		buffer := bytes.NewBuffer(nil)
+		cmd := History{Driver: r.Driver}
+		err := cmd.Execute("history", buffer, testCase.args)
+		if testCase.shouldFail {
+			if assert.Error(r.T(), err, "testCase: %d %v", i, testCase.args) {
+				assert.Contains(r.T(), err.Error(), testCase.onFail, "testCase: %d %v", i, testCase.args)
+			}
+		} else {
+			assert.Nil(r.T(), err, "testCase: %d %v", i, testCase.args)
+		}
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err := History{Driver: r.Driver}.Execute("history", buffer, []string{"unittest", "-f", "json"})
+	assert.Nil(r.T(), err)
+
+	var revisions []state.Release
+	assert.Nil(r.T(), json.Unmarshal(buffer.Bytes(), &revisions))
+	assert.Len(r.T(), revisions, 2)
+	assert.Equal(r.T(), 1, revisions[0].Revision)
+	assert.Equal(r.T(), state.StatusDeployed, revisions[0].Status)
+	assert.Equal(r.T(), 2, revisions[1].Revision)
+	assert.Equal(r.T(), state.StatusFailed, revisions[1].Status)
+
+	buffer = bytes.NewBuffer(nil)
+	err = History{Driver: r.Driver}.Execute("history", buffer, []string{"unittest"})
+	assert.Nil(r.T(), err)
+	assert.Contains(r.T(), buffer.String(), "REVISION")
+	assert.Contains(r.T(), buffer.String(), state.StatusFailed)
+}
+
+func TestHistorySuite(t *testing.T) {
+	suite.Run(t, new(HistorySuite))
+}
@@ -2,25 +2,17 @@ package releases
 
 import (
 	"context"
-	"fmt"
 	"io"
-	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
-	v1apps "k8s.io/api/apps/v1"
-	v1core "k8s.io/api/core/v1"
-	v1ext "k8s.io/api/extensions/v1beta1"
-	v1policy "k8s.io/api/policy/v1beta1"
-	v1rbac "k8s.io/api/rbac/v1"
-	v1err "k8s.io/apimachinery/pkg/api/errors"
-	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
@@ -34,13 +26,6 @@ type Install struct {
 	} `json:"driver" yaml:"driver"`
 }
 
-// installOptions is used for executing the run() command.
-type installOptions struct {
-	Name     string         `json:"name" yaml:"name"`
-	Version  semver.Version `json:"version" yaml:"version"`
-	Resource string         `json:"resource" yaml:"resource"`
-}
-
 var _ interface {
 	types.Resource
 	types.Command
@@ -53,7 +38,10 @@ func (r Install) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Deploys release resources on running cluster.",
 		Long:  "Deploys release resources on running cluster",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			if patches, ok := r.Driver.(driver.WithPatches); ok {
 				for _, patch := range *patches.Patches(name) {
 					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
@@ -62,14 +50,11 @@ func (r Install) NewCommand(ctx context.Context, name string) *cobra.Command {
 				}
 			}
 
-			if try, _ := cmd.Flags().GetBool("try"); try {
-				rbytes, err := yaml.Marshal(r.Driver)
-				if err != nil {
-					return err
-				}
-
-				fmt.Fprintf(cmd.OutOrStdout(), "%+v\n", string(rbytes))
-				return nil
+			files, _ := cmd.Flags().GetStringArray("values")
+			sets, _ := cmd.Flags().GetStringArray("set")
+			releases, err := renderReleases(r.Driver, files, sets)
+			if err != nil {
+				return err
 			}
 
 			var name string
@@ -93,13 +78,32 @@ func (r Install) NewCommand(ctx context.Context, name string) *cobra.Command {
 				resource = args[1]
 			}
 
-			opts := installOptions{
-				Name:     name,
-				Version:  version,
-				Resource: resource,
+			forceConflicts, _ := cmd.Flags().GetBool("force-conflicts")
+			wait, _ := cmd.Flags().GetBool("wait")
+			waitTimeout, _ := cmd.Flags().GetDuration("wait-timeout")
+			prune, _ := cmd.Flags().GetBool("prune")
+			opts := applyOptions{
+				Name:           name,
+				Version:        version,
+				Resource:       resource,
+				ForceConflicts: forceConflicts,
+				Wait:           wait,
+				WaitTimeout:    waitTimeout,
+				Prune:          prune,
 			}
 
-			return r.run(ctx, cmd.OutOrStdout(), opts)
+			if try, _ := cmd.Flags().GetBool("try"); try {
+				if err := tryRender(cmd.OutOrStdout(), releases, opts); err != nil {
+					return err
+				}
+
+				if opts.Prune {
+					return tryPrune(ctx, cmd.OutOrStdout(), r.Driver, releases, opts)
+				}
+				return nil
+			}
+
+			return r.run(ctx, cmd.OutOrStdout(), releases, opts)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -112,7 +116,31 @@ func (r Install) NewCommand(ctx context.Context, name string) *cobra.Command {
 	flags.SortFlags = false
 	flags.Bool(
 		"try", false,
-		"Simulates and prints resource execution parameters.",
+		"Simulates and prints the rendered release manifests.",
+	)
+	flags.Bool(
+		"force-conflicts", false,
+		"Takes ownership of fields managed by other field managers.",
+	)
+	flags.Bool(
+		"prune", false,
+		"Deletes objects of the release missing from its manifests.",
+	)
+	flags.Bool(
+		"wait", false,
+		"Waits until the release resources are ready.",
+	)
+	flags.Duration(
+		"wait-timeout", 5*time.Minute,
+		"Gives up waiting once `DURATION` elapses.",
+	)
+	flags.StringArray(
+		"values", nil,
+		"Reads release values from a yaml `FILE`.",
+	)
+	flags.StringArray(
+		"set", nil,
+		"Overrides a single release value with `KEY=VALUE`.",
 	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
@@ -141,237 +169,6 @@ func (r Install) validation(cmd *cobra.Command, args []string) error {
 
 // run is a starting point method for executing the cluster release install
 // command.
-func (r Install) run(ctx context.Context, out io.Writer, opts installOptions) error {
-	kubectl, err := GetK8sClientset(ctx, r.Driver, *r.Driver.Namespace())
-	if err != nil {
-		return err
-	}
-
-	sort.Sort(*r.Driver.Releases())
-	for _, rel := range *r.Driver.Releases() {
-		if opts.Name != "" && rel.Name != opts.Name ||
-			(!opts.Version.EQ(semver.Version{}) &&
-				!rel.Version.Equals(opts.Version)) {
-			continue
-		}
-
-		for _, manifest := range rel.Manifests {
-			if m, ok := manifest.(runtime.Object); ok && opts.Resource != "" {
-				resource := m.GetObjectKind().GroupVersionKind().Kind
-				if !strings.EqualFold(resource, opts.Resource) {
-					continue
-				}
-			}
-
-			switch manifest := manifest.(type) {
-			case *v1core.Namespace:
-				_, err := kubectl.CoreV1().
-					Namespaces().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						Namespaces().
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1core.Pod:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.CoreV1().
-					Pods(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						Pods(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1core.ServiceAccount:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.CoreV1().
-					ServiceAccounts(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						ServiceAccounts(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1core.ConfigMap:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.CoreV1().
-					ConfigMaps(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						ConfigMaps(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1core.Endpoints:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.CoreV1().
-					Endpoints(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						Endpoints(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1core.Service:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.CoreV1().
-					Services(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						Services(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1core.Secret:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.CoreV1().
-					Secrets(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.CoreV1().
-						Secrets(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1rbac.Role:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.RbacV1().
-					Roles(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.RbacV1().
-						Roles(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1rbac.RoleBinding:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.RbacV1().
-					RoleBindings(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.RbacV1().
-						RoleBindings(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1rbac.ClusterRole:
-				_, err := kubectl.RbacV1().
-					ClusterRoles().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.RbacV1().
-						ClusterRoles().
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1rbac.ClusterRoleBinding:
-				_, err := kubectl.RbacV1().
-					ClusterRoleBindings().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.RbacV1().
-						ClusterRoleBindings().
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1policy.PodSecurityPolicy:
-				_, err := kubectl.PolicyV1beta1().
-					PodSecurityPolicies().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.PolicyV1beta1().
-						PodSecurityPolicies().
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1apps.DaemonSet:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.AppsV1().
-					DaemonSets(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.AppsV1().
-						DaemonSets(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1apps.Deployment:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.AppsV1().
-					Deployments(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.AppsV1().
-						Deployments(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1apps.StatefulSet:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.AppsV1().
-					Deployments(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.AppsV1().
-						StatefulSets(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			case *v1ext.Ingress:
-				namespace := FallBackNS(manifest.Namespace, *r.Driver.Namespace())
-				_, err := kubectl.ExtensionsV1beta1().
-					Ingresses(namespace).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					_, err := kubectl.ExtensionsV1beta1().
-						Ingresses(namespace).
-						Create(ctx, manifest, v1meta.CreateOptions{})
-					if err != nil {
-						return err
-					}
-				}
-			default:
-				return fmt.Errorf("unsupported manifest type %T", manifest)
-			}
-		}
-	}
-	return nil
+func (r Install) run(ctx context.Context, out io.Writer, releases types.Releases, opts applyOptions) error {
+	return applyReleases(ctx, out, r.Driver, releases, opts, events.StatusInstalled)
 }
@@ -0,0 +1,53 @@
+package releases
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Labels and annotations attached to every object applied as part of a
+// release. The labels follow the recommended kubernetes labels so that the
+// objects of a release may be queried with kubectl, e.g.
+// `kubectl get all -l app.kubernetes.io/instance=api,app.kubernetes.io/version=1.4.0`.
+const (
+	ManagedByLabel         = "app.kubernetes.io/managed-by"
+	InstanceLabel          = "app.kubernetes.io/instance"
+	VersionLabel           = "app.kubernetes.io/version"
+	ManifestHashAnnotation = "migrate.trivigy.com/manifest-hash"
+)
+
+// labelObject marks the cluster object as managed by the release and records
+// the hash of the manifest it was applied from.
+func labelObject(obj *unstructured.Unstructured, rel *types.Release) error {
+	rbytes, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(rbytes)
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	// build metadata is separated by a plus sign which is not allowed within
+	// label values.
+	labels[ManagedByLabel] = FieldManager
+	labels[InstanceLabel] = rel.Name
+	labels[VersionLabel] = strings.ReplaceAll(rel.Version.String(), "+", "_")
+	obj.SetLabels(labels)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[ManifestHashAnnotation] = "sha256:" + hex.EncodeToString(hash[:])
+	obj.SetAnnotations(annotations)
+	return nil
+}
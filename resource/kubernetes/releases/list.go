@@ -0,0 +1,237 @@
+package releases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// List represents the cluster release list command object.
+type List struct {
+	Driver interface {
+		driver.WithNamespace
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// listOptions is used for executing the run() command.
+type listOptions struct {
+	Format string `json:"format" yaml:"format"`
+}
+
+// installedRelease represents a release version discovered from the labels
+// of the cluster objects.
+type installedRelease struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	Namespaces []string `json:"namespaces"`
+	Objects    int      `json:"objects"`
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(List)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r List) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:],
+		Short: "Prints the releases installed on running cluster.",
+		Long:  "Prints the releases installed on running cluster",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			format, _ := cmd.Flags().GetString("format")
+			opts := listOptions{
+				Format: format,
+			}
+			return r.run(ctx, cmd.OutOrStdout(), opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.StringP(
+		"format", "f", "table",
+		"Output `FORMAT` (table or json).",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r List) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r List) validation(cmd *cobra.Command, args []string) error {
+	if err := require.NoArgs(args); err != nil {
+		return err
+	}
+
+	switch format, _ := cmd.Flags().GetString("format"); format {
+	case "table", "json":
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+	return nil
+}
+
+// run is a starting point method for executing the cluster release list
+// command.
+func (r List) run(ctx context.Context, out io.Writer, opts listOptions) error {
+	cluster, err := LookupCluster(ctx, r.Driver, *r.Driver.Namespace())
+	if err != nil {
+		return err
+	}
+
+	installed, err := discoverReleases(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(installed)
+	}
+
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Name", "Version", "Namespaces", "Objects"})
+	table.SetColWidth(80)
+	for _, release := range installed {
+		table.Append([]string{
+			release.Name,
+			release.Version,
+			strings.Join(release.Namespaces, ","),
+			strconv.Itoa(release.Objects),
+		})
+	}
+	table.Render()
+	return nil
+}
+
+// discoverReleases lists the objects managed by releases across every
+// listable api resource of the cluster and groups them by the release name
+// and version found in their labels. Api resources which cannot be listed,
+// e.g. due to missing permissions, are skipped.
+func discoverReleases(ctx context.Context, cluster *Cluster) ([]installedRelease, error) {
+	resources, err := discovery.ServerPreferredResources(cluster.Discovery)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{ManagedByLabel: FieldManager}).String()
+	seen := make(map[string]bool)
+	found := make(map[string]*installedRelease)
+	namespaces := make(map[string]map[string]bool)
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range list.APIResources {
+			if strings.Contains(resource.Name, "/") || !hasVerb(resource, "list") {
+				continue
+			}
+
+			items, err := cluster.Client.Resource(gv.WithResource(resource.Name)).
+				List(ctx, v1meta.ListOptions{LabelSelector: selector})
+			if v1err.IsForbidden(err) || v1err.IsNotFound(err) || v1err.IsMethodNotSupported(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			// the same objects may be served by more than one api group,
+			// e.g. ingresses, therefore they are counted only once.
+			for i := range items.Items {
+				key := objectKey(&items.Items[i])
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				name := items.Items[i].GetLabels()[InstanceLabel]
+				version := items.Items[i].GetLabels()[VersionLabel]
+				if name == "" {
+					continue
+				}
+
+				id := name + ":" + version
+				if _, ok := found[id]; !ok {
+					found[id] = &installedRelease{Name: name, Version: version}
+					namespaces[id] = make(map[string]bool)
+				}
+				found[id].Objects++
+				if namespace := items.Items[i].GetNamespace(); namespace != "" {
+					namespaces[id][namespace] = true
+				}
+			}
+		}
+	}
+
+	installed := make([]installedRelease, 0, len(found))
+	for id, release := range found {
+		release.Namespaces = make([]string, 0, len(namespaces[id]))
+		for namespace := range namespaces[id] {
+			release.Namespaces = append(release.Namespaces, namespace)
+		}
+		sort.Strings(release.Namespaces)
+		installed = append(installed, *release)
+	}
+
+	sort.Slice(installed, func(i, j int) bool {
+		if installed[i].Name != installed[j].Name {
+			return installed[i].Name < installed[j].Name
+		}
+		return installed[i].Version < installed[j].Version
+	})
+	return installed, nil
+}
+
+// hasVerb reports whether the api resource supports the verb.
+func hasVerb(resource v1meta.APIResource, verb string) bool {
+	for _, supported := range resource.Verbs {
+		if supported == verb {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type ListSuite struct {
+	suite.Suite
+	Cluster *Cluster
+}
+
+func (r *ListSuite) SetupTest() {
+	r.Cluster = newFakeCluster()
+}
+
+func (r *ListSuite) TestDiscoverReleases() {
+	ctx := context.Background()
+	releases := []*types.Release{
+		{
+			Name:    "api",
+			Version: semver.Version{Major: 1, Minor: 4, Patch: 0},
+			Manifests: []runtime.Object{
+				&v1core.Namespace{
+					TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+					ObjectMeta: v1meta.ObjectMeta{Name: "other"},
+				},
+				&v1core.ConfigMap{
+					TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+					ObjectMeta: v1meta.ObjectMeta{Name: "api"},
+				},
+				&v1apps.Deployment{
+					TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+					ObjectMeta: v1meta.ObjectMeta{Name: "api", Namespace: "other"},
+				},
+			},
+		},
+		{
+			Name:    "web",
+			Version: semver.Version{Major: 0, Minor: 1, Patch: 0},
+			Manifests: []runtime.Object{
+				&v1core.Service{
+					TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+					ObjectMeta: v1meta.ObjectMeta{Name: "web"},
+				},
+			},
+		},
+	}
+
+	for _, rel := range releases {
+		assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, rel, applyOptions{}, events.StatusInstalled))
+	}
+
+	foreign := &v1core.ConfigMap{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "foreign"},
+	}
+	resource, obj, err := r.Cluster.Resource(foreign)
+	assert.Nil(r.T(), err)
+	_, err = resource.Create(ctx, obj, v1meta.CreateOptions{})
+	assert.Nil(r.T(), err)
+
+	installed, err := discoverReleases(ctx, r.Cluster)
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), []installedRelease{
+		{Name: "api", Version: "1.4.0", Namespaces: []string{"other", "unittest"}, Objects: 3},
+		{Name: "web", Version: "0.1.0", Namespaces: []string{"unittest"}, Objects: 1},
+	}, installed)
+}
+
+func (r *ListSuite) TestListReadOnly() {
+	server, changes := newFakeAPIServer()
+	defer server.Close()
+
+	d := testutils.Kubernetes{
+		Namespace: &[]string{"missing"}[0],
+		Driver:    apiServerDriver{URL: server.URL},
+	}.Build()
+
+	buffer := bytes.NewBuffer(nil)
+	err := List{Driver: d}.Execute("list", buffer, []string{"--format", "json"})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), "[]\n", buffer.String())
+	assert.Empty(r.T(), *changes)
+}
+
+func TestListSuite(t *testing.T) {
+	suite.Run(t, new(ListSuite))
+}
@@ -0,0 +1,104 @@
+package releases
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// OrderAnnotation overrides the priority of a manifest object. The value is
+// an integer compared against the priorities of the well known kinds, e.g. a
+// value of 45 places an object after the rbac objects and before the config
+// maps.
+const OrderAnnotation = "migrate.trivigy.com/order"
+
+// kindPriorities defines the order in which the well known kinds are applied.
+// Objects are uninstalled in the reverse order.
+var kindPriorities = map[string]int{
+	"Namespace":                10,
+	"CustomResourceDefinition": 20,
+	"PodSecurityPolicy":        30,
+	"ServiceAccount":           30,
+	"ClusterRole":              40,
+	"ClusterRoleBinding":       40,
+	"Role":                     40,
+	"RoleBinding":              40,
+	"ConfigMap":                50,
+	"Secret":                   50,
+	"StorageClass":             60,
+	"PersistentVolume":         60,
+	"PersistentVolumeClaim":    60,
+	"Service":                  70,
+	"Pod":                      80,
+	"ReplicaSet":               80,
+	"Deployment":               80,
+	"StatefulSet":              80,
+	"DaemonSet":                80,
+	"Job":                      80,
+	"CronJob":                  80,
+	"Ingress":                  90,
+}
+
+// defaultPriority defines the priority of any other kind, including custom
+// resources, which therefore go after their definitions.
+const defaultPriority = 100
+
+// sortManifests returns the manifest objects in the order in which they are
+// applied. Objects of equal priority keep their relative order.
+func sortManifests(manifests []runtime.Object) ([]runtime.Object, error) {
+	priorities := make(map[runtime.Object]int, len(manifests))
+	for _, manifest := range manifests {
+		priority, err := manifestPriority(manifest)
+		if err != nil {
+			return nil, err
+		}
+		priorities[manifest] = priority
+	}
+
+	sorted := make([]runtime.Object, len(manifests))
+	copy(sorted, manifests)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return priorities[sorted[i]] < priorities[sorted[j]]
+	})
+	return sorted, nil
+}
+
+// reverseManifests returns the manifest objects in the order in which they
+// are uninstalled.
+func reverseManifests(manifests []runtime.Object) ([]runtime.Object, error) {
+	sorted, err := sortManifests(manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+		sorted[i], sorted[j] = sorted[j], sorted[i]
+	}
+	return sorted, nil
+}
+
+// manifestPriority returns the priority of the manifest object, preferring
+// the order annotation over the priority of its kind.
+func manifestPriority(manifest runtime.Object) (int, error) {
+	kind, name, _ := describeManifest(manifest, "")
+	accessor, err := meta.Accessor(manifest)
+	if err != nil {
+		return 0, err
+	}
+
+	if value, ok := accessor.GetAnnotations()[OrderAnnotation]; ok {
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s annotation %q on %s %q", OrderAnnotation, value, kind, name)
+		}
+		return priority, nil
+	}
+
+	if priority, ok := kindPriorities[kind]; ok {
+		return priority, nil
+	}
+	return defaultPriority, nil
+}
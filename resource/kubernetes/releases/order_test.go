@@ -0,0 +1,100 @@
+package releases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1ext "k8s.io/api/extensions/v1beta1"
+	v1rbac "k8s.io/api/rbac/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type OrderSuite struct {
+	suite.Suite
+}
+
+func (r *OrderSuite) TestSortManifests() {
+	widget := &unstructured.Unstructured{}
+	widget.SetAPIVersion("example.com/v1")
+	widget.SetKind("Widget")
+	widget.SetName("unittest")
+
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		manifests  []runtime.Object
+		sorted     []string
+	}{
+		{
+			false, "",
+			[]runtime.Object{
+				widget,
+				&v1ext.Ingress{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1apps.Deployment{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1core.Service{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1core.Secret{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1core.ConfigMap{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1rbac.RoleBinding{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1core.ServiceAccount{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1core.Namespace{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+			},
+			[]string{
+				"Namespace", "ServiceAccount", "RoleBinding", "Secret", "ConfigMap",
+				"Service", "Deployment", "Ingress", "Widget",
+			},
+		},
+		{
+			false, "",
+			[]runtime.Object{
+				&v1apps.Deployment{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+				&v1core.ConfigMap{ObjectMeta: v1meta.ObjectMeta{
+					Name:        "unittest",
+					Annotations: map[string]string{OrderAnnotation: "90"},
+				}},
+				&v1core.Namespace{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+			},
+			[]string{"Namespace", "Deployment", "ConfigMap"},
+		},
+		{
+			true, `invalid migrate.trivigy.com/order annotation "first" on ConfigMap "unittest"`,
+			[]runtime.Object{
+				&v1core.ConfigMap{ObjectMeta: v1meta.ObjectMeta{
+					Name:        "unittest",
+					Annotations: map[string]string{OrderAnnotation: "first"},
+				}},
+			},
+			nil,
+		},
+	}
+
+	for i, testCase := range testCases {
+		sorted, err := sortManifests(testCase.manifests)
+		if testCase.shouldFail {
+			assert.EqualError(r.T(), err, testCase.onFail, "testCase: %d", i)
+			continue
+		}
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		kinds := make([]string, 0)
+		for _, manifest := range sorted {
+			kind, _, _ := describeManifest(manifest, "")
+			kinds = append(kinds, kind)
+		}
+		assert.Equal(r.T(), testCase.sorted, kinds, "testCase: %d", i)
+
+		reversed, err := reverseManifests(testCase.manifests)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		for j := range reversed {
+			assert.Equal(r.T(), sorted[len(sorted)-1-j], reversed[j], "testCase: %d", i)
+		}
+	}
+}
+
+func TestOrderSuite(t *testing.T) {
+	suite.Run(t, new(OrderSuite))
+}
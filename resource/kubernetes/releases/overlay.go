@@ -0,0 +1,400 @@
+package releases
+
+import (
+	"fmt"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// clusterKinds defines the well known kinds of cluster scoped objects which
+// keep their namespace unset. The namespace of any other cluster scoped
+// object is dropped once it is resolved against the cluster.
+var clusterKinds = map[string]bool{
+	"Namespace":                      true,
+	"CustomResourceDefinition":       true,
+	"PodSecurityPolicy":              true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"StorageClass":                   true,
+	"PersistentVolume":               true,
+	"PriorityClass":                  true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+	"APIService":                     true,
+}
+
+// referencedKinds defines the kinds of objects referenced by name from pod
+// templates, which therefore follow the objects when they are renamed.
+var referencedKinds = map[string]bool{
+	"ConfigMap":             true,
+	"Secret":                true,
+	"ServiceAccount":        true,
+	"PersistentVolumeClaim": true,
+}
+
+// applyOverlay returns copies of the manifest objects transformed by the
+// overlay. The patches target the objects by their original names and are
+// applied first, followed by the images, common labels, namespace and name
+// transformations. The manifest objects themselves are never modified so that
+// the overlay is applied exactly once per rendering.
+func applyOverlay(overlay *types.Overlay, manifests []runtime.Object) ([]runtime.Object, error) {
+	objs := make([]*unstructured.Unstructured, 0, len(manifests))
+	for _, manifest := range manifests {
+		obj, err := toUnstructured(manifest.DeepCopyObject())
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+
+	for _, patch := range overlay.PatchesStrategicMerge {
+		if err := patchStrategicMerge(objs, patch); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, patch := range overlay.PatchesJSON6902 {
+		if err := patchJSON6902(objs, patch); err != nil {
+			return nil, err
+		}
+	}
+
+	renames := make(map[string]string)
+	for _, obj := range objs {
+		if spec := podSpec(obj); spec != nil {
+			replaceImages(spec, overlay.Images)
+		}
+
+		if err := addCommonLabels(obj, overlay.CommonLabels); err != nil {
+			return nil, err
+		}
+
+		kind := obj.GetKind()
+		if overlay.Namespace != "" && !clusterKinds[kind] {
+			obj.SetNamespace(overlay.Namespace)
+		}
+
+		if (overlay.NamePrefix != "" || overlay.NameSuffix != "") &&
+			kind != "Namespace" && kind != "CustomResourceDefinition" {
+			name := overlay.NamePrefix + obj.GetName() + overlay.NameSuffix
+			if referencedKinds[kind] {
+				renames[kind+"/"+obj.GetName()] = name
+			}
+			obj.SetName(name)
+		}
+	}
+
+	transformed := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		if spec := podSpec(obj); spec != nil {
+			renameReferences(spec, renames)
+		}
+		transformed = append(transformed, obj)
+	}
+	return transformed, nil
+}
+
+// patchStrategicMerge applies the strategic merge patch onto the objects
+// matching its apiVersion, kind, name and, when set, namespace. Kinds unknown
+// to the client scheme, such as custom resources, are patched with a json
+// merge patch instead.
+func patchStrategicMerge(objs []*unstructured.Unstructured, patch string) error {
+	pbytes, err := k8syaml.YAMLToJSON([]byte(patch))
+	if err != nil {
+		return fmt.Errorf("invalid strategic merge patch (%s)", err)
+	}
+
+	target := &unstructured.Unstructured{}
+	if err := target.UnmarshalJSON(pbytes); err != nil {
+		return fmt.Errorf("invalid strategic merge patch (%s)", err)
+	}
+
+	matched := false
+	for _, obj := range objs {
+		if obj.GetAPIVersion() != target.GetAPIVersion() ||
+			obj.GetKind() != target.GetKind() ||
+			obj.GetName() != target.GetName() ||
+			target.GetNamespace() != "" && obj.GetNamespace() != target.GetNamespace() {
+			continue
+		}
+		matched = true
+
+		obytes, err := obj.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		var patched []byte
+		if dataStruct, err := scheme.Scheme.New(obj.GroupVersionKind()); err == nil {
+			patched, err = strategicpatch.StrategicMergePatch(obytes, pbytes, dataStruct)
+			if err != nil {
+				return fmt.Errorf("failed to patch %s %q (%s)", obj.GetKind(), obj.GetName(), err)
+			}
+		} else {
+			patched, err = jsonpatch.MergePatch(obytes, pbytes)
+			if err != nil {
+				return fmt.Errorf("failed to patch %s %q (%s)", obj.GetKind(), obj.GetName(), err)
+			}
+		}
+
+		obj.Object = nil
+		if err := obj.UnmarshalJSON(patched); err != nil {
+			return err
+		}
+	}
+
+	if !matched {
+		return fmt.Errorf("strategic merge patch for %s %q matches no manifest", target.GetKind(), target.GetName())
+	}
+	return nil
+}
+
+// patchJSON6902 applies the json patch operations onto the objects matching
+// the patch target.
+func patchJSON6902(objs []*unstructured.Unstructured, patch types.JSON6902Patch) error {
+	pbytes, err := k8syaml.YAMLToJSON([]byte(patch.Patch))
+	if err != nil {
+		return fmt.Errorf("invalid json patch for %s %q (%s)", patch.Target.Kind, patch.Target.Name, err)
+	}
+
+	operations, err := jsonpatch.DecodePatch(pbytes)
+	if err != nil {
+		return fmt.Errorf("invalid json patch for %s %q (%s)", patch.Target.Kind, patch.Target.Name, err)
+	}
+
+	matched := false
+	for _, obj := range objs {
+		if !matchTarget(obj, patch.Target) {
+			continue
+		}
+		matched = true
+
+		obytes, err := obj.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		patched, err := operations.Apply(obytes)
+		if err != nil {
+			return fmt.Errorf("failed to patch %s %q (%s)", obj.GetKind(), obj.GetName(), err)
+		}
+
+		obj.Object = nil
+		if err := obj.UnmarshalJSON(patched); err != nil {
+			return err
+		}
+	}
+
+	if !matched {
+		return fmt.Errorf("json patch for %s %q matches no manifest", patch.Target.Kind, patch.Target.Name)
+	}
+	return nil
+}
+
+// matchTarget reports whether the object is selected by the patch target.
+func matchTarget(obj *unstructured.Unstructured, target types.PatchTarget) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Kind == target.Kind && obj.GetName() == target.Name &&
+		(target.Group == "" || gvk.Group == target.Group) &&
+		(target.Version == "" || gvk.Version == target.Version) &&
+		(target.Namespace == "" || obj.GetNamespace() == target.Namespace)
+}
+
+// podSpec returns the pod spec of the object, i.e. the spec of a pod, the pod
+// template of a cron job's job template or the pod template of any other
+// workload. The spec is returned by reference so that it can be modified.
+func podSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	var path []string
+	switch obj.GetKind() {
+	case "Pod":
+		path = []string{"spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		path = []string{"spec", "template", "spec"}
+	}
+
+	spec, _, _ := unstructured.NestedFieldNoCopy(obj.Object, path...)
+	if spec, ok := spec.(map[string]interface{}); ok {
+		return spec
+	}
+	return nil
+}
+
+// addCommonLabels adds the labels to the object. The selectors of services
+// and workloads as well as the labels of pod templates are extended too so
+// that they keep selecting the same, labelled, pods.
+func addCommonLabels(obj *unstructured.Unstructured, commonLabels map[string]string) error {
+	if len(commonLabels) == 0 {
+		return nil
+	}
+
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string, len(commonLabels))
+	}
+	for key, value := range commonLabels {
+		objLabels[key] = value
+	}
+	obj.SetLabels(objLabels)
+
+	paths := make([][]string, 0, 2)
+	switch obj.GetKind() {
+	case "Service":
+		if _, ok, _ := unstructured.NestedMap(obj.Object, "spec", "selector"); ok {
+			paths = append(paths, []string{"spec", "selector"})
+		}
+	case "Deployment", "ReplicaSet", "StatefulSet", "DaemonSet":
+		paths = append(paths,
+			[]string{"spec", "selector", "matchLabels"},
+			[]string{"spec", "template", "metadata", "labels"},
+		)
+	case "Job":
+		paths = append(paths, []string{"spec", "template", "metadata", "labels"})
+	case "CronJob":
+		paths = append(paths, []string{"spec", "jobTemplate", "spec", "template", "metadata", "labels"})
+	}
+
+	for _, path := range paths {
+		fieldLabels, _, err := unstructured.NestedStringMap(obj.Object, path...)
+		if err != nil {
+			return err
+		}
+		if fieldLabels == nil {
+			fieldLabels = make(map[string]string, len(commonLabels))
+		}
+		for key, value := range commonLabels {
+			fieldLabels[key] = value
+		}
+		if err := unstructured.SetNestedStringMap(obj.Object, fieldLabels, path...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceImages replaces the images of the containers and init containers of
+// the pod spec.
+func replaceImages(spec map[string]interface{}, images []types.Image) {
+	if len(images) == 0 {
+		return
+	}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		for _, container := range nestedMaps(spec, field) {
+			if image, ok := container["image"].(string); ok {
+				container["image"] = replaceImage(image, images)
+			}
+		}
+	}
+}
+
+// replaceImage returns the image reference with the name, tag or digest of
+// the first image replacement matching its name.
+func replaceImage(image string, images []types.Image) string {
+	name, suffix := image, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, suffix = name[:i], name[i:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, suffix = name[:i], name[i:]
+	}
+
+	for _, replacement := range images {
+		if replacement.Name != name {
+			continue
+		}
+
+		if replacement.NewName != "" {
+			name = replacement.NewName
+		}
+
+		switch {
+		case replacement.Digest != "":
+			suffix = "@" + replacement.Digest
+		case replacement.NewTag != "":
+			suffix = ":" + replacement.NewTag
+		}
+		return name + suffix
+	}
+	return image
+}
+
+// renameReferences updates the names of the config maps, secrets, service
+// accounts and persistent volume claims referenced by the pod spec.
+func renameReferences(spec map[string]interface{}, renames map[string]string) {
+	if len(renames) == 0 {
+		return
+	}
+
+	rename := func(fields map[string]interface{}, key string, kind string) {
+		if name, ok := fields[key].(string); ok {
+			if renamed, ok := renames[kind+"/"+name]; ok {
+				fields[key] = renamed
+			}
+		}
+	}
+
+	rename(spec, "serviceAccountName", "ServiceAccount")
+	for _, secret := range nestedMaps(spec, "imagePullSecrets") {
+		rename(secret, "name", "Secret")
+	}
+
+	for _, volume := range nestedMaps(spec, "volumes") {
+		if source, ok := volume["configMap"].(map[string]interface{}); ok {
+			rename(source, "name", "ConfigMap")
+		}
+		if source, ok := volume["secret"].(map[string]interface{}); ok {
+			rename(source, "secretName", "Secret")
+		}
+		if source, ok := volume["persistentVolumeClaim"].(map[string]interface{}); ok {
+			rename(source, "claimName", "PersistentVolumeClaim")
+		}
+	}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		for _, container := range nestedMaps(spec, field) {
+			for _, source := range nestedMaps(container, "envFrom") {
+				if ref, ok := source["configMapRef"].(map[string]interface{}); ok {
+					rename(ref, "name", "ConfigMap")
+				}
+				if ref, ok := source["secretRef"].(map[string]interface{}); ok {
+					rename(ref, "name", "Secret")
+				}
+			}
+
+			for _, env := range nestedMaps(container, "env") {
+				from, ok := env["valueFrom"].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if ref, ok := from["configMapKeyRef"].(map[string]interface{}); ok {
+					rename(ref, "name", "ConfigMap")
+				}
+				if ref, ok := from["secretKeyRef"].(map[string]interface{}); ok {
+					rename(ref, "name", "Secret")
+				}
+			}
+		}
+	}
+}
+
+// nestedMaps returns the maps of the list found under the key of the fields.
+// The maps are returned by reference so that they can be modified in place.
+func nestedMaps(fields map[string]interface{}, key string) []map[string]interface{} {
+	items, _ := fields[key].([]interface{})
+	maps := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if item, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, item)
+		}
+	}
+	return maps
+}
@@ -0,0 +1,320 @@
+package releases
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type OverlaySuite struct {
+	suite.Suite
+	Manifests []runtime.Object
+}
+
+func (r *OverlaySuite) SetupTest() {
+	r.Manifests = []runtime.Object{
+		&v1core.Namespace{
+			TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+		},
+		&v1core.ConfigMap{
+			TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: v1meta.ObjectMeta{Name: "settings", Namespace: "unittest"},
+			Data:       map[string]string{"level": "debug"},
+		},
+		&v1core.Service{
+			TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: v1meta.ObjectMeta{Name: "api", Namespace: "unittest"},
+			Spec:       v1core.ServiceSpec{Selector: map[string]string{"app": "api"}},
+		},
+		&v1apps.Deployment{
+			TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: v1meta.ObjectMeta{Name: "api", Namespace: "unittest"},
+			Spec: v1apps.DeploymentSpec{
+				Replicas: &[]int32{1}[0],
+				Selector: &v1meta.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				Template: v1core.PodTemplateSpec{
+					ObjectMeta: v1meta.ObjectMeta{Labels: map[string]string{"app": "api"}},
+					Spec: v1core.PodSpec{
+						InitContainers: []v1core.Container{{Name: "migrate", Image: "registry.local:5000/api:1.0.0"}},
+						Containers: []v1core.Container{{
+							Name:  "api",
+							Image: "registry.local:5000/api:1.0.0",
+							EnvFrom: []v1core.EnvFromSource{{
+								ConfigMapRef: &v1core.ConfigMapEnvSource{
+									LocalObjectReference: v1core.LocalObjectReference{Name: "settings"},
+								},
+							}},
+						}, {
+							Name:  "proxy",
+							Image: "nginx",
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *OverlaySuite) TestApplyOverlay() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		overlay    types.Overlay
+		names      []string
+		fields     map[string]interface{}
+	}{
+		{
+			false, "",
+			types.Overlay{},
+			[]string{"Namespace//unittest", "ConfigMap/unittest/settings", "Service/unittest/api", "Deployment/unittest/api"},
+			map[string]interface{}{
+				"3:spec.replicas": int64(1),
+			},
+		},
+		{
+			false, "",
+			types.Overlay{
+				Namespace:  "staging",
+				NamePrefix: "staging-",
+				NameSuffix: "-v1",
+			},
+			[]string{"Namespace//unittest", "ConfigMap/staging/staging-settings-v1", "Service/staging/staging-api-v1", "Deployment/staging/staging-api-v1"},
+			map[string]interface{}{
+				"3:spec.template.spec.containers.0.envFrom.0.configMapRef.name": "staging-settings-v1",
+			},
+		},
+		{
+			false, "",
+			types.Overlay{
+				CommonLabels: map[string]string{"env": "staging"},
+			},
+			[]string{"Namespace//unittest", "ConfigMap/unittest/settings", "Service/unittest/api", "Deployment/unittest/api"},
+			map[string]interface{}{
+				"0:metadata.labels.env":                      "staging",
+				"2:spec.selector.env":                        "staging",
+				"2:spec.selector.app":                        "api",
+				"3:spec.selector.matchLabels.env":            "staging",
+				"3:spec.template.metadata.labels.env":        "staging",
+				"3:spec.template.metadata.labels.app":        "api",
+				"3:metadata.labels.env":                      "staging",
+				"1:data.level":                               "debug",
+				"3:spec.template.spec.containers.1.image":    "nginx",
+				"3:spec.template.spec.initContainers.0.name": "migrate",
+			},
+		},
+		{
+			false, "",
+			types.Overlay{
+				Images: []types.Image{
+					{Name: "registry.local:5000/api", NewTag: "1.4.0"},
+					{Name: "nginx", NewName: "registry.local:5000/nginx", Digest: "sha256:abc"},
+				},
+			},
+			[]string{"Namespace//unittest", "ConfigMap/unittest/settings", "Service/unittest/api", "Deployment/unittest/api"},
+			map[string]interface{}{
+				"3:spec.template.spec.initContainers.0.image": "registry.local:5000/api:1.4.0",
+				"3:spec.template.spec.containers.0.image":     "registry.local:5000/api:1.4.0",
+				"3:spec.template.spec.containers.1.image":     "registry.local:5000/nginx@sha256:abc",
+			},
+		},
+		{
+			false, "",
+			types.Overlay{
+				Namespace: "staging",
+				PatchesStrategicMerge: []string{"" +
+					"apiVersion: apps/v1\n" +
+					"kind: Deployment\n" +
+					"metadata:\n" +
+					"  name: api\n" +
+					"spec:\n" +
+					"  replicas: 3\n" +
+					"  template:\n" +
+					"    spec:\n" +
+					"      containers:\n" +
+					"      - name: proxy\n" +
+					"        image: nginx:1.19\n",
+				},
+				PatchesJSON6902: []types.JSON6902Patch{
+					{
+						Target: types.PatchTarget{Version: "v1", Kind: "ConfigMap", Name: "settings"},
+						Patch:  "- op: replace\n  path: /data/level\n  value: info\n",
+					},
+				},
+			},
+			[]string{"Namespace//unittest", "ConfigMap/staging/settings", "Service/staging/api", "Deployment/staging/api"},
+			map[string]interface{}{
+				"1:data.level":    "info",
+				"3:spec.replicas": int64(3),
+				"3:spec.template.spec.containers.0.image": "registry.local:5000/api:1.0.0",
+				"3:spec.template.spec.containers.1.image": "nginx:1.19",
+			},
+		},
+		{
+			true, `strategic merge patch for Deployment "missing" matches no manifest`,
+			types.Overlay{
+				PatchesStrategicMerge: []string{"{\"apiVersion\": \"apps/v1\", \"kind\": \"Deployment\", \"metadata\": {\"name\": \"missing\"}}"},
+			},
+			nil,
+			nil,
+		},
+		{
+			true, `json patch for ConfigMap "missing" matches no manifest`,
+			types.Overlay{
+				PatchesJSON6902: []types.JSON6902Patch{
+					{
+						Target: types.PatchTarget{Kind: "ConfigMap", Name: "missing"},
+						Patch:  "[]",
+					},
+				},
+			},
+			nil,
+			nil,
+		},
+		{
+			true, `failed to patch ConfigMap "settings" (Unable to remove nonexistent key: missing)`,
+			types.Overlay{
+				PatchesJSON6902: []types.JSON6902Patch{
+					{
+						Target: types.PatchTarget{Kind: "ConfigMap", Name: "settings"},
+						Patch:  `[{"op": "remove", "path": "/data/missing"}]`,
+					},
+				},
+			},
+			nil,
+			nil,
+		},
+	}
+
+	for i, testCase := range testCases {
+		manifests, err := applyOverlay(&testCase.overlay, r.Manifests)
+		if testCase.shouldFail {
+			assert.EqualError(r.T(), err, testCase.onFail, "testCase: %d", i)
+			continue
+		}
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		names := make([]string, 0, len(manifests))
+		for _, manifest := range manifests {
+			kind, name, namespace := describeManifest(manifest, "")
+			names = append(names, kind+"/"+namespace+"/"+name)
+		}
+		assert.Equal(r.T(), testCase.names, names, "testCase: %d", i)
+
+		for key, expected := range testCase.fields {
+			parts := strings.SplitN(key, ":", 2)
+			index, _ := strconv.Atoi(parts[0])
+			value := lookupField(manifests[index].(*unstructured.Unstructured).Object, parts[1])
+			assert.Equal(r.T(), expected, value, "testCase: %d, field: %s", i, key)
+		}
+	}
+
+	// the base manifests are left untouched.
+	assert.Equal(r.T(), "settings", r.Manifests[1].(*v1core.ConfigMap).Name)
+	assert.Equal(r.T(), "debug", r.Manifests[1].(*v1core.ConfigMap).Data["level"])
+}
+
+func (r *OverlaySuite) TestRenderReleases() {
+	d := testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Overlay:   &types.Overlay{NameSuffix: "-staging"},
+		Releases: &types.Releases{
+			{
+				Name:      "unittest",
+				Version:   semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: r.Manifests,
+			},
+		},
+	}.Build()
+
+	releases, err := renderReleases(d, nil, nil)
+	assert.Nil(r.T(), err)
+	_, name, _ := describeManifest(releases[0].Manifests[1], "")
+	assert.Equal(r.T(), "settings-staging", name)
+
+	d.(driver.WithOverlay).Overlay().PatchesStrategicMerge = []string{"- invalid"}
+	_, err = renderReleases(d, nil, nil)
+	assert.EqualError(r.T(), err, ""+
+		`failed to apply overlay onto release "unittest" version 0.0.1: invalid strategic merge patch `+
+		`(json: cannot unmarshal array into Go value of type map[string]interface {})`)
+}
+
+func (r *OverlaySuite) TestRenderReleasesTwice() {
+	d := testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Overlay: &types.Overlay{
+			NamePrefix:   "dev-",
+			CommonLabels: map[string]string{"env": "dev"},
+			PatchesJSON6902: []types.JSON6902Patch{
+				{
+					Target: types.PatchTarget{Kind: "Deployment", Name: "api"},
+					Patch: "" +
+						"- op: add\n" +
+						"  path: /spec/template/spec/containers/-\n" +
+						"  value: {name: sidecar, image: busybox}\n",
+				},
+			},
+		},
+		Releases: &types.Releases{
+			{
+				Name:      "unittest",
+				Version:   semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: r.Manifests,
+			},
+		},
+	}.Build()
+
+	for i := 0; i < 2; i++ {
+		releases, err := renderReleases(d, nil, nil)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		obj := releases[0].Manifests[3].(*unstructured.Unstructured)
+		assert.Equal(r.T(), "dev-api", obj.GetName(), "testCase: %d", i)
+		assert.Equal(r.T(), map[string]string{"env": "dev"}, obj.GetLabels(), "testCase: %d", i)
+		assert.Equal(r.T(), "dev-settings", lookupField(obj.Object, "spec.template.spec.containers.0.envFrom.0.configMapRef.name"), "testCase: %d", i)
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		assert.Len(r.T(), containers, 3, "testCase: %d", i)
+	}
+
+	deployment := (*d.Releases())[0].Manifests[3].(*v1apps.Deployment)
+	assert.Equal(r.T(), "api", deployment.Name)
+	assert.Len(r.T(), deployment.Spec.Template.Spec.Containers, 2)
+}
+
+// lookupField returns the value found at the dot separated path of the object
+// where numeric path elements index into lists.
+func lookupField(object interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		switch current := object.(type) {
+		case map[string]interface{}:
+			object = current[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index >= len(current) {
+				return nil
+			}
+			object = current[index]
+		default:
+			return nil
+		}
+	}
+	return object
+}
+
+func TestOverlaySuite(t *testing.T) {
+	suite.Run(t, new(OverlaySuite))
+}
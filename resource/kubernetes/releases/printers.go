@@ -0,0 +1,328 @@
+package releases
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1ext "k8s.io/api/extensions/v1beta1"
+	v1policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// printer renders the describe columns of a single kind of cluster object.
+// Objects are converted onto the typed object returned by New before being
+// passed to Rows.
+type printer struct {
+	Header []string
+	New    func() runtime.Object
+	Rows   func(obj runtime.Object) [][]string
+}
+
+// printers defines the describe columns of the well known kinds. Any other
+// kind, including custom resources, is rendered by the generic printer.
+var printers = map[schema.GroupKind]printer{
+	{Group: "", Kind: "Namespace"}: {
+		Header: []string{"NAME", "STATUS", "AGE"},
+		New:    func() runtime.Object { return &v1core.Namespace{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.Namespace)
+			return [][]string{{result.Name, string(result.Status.Phase), age(result)}}
+		},
+	},
+	{Group: "", Kind: "Pod"}: {
+		Header: []string{"NAMESPACE", "NAME", "READY", "STATUS", "RESTARTS", "AGE"},
+		New:    func() runtime.Object { return &v1core.Pod{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.Pod)
+			readyCount := 0
+			restartCount := 0
+			for _, stat := range result.Status.ContainerStatuses {
+				restartCount += int(stat.RestartCount)
+				if stat.Ready {
+					readyCount++
+				}
+			}
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				fmt.Sprintf("%d/%d", readyCount, len(result.Status.ContainerStatuses)),
+				string(result.Status.Phase),
+				fmt.Sprintf("%d", restartCount),
+				age(result),
+			}}
+		},
+	},
+	{Group: "", Kind: "ServiceAccount"}: {
+		Header: []string{"NAMESPACE", "NAME", "SECRETS", "AGE"},
+		New:    func() runtime.Object { return &v1core.ServiceAccount{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.ServiceAccount)
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				fmt.Sprintf("%d", len(result.Secrets)),
+				age(result),
+			}}
+		},
+	},
+	{Group: "", Kind: "ConfigMap"}: {
+		Header: []string{"NAMESPACE", "NAME", "DATA", "AGE"},
+		New:    func() runtime.Object { return &v1core.ConfigMap{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.ConfigMap)
+			var rbytes []byte
+			for _, data := range result.Data {
+				rbytes = append(rbytes, []byte(data)...)
+			}
+			for _, binary := range result.BinaryData {
+				rbytes = append(rbytes, binary...)
+			}
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				fmt.Sprintf("%dB", len(rbytes)),
+				age(result),
+			}}
+		},
+	},
+	{Group: "", Kind: "Endpoints"}: {
+		Header: []string{"NAMESPACE", "NAME", "ENDPOINTS", "AGE"},
+		New:    func() runtime.Object { return &v1core.Endpoints{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.Endpoints)
+			endpoints := make([]string, 0)
+			for _, subset := range result.Subsets {
+				for _, address := range subset.Addresses {
+					for _, ports := range subset.Ports {
+						endpoints = append(endpoints, fmt.Sprintf("%s:%d", address.IP, ports.Port))
+					}
+				}
+			}
+			if len(endpoints) > 3 {
+				endpoints = append(endpoints[:3], fmt.Sprintf("+ %d more...", len(endpoints[3:])))
+			}
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				strings.Join(endpoints, ","),
+				age(result),
+			}}
+		},
+	},
+	{Group: "", Kind: "Service"}: {
+		Header: []string{"NAMESPACE", "NAME", "TYPE", "CLUSTER-IP", "EXTERNAL-IP", "PORT(s)", "AGE"},
+		New:    func() runtime.Object { return &v1core.Service{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.Service)
+			externalIP := "<none>"
+			if len(result.Status.LoadBalancer.Ingress) > 0 {
+				externalIP = result.Status.LoadBalancer.Ingress[0].IP
+			}
+
+			var ports []string
+			for _, port := range result.Spec.Ports {
+				portStr := fmt.Sprintf("%d", port.Port)
+				if port.NodePort != 0 {
+					portStr += fmt.Sprintf(":%d", port.NodePort)
+				}
+				portStr += "/" + string(port.Protocol)
+				ports = append(ports, portStr)
+			}
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				string(result.Spec.Type),
+				result.Spec.ClusterIP,
+				externalIP,
+				strings.Join(ports, ","),
+				age(result),
+			}}
+		},
+	},
+	{Group: "", Kind: "Secret"}: {
+		Header: []string{"NAMESPACE", "NAME", "TYPE", "DATA", "AGE"},
+		New:    func() runtime.Object { return &v1core.Secret{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1core.Secret)
+			var rbytes []byte
+			for _, data := range result.StringData {
+				rbytes = append(rbytes, []byte(data)...)
+			}
+			for _, binary := range result.Data {
+				rbytes = append(rbytes, binary...)
+			}
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				string(result.Type),
+				fmt.Sprintf("%dB", len(rbytes)),
+				age(result),
+			}}
+		},
+	},
+	{Group: "policy", Kind: "PodSecurityPolicy"}: {
+		Header: []string{"NAME", "PRIV", "CAPS", "VOLUMES"},
+		New:    func() runtime.Object { return &v1policy.PodSecurityPolicy{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1policy.PodSecurityPolicy)
+			var caps []string
+			for _, capability := range result.Spec.AllowedCapabilities {
+				caps = append(caps, string(capability))
+			}
+
+			var volumes []string
+			for _, volume := range result.Spec.Volumes {
+				volumes = append(volumes, string(volume))
+			}
+			return [][]string{{
+				result.Name,
+				strconv.FormatBool(result.Spec.Privileged),
+				strings.Join(caps, ","),
+				strings.Join(volumes, ","),
+			}}
+		},
+	},
+	{Group: "apps", Kind: "DaemonSet"}: {
+		Header: []string{"NAMESPACE", "NAME", "DESIRED", "CURRENT", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"},
+		New:    func() runtime.Object { return &v1apps.DaemonSet{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1apps.DaemonSet)
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				fmt.Sprintf("%d", result.Status.DesiredNumberScheduled),
+				fmt.Sprintf("%d", result.Status.CurrentNumberScheduled),
+				fmt.Sprintf("%d", result.Status.NumberReady),
+				fmt.Sprintf("%d", result.Status.UpdatedNumberScheduled),
+				fmt.Sprintf("%d", result.Status.NumberAvailable),
+				age(result),
+			}}
+		},
+	},
+	{Group: "apps", Kind: "Deployment"}: {
+		Header: []string{"NAMESPACE", "NAME", "READY", "UP-TO-DATE", "AVAILABLE", "AGE"},
+		New:    func() runtime.Object { return &v1apps.Deployment{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1apps.Deployment)
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				fmt.Sprintf("%d/%d", result.Status.ReadyReplicas, result.Status.Replicas),
+				fmt.Sprintf("%d", result.Status.UpdatedReplicas),
+				fmt.Sprintf("%d", result.Status.AvailableReplicas),
+				age(result),
+			}}
+		},
+	},
+	{Group: "apps", Kind: "StatefulSet"}: {
+		Header: []string{"NAMESPACE", "NAME", "DESIRED", "CURRENT", "AGE"},
+		New:    func() runtime.Object { return &v1apps.StatefulSet{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*v1apps.StatefulSet)
+			return [][]string{{
+				result.Namespace,
+				result.Name,
+				fmt.Sprintf("%d", result.Status.Replicas),
+				fmt.Sprintf("%d", result.Status.ReadyReplicas),
+				age(result),
+			}}
+		},
+	},
+	{Group: "extensions", Kind: "Ingress"}:        ingressPrinter,
+	{Group: "networking.k8s.io", Kind: "Ingress"}: ingressPrinter,
+}
+
+// ingressPrinter renders a row for every path of every rule of an ingress.
+var ingressPrinter = printer{
+	Header: []string{"NAMESPACE", "NAME", "HOST", "PATH", "PORT", "AGE"},
+	New:    func() runtime.Object { return &v1ext.Ingress{} },
+	Rows: func(obj runtime.Object) [][]string {
+		result := obj.(*v1ext.Ingress)
+		namespace := result.Namespace
+		name := result.Name
+		created := age(result)
+
+		rows := make([][]string, 0)
+		for i := range result.Spec.Rules {
+			if i != 0 {
+				namespace = ""
+				name = ""
+				created = ""
+			}
+
+			if result.Spec.Rules[i].HTTP == nil {
+				continue
+			}
+
+			host := result.Spec.Rules[i].Host
+			for j := range result.Spec.Rules[i].HTTP.Paths {
+				if j != 0 {
+					host = ""
+				}
+				path := result.Spec.Rules[i].HTTP.Paths[j].Path
+				if path == "" {
+					path = "/"
+				}
+				port := result.Spec.Rules[i].HTTP.Paths[j].Backend.ServicePort.String()
+				rows = append(rows, []string{namespace, name, host, path, port, created})
+			}
+		}
+		return rows
+	},
+}
+
+// lookupPrinter returns the printer of the kind. Kinds without a printer of
+// their own are rendered with the name and age columns only.
+func lookupPrinter(gk schema.GroupKind, namespaced bool) printer {
+	if p, ok := printers[gk]; ok {
+		return p
+	}
+
+	header := []string{"NAME", "AGE"}
+	if namespaced {
+		header = []string{"NAMESPACE", "NAME", "AGE"}
+	}
+	return printer{
+		Header: header,
+		New:    func() runtime.Object { return &unstructured.Unstructured{} },
+		Rows: func(obj runtime.Object) [][]string {
+			result := obj.(*unstructured.Unstructured)
+			if namespaced {
+				return [][]string{{result.GetNamespace(), result.GetName(), age(result)}}
+			}
+			return [][]string{{result.GetName(), age(result)}}
+		},
+	}
+}
+
+// render converts the cluster object onto the typed object of the printer
+// and returns the rendered rows.
+func (r printer) render(obj *unstructured.Unstructured) ([][]string, error) {
+	typed := r.New()
+	if result, ok := typed.(*unstructured.Unstructured); ok {
+		result.Object = obj.Object
+		return r.Rows(result), nil
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return nil, err
+	}
+	return r.Rows(typed), nil
+}
+
+// age returns the time passed since the object was created in minutes.
+func age(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	diffTime := time.Since(accessor.GetCreationTimestamp().Time)
+	return fmt.Sprintf("%.2fm", diffTime.Minutes())
+}
@@ -0,0 +1,192 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/blang/semver"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// latestReleases returns the newest selected version of every release name.
+// Pruning happens only after the newest version was applied so that objects
+// of newer versions are not deleted and recreated when applying a range of
+// versions at once.
+func latestReleases(releases types.Releases, opts applyOptions) map[string]*types.Release {
+	latest := make(map[string]*types.Release)
+	for _, rel := range releases {
+		if opts.Name != "" && rel.Name != opts.Name ||
+			(!opts.Version.EQ(semver.Version{}) &&
+				!rel.Version.Equals(opts.Version)) {
+			continue
+		}
+
+		if current, ok := latest[rel.Name]; !ok || current.Version.LT(rel.Version) {
+			latest[rel.Name] = rel
+		}
+	}
+	return latest
+}
+
+// pruneManifests returns the labelled cluster objects of the release which
+// are no longer part of its manifests, in the order in which they are
+// deleted. Only the kinds and namespaces used by any registered version of
+// the release are searched since no other objects could have been applied.
+func pruneManifests(
+	ctx context.Context,
+	cluster *Cluster,
+	releases types.Releases,
+	rel *types.Release,
+	resource string,
+) ([]runtime.Object, error) {
+	wanted := make(map[string]bool)
+	for _, manifest := range rel.Manifests {
+		_, obj, err := cluster.Resource(manifest)
+		if err != nil {
+			return nil, err
+		}
+		wanted[objectKey(obj)] = true
+	}
+
+	scopes := make(map[string]dynamic.ResourceInterface)
+	for _, current := range releases {
+		if current.Name != rel.Name {
+			continue
+		}
+
+		for _, manifest := range current.Manifests {
+			if !matchResource(manifest, resource) {
+				continue
+			}
+
+			client, obj, err := cluster.Resource(manifest)
+			if err != nil {
+				return nil, err
+			}
+			scopes[obj.GetAPIVersion()+"/"+obj.GetKind()+"/"+obj.GetNamespace()] = client
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{
+		ManagedByLabel: FieldManager,
+		InstanceLabel:  rel.Name,
+	}).String()
+	seen := make(map[string]bool)
+	prune := make([]runtime.Object, 0)
+	for _, client := range scopes {
+		list, err := client.List(ctx, v1meta.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range list.Items {
+			key := objectKey(&list.Items[i])
+			if wanted[key] || seen[key] {
+				continue
+			}
+			seen[key] = true
+			prune = append(prune, &list.Items[i])
+		}
+	}
+
+	// scopes are visited in random order therefore objects are sorted by
+	// their identity before being ordered by their kind priorities.
+	sort.Slice(prune, func(i, j int) bool {
+		return objectKey(prune[i].(*unstructured.Unstructured)) < objectKey(prune[j].(*unstructured.Unstructured))
+	})
+	return reverseManifests(prune)
+}
+
+// pruneRelease deletes the cluster objects of the release which are no longer
+// part of its manifests.
+func pruneRelease(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	d interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	},
+	releases types.Releases,
+	rel *types.Release,
+	opts applyOptions,
+) error {
+	manifests, err := pruneManifests(ctx, cluster, releases, rel, opts.Resource)
+	if err != nil {
+		return err
+	}
+
+	uninstall := Uninstall{Driver: d}
+	for _, manifest := range manifests {
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		changed, err := uninstall.uninstallManifest(ctx, cluster, manifest)
+		if rerr := report(ctx, out, rel, manifest, events.StatusPruned, changed, start, err); rerr != nil {
+			return rerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tryPrune prints the cluster objects which would be pruned after applying
+// the selected releases.
+func tryPrune(
+	ctx context.Context,
+	out io.Writer,
+	d interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	},
+	releases types.Releases,
+	opts applyOptions,
+) error {
+	cluster, err := LookupCluster(ctx, d, *d.Namespace())
+	if err != nil {
+		return err
+	}
+
+	sort.Sort(releases)
+	latest := latestReleases(releases, opts)
+	for _, rel := range releases {
+		if latest[rel.Name] != rel {
+			continue
+		}
+
+		manifests, err := pruneManifests(ctx, cluster, releases, rel, opts.Resource)
+		if err != nil {
+			return err
+		}
+
+		for _, manifest := range manifests {
+			obj := manifest.(*unstructured.Unstructured)
+			fmt.Fprintf(out, "prune %s/%s %s\n", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+		}
+	}
+	return nil
+}
+
+// objectKey returns the identity of the cluster object regardless of the api
+// group version it was retrieved through.
+func objectKey(obj *unstructured.Unstructured) string {
+	return obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,172 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1core "k8s.io/api/core/v1"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type PruneSuite struct {
+	suite.Suite
+	Cluster *Cluster
+	Driver  interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *PruneSuite) SetupTest() {
+	r.Cluster = newFakeCluster()
+	r.Driver = testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases: &types.Releases{
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: []runtime.Object{
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "settings"},
+					},
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "legacy"},
+					},
+					&v1core.Service{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "legacy"},
+					},
+				},
+			},
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 2, Build: []string{"build", "1"}},
+				Manifests: []runtime.Object{
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "settings"},
+					},
+				},
+			},
+			{
+				Name:    "other",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: []runtime.Object{
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "other"},
+					},
+				},
+			},
+		},
+	}.Build()
+}
+
+func (r *PruneSuite) TestLatestReleases() {
+	releases := *r.Driver.Releases()
+	testCases := []struct {
+		opts   applyOptions
+		latest map[string]*types.Release
+	}{
+		{
+			applyOptions{},
+			map[string]*types.Release{"unittest": releases[1], "other": releases[2]},
+		},
+		{
+			applyOptions{Name: "unittest", Version: releases[0].Version},
+			map[string]*types.Release{"unittest": releases[0]},
+		},
+		{
+			applyOptions{Name: "missing"},
+			map[string]*types.Release{},
+		},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(r.T(), testCase.latest, latestReleases(releases, testCase.opts), "testCase: %d", i)
+	}
+}
+
+func (r *PruneSuite) TestPrune() {
+	ctx := context.Background()
+	releases := *r.Driver.Releases()
+	for _, rel := range releases {
+		assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, rel, applyOptions{}, events.StatusInstalled))
+	}
+
+	foreign := &v1core.ConfigMap{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "foreign"},
+	}
+	resource, obj, err := r.Cluster.Resource(foreign)
+	assert.Nil(r.T(), err)
+	_, err = resource.Create(ctx, obj, v1meta.CreateOptions{})
+	assert.Nil(r.T(), err)
+
+	resource, obj, err = r.Cluster.Resource(releases[1].Manifests[0])
+	assert.Nil(r.T(), err)
+	settings, err := resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), map[string]string{
+		ManagedByLabel: "migrate",
+		InstanceLabel:  "unittest",
+		VersionLabel:   "0.0.2_build.1",
+	}, settings.GetLabels())
+	assert.Regexp(r.T(), "^sha256:[0-9a-f]{64}$", settings.GetAnnotations()[ManifestHashAnnotation])
+
+	testCases := []struct {
+		rel      *types.Release
+		resource string
+		pruned   []string
+	}{
+		{releases[1], "", []string{"Service/unittest/legacy", "ConfigMap/unittest/legacy"}},
+		{releases[1], "configmap", []string{"ConfigMap/unittest/legacy"}},
+		{releases[0], "", []string{}},
+		{releases[2], "", []string{}},
+	}
+
+	for i, testCase := range testCases {
+		manifests, err := pruneManifests(ctx, r.Cluster, releases, testCase.rel, testCase.resource)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		pruned := make([]string, 0)
+		for _, manifest := range manifests {
+			kind, name, namespace := describeManifest(manifest, "")
+			pruned = append(pruned, kind+"/"+namespace+"/"+name)
+		}
+		assert.Equal(r.T(), testCase.pruned, pruned, "testCase: %d", i)
+	}
+
+	assert.Nil(r.T(), pruneRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Driver, releases, releases[1], applyOptions{}))
+	for i, manifest := range []runtime.Object{releases[0].Manifests[1], releases[0].Manifests[2]} {
+		resource, obj, err := r.Cluster.Resource(manifest)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		_, err = resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+		assert.True(r.T(), v1err.IsNotFound(err), "testCase: %d", i)
+	}
+
+	for i, manifest := range []runtime.Object{foreign, releases[1].Manifests[0], releases[2].Manifests[0]} {
+		resource, obj, err := r.Cluster.Resource(manifest)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		_, err = resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+		assert.Nil(r.T(), err, "testCase: %d", i)
+	}
+}
+
+func TestPruneSuite(t *testing.T) {
+	suite.Run(t, new(PruneSuite))
+}
@@ -0,0 +1,397 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// Rollback represents the cluster release rollback command object.
+type Rollback struct {
+	Driver interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+// rollbackOptions is used for executing the run() command.
+type rollbackOptions struct {
+	Name           string `json:"name" yaml:"name"`
+	Target         string `json:"target" yaml:"target"`
+	Try            bool   `json:"try" yaml:"try"`
+	ForceConflicts bool   `json:"forceConflicts" yaml:"forceConflicts"`
+}
+
+// rollbackPlan represents the change set of rolling a release back from one
+// version onto another.
+type rollbackPlan struct {
+	From    *types.Release
+	To      *types.Release
+	Apply   []runtime.Object
+	Destroy []runtime.Object
+}
+
+var _ interface {
+	types.Resource
+	types.Command
+} = new(Rollback)
+
+// NewCommand creates a new cobra.Command, configures it and returns it.
+func (r Rollback) NewCommand(ctx context.Context, name string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   name[strings.LastIndex(name, ".")+1:] + " NAME [REVISION|VERSION]",
+		Short: "Rolls a release back onto a previous revision.",
+		Long:  "Rolls a release back onto a previous revision",
+		Args:  require.Args(r.validation),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
+			if patches, ok := r.Driver.(driver.WithPatches); ok {
+				for _, patch := range *patches.Patches(name) {
+					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
+						return err
+					}
+				}
+			}
+
+			files, _ := cmd.Flags().GetStringArray("values")
+			sets, _ := cmd.Flags().GetStringArray("set")
+			releases, err := renderReleases(r.Driver, files, sets)
+			if err != nil {
+				return err
+			}
+
+			var target string
+			if len(args) > 1 {
+				target = args[1]
+			}
+
+			try, _ := cmd.Flags().GetBool("try")
+			forceConflicts, _ := cmd.Flags().GetBool("force-conflicts")
+			opts := rollbackOptions{
+				Name:           args[0],
+				Target:         target,
+				Try:            try,
+				ForceConflicts: forceConflicts,
+			}
+			return r.run(ctx, cmd.OutOrStdout(), releases, opts)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.SetUsageTemplate(global.DefaultUsageTemplate)
+	cmd.SetHelpCommand(&cobra.Command{Hidden: true})
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.Bool(
+		"try", false,
+		"Simulates and prints resource execution parameters.",
+	)
+	flags.Bool(
+		"force-conflicts", false,
+		"Takes ownership of fields managed by other field managers.",
+	)
+	flags.StringArray(
+		"values", nil,
+		"Reads release values from a yaml `FILE`.",
+	)
+	flags.StringArray(
+		"set", nil,
+		"Overrides a single release value with `KEY=VALUE`.",
+	)
+	flags.Bool("help", false, "Show help information.")
+	return cmd
+}
+
+// Execute runs the command.
+func (r Rollback) Execute(name string, out io.Writer, args []string) error {
+	wrap := types.Executor{Name: name, Command: r}
+	ctx := context.WithValue(context.Background(), global.RefRoot, wrap)
+	cmd := r.NewCommand(ctx, name)
+	cmd.SetOut(out)
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validation represents a sequence of positional argument validation steps.
+func (r Rollback) validation(cmd *cobra.Command, args []string) error {
+	if err := require.MinArgs(args, 1); err != nil {
+		return err
+	}
+
+	if err := require.MaxArgs(args, 2); err != nil {
+		return err
+	}
+	return nil
+}
+
+// run is a starting point method for executing the cluster release rollback
+// command.
+func (r Rollback) run(ctx context.Context, out io.Writer, releases types.Releases, opts rollbackOptions) error {
+	records, err := openReleases(ctx, r.Driver)
+	if err != nil {
+		return err
+	}
+
+	// the lock is held from planning until the revision is recorded so
+	// that the rollback never acts on revisions recorded meanwhile. The
+	// namespace is ensured first since the lock may be kept in secrets of
+	// the namespace.
+	var cluster *Cluster
+	if !opts.Try {
+		if cluster, err = GetCluster(ctx, r.Driver, *r.Driver.Namespace()); err != nil {
+			return err
+		}
+
+		unlock, err := lockReleases(ctx, records)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	plan, err := r.plan(ctx, records, releases, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Try {
+		fmt.Fprintf(out, "==> release %q (%s -> %s)\n",
+			plan.To.Name,
+			plan.From.Version.String(),
+			plan.To.Version.String(),
+		)
+		for _, manifest := range plan.Apply {
+			kind, name, namespace := describeManifest(manifest, *r.Driver.Namespace())
+			fmt.Fprintf(out, "apply %s/%s %s\n", kind, name, namespace)
+		}
+		for _, manifest := range plan.Destroy {
+			kind, name, namespace := describeManifest(manifest, *r.Driver.Namespace())
+			fmt.Fprintf(out, "delete %s/%s %s\n", kind, name, namespace)
+		}
+		return nil
+	}
+
+	err = r.rollbackRelease(ctx, out, cluster, plan, opts)
+
+	status := state.StatusDeployed
+	if err != nil {
+		status = state.StatusFailed
+	}
+	if rerr := recordRelease(ctx, records, plan.To, status); rerr != nil {
+		return rerr
+	}
+	if err != nil {
+		return err
+	}
+
+	if !events.JSONL(ctx) {
+		fmt.Fprintf(out, "release %q successfully rolled back (%s -> %s)\n",
+			plan.To.Name,
+			plan.From.Version.String(),
+			plan.To.Version.String(),
+		)
+	}
+	return nil
+}
+
+// plan resolves the currently deployed and the target versions of the release
+// and computes the change set between them. Without an explicit target the
+// release is rolled back onto the latest superseded revision.
+func (r Rollback) plan(
+	ctx context.Context,
+	records state.ReleaseStore,
+	releases types.Releases,
+	opts rollbackOptions,
+) (*rollbackPlan, error) {
+	recorded, err := records.Releases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]state.Release, 0)
+	for _, release := range recorded {
+		if release.Name == opts.Name {
+			revisions = append(revisions, release)
+		}
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("release %q not found", opts.Name)
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+
+	current := -1
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if revisions[i].Status == state.StatusDeployed {
+			current = i
+			break
+		}
+	}
+
+	if current < 0 {
+		return nil, fmt.Errorf("release %q is not deployed", opts.Name)
+	}
+
+	var version string
+	if opts.Target == "" {
+		for i := current - 1; i >= 0; i-- {
+			if revisions[i].Status == state.StatusSuperseded {
+				version = revisions[i].Version
+				break
+			}
+		}
+
+		if version == "" {
+			return nil, fmt.Errorf("release %q has no previous revision", opts.Name)
+		}
+	} else if revision, err := strconv.Atoi(opts.Target); err == nil {
+		for _, record := range revisions {
+			if record.Revision == revision {
+				version = record.Version
+				break
+			}
+		}
+
+		if version == "" {
+			return nil, fmt.Errorf("release %q revision %d not found", opts.Name, revision)
+		}
+	} else {
+		tag, err := semver.Parse(opts.Target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid revision or version %q", opts.Target)
+		}
+		version = tag.String()
+	}
+
+	from, err := r.lookup(releases, opts.Name, revisions[current].Version)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := r.lookup(releases, opts.Name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	apply, err := sortManifests(to.Manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := *r.Driver.Namespace()
+	wanted := make(map[string]bool)
+	for _, manifest := range apply {
+		wanted[manifestKey(manifest, fallback)] = true
+	}
+
+	// objects of the newer version are deleted in reverse order so that
+	// dependents go before the objects they depend on.
+	manifests, err := reverseManifests(from.Manifests)
+	if err != nil {
+		return nil, err
+	}
+
+	destroy := make([]runtime.Object, 0)
+	for _, manifest := range manifests {
+		if !wanted[manifestKey(manifest, fallback)] {
+			destroy = append(destroy, manifest)
+		}
+	}
+
+	return &rollbackPlan{
+		From:    from,
+		To:      to,
+		Apply:   apply,
+		Destroy: destroy,
+	}, nil
+}
+
+// lookup returns the release with the specified name and version from the
+// rendered releases.
+func (r Rollback) lookup(releases types.Releases, name, version string) (*types.Release, error) {
+	for _, rel := range releases {
+		if rel.Name == name && rel.Version.String() == version {
+			return rel, nil
+		}
+	}
+	return nil, fmt.Errorf("release %q version %s not found", name, version)
+}
+
+// rollbackRelease applies the manifests of the target version and deletes the
+// objects which exist only in the newer version.
+func (r Rollback) rollbackRelease(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	plan *rollbackPlan,
+	opts rollbackOptions,
+) (err error) {
+	ctx, end := startRelease(ctx, plan.To)
+	defer func() { end(err) }()
+
+	for _, manifest := range plan.Apply {
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		changed, err := applyManifest(ctx, cluster, plan.To, manifest, opts.ForceConflicts)
+		if rerr := report(ctx, out, plan.To, manifest, events.StatusApplied, changed, start, err); rerr != nil {
+			return rerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	uninstall := Uninstall{Driver: r.Driver}
+	for _, manifest := range plan.Destroy {
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		changed, err := uninstall.uninstallManifest(ctx, cluster, manifest)
+		if rerr := report(ctx, out, plan.From, manifest, events.StatusUninstalled, changed, start, err); rerr != nil {
+			return rerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestKey returns the identity of the manifest object within the cluster.
+func manifestKey(manifest runtime.Object, fallback string) string {
+	kind, name, namespace := describeManifest(manifest, fallback)
+	return kind + "/" + namespace + "/" + name
+}
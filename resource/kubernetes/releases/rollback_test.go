@@ -0,0 +1,156 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/state"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type RollbackSuite struct {
+	suite.Suite
+	Dir    string
+	State  state.Store
+	Driver interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *RollbackSuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+	r.State = state.NewFile(filepath.Join(dir, "state.json"))
+
+	service := &v1core.Service{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+	}
+
+	r.Driver = testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Releases: &types.Releases{
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: []runtime.Object{
+					service,
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+					},
+				},
+			},
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 2},
+				Manifests: []runtime.Object{
+					service,
+					&v1core.Secret{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+					},
+					&v1apps.Deployment{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "unittest", Namespace: "other"},
+					},
+				},
+			},
+		},
+		State: r.State,
+	}.Build()
+}
+
+func (r *RollbackSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *RollbackSuite) TestRollbackCommand() {
+	ctx := context.Background()
+	releases := *r.Driver.Releases()
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[0], state.StatusDeployed))
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[1], state.StatusDeployed))
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[1], state.StatusFailed))
+
+	plan := "==> release \"unittest\" (0.0.2 -> 0.0.1)\n" +
+		"apply ConfigMap/unittest unittest\n" +
+		"apply Service/unittest unittest\n" +
+		"delete Deployment/unittest other\n" +
+		"delete Secret/unittest unittest\n"
+
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		args       []string
+		output     string
+	}{
+		{false, "", []string{"unittest", "--try"}, plan},
+		{false, "", []string{"unittest", "1", "--try"}, plan},
+		{false, "", []string{"unittest", "0.0.1", "--try"}, plan},
+		{
+			false, "",
+			[]string{"unittest", "0.0.2", "--try"},
+			"==> release \"unittest\" (0.0.2 -> 0.0.2)\n" +
+				"apply Secret/unittest unittest\n" +
+				"apply Service/unittest unittest\n" +
+				"apply Deployment/unittest other\n",
+		},
+		{true, `release "missing" not found`, []string{"missing", "--try"}, ""},
+		{true, `release "unittest" revision 9 not found`, []string{"unittest", "9", "--try"}, ""},
+		{true, `release "unittest" version 0.0.9 not found`, []string{"unittest", "0.0.9", "--try"}, ""},
+		{true, `invalid revision or version "latest"`, []string{"unittest", "latest", "--try"}, ""},
+		{true, "requires at least 1 arg(s), received 0", []string{}, ""},
+		{true, "accepts at most 2 arg(s), received 3", []string{"unittest", "1", "2"}, ""},
+	}
+
+	for i, testCase := range testCases {
+		buffer := bytes.NewBuffer(nil)
+		err := Rollback{Driver: r.Driver}.Execute("rollback", buffer, testCase.args)
+		if testCase.shouldFail {
+			if assert.Error(r.T(), err, "testCase: %d %v", i, testCase.args) {
+				assert.Contains(r.T(), err.Error(), testCase.onFail, "testCase: %d %v", i, testCase.args)
+			}
+		} else {
+			assert.Nil(r.T(), err, "testCase: %d %v", i, testCase.args)
+			assert.Equal(r.T(), testCase.output, buffer.String(), "testCase: %d %v", i, testCase.args)
+		}
+	}
+}
+
+func (r *RollbackSuite) TestRollbackNotDeployed() {
+	ctx := context.Background()
+	releases := *r.Driver.Releases()
+	err := Rollback{Driver: r.Driver}.Execute("rollback", bytes.NewBuffer(nil), []string{"unittest", "--try"})
+	assert.EqualError(r.T(), err, `release "unittest" not found`)
+
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[0], state.StatusDeployed))
+	err = Rollback{Driver: r.Driver}.Execute("rollback", bytes.NewBuffer(nil), []string{"unittest", "--try"})
+	assert.EqualError(r.T(), err, `release "unittest" has no previous revision`)
+
+	assert.Nil(r.T(), recordRelease(ctx, r.State, releases[0], state.StatusUninstalled))
+	err = Rollback{Driver: r.Driver}.Execute("rollback", bytes.NewBuffer(nil), []string{"unittest", "--try"})
+	assert.EqualError(r.T(), err, `release "unittest" is not deployed`)
+}
+
+func TestRollbackSuite(t *testing.T) {
+	suite.Run(t, new(RollbackSuite))
+}
@@ -6,22 +6,22 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
-	v1apps "k8s.io/api/apps/v1"
-	v1core "k8s.io/api/core/v1"
-	v1ext "k8s.io/api/extensions/v1beta1"
-	v1policy "k8s.io/api/policy/v1beta1"
-	v1rbac "k8s.io/api/rbac/v1"
 	v1err "k8s.io/apimachinery/pkg/api/errors"
 	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
@@ -53,7 +53,10 @@ func (r Uninstall) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Stops a running release and removes the resources.",
 		Long:  "Stops a running release and removes the resources",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			if patches, ok := r.Driver.(driver.WithPatches); ok {
 				for _, patch := range *patches.Patches(name) {
 					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
@@ -62,6 +65,13 @@ func (r Uninstall) NewCommand(ctx context.Context, name string) *cobra.Command {
 				}
 			}
 
+			files, _ := cmd.Flags().GetStringArray("values")
+			sets, _ := cmd.Flags().GetStringArray("set")
+			releases, err := renderReleases(r.Driver, files, sets)
+			if err != nil {
+				return err
+			}
+
 			if try, _ := cmd.Flags().GetBool("try"); try {
 				rbytes, err := yaml.Marshal(r.Driver)
 				if err != nil {
@@ -99,7 +109,7 @@ func (r Uninstall) NewCommand(ctx context.Context, name string) *cobra.Command {
 				Resource: resource,
 			}
 
-			return r.run(ctx, cmd.OutOrStdout(), opts)
+			return r.run(ctx, cmd.OutOrStdout(), releases, opts)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -114,6 +124,14 @@ func (r Uninstall) NewCommand(ctx context.Context, name string) *cobra.Command {
 		"try", false,
 		"Simulates and prints resource execution parameters.",
 	)
+	flags.StringArray(
+		"values", nil,
+		"Reads release values from a yaml `FILE`.",
+	)
+	flags.StringArray(
+		"set", nil,
+		"Overrides a single release value with `KEY=VALUE`.",
+	)
 	flags.Bool("help", false, "Show help information.")
 	return cmd
 }
@@ -141,289 +159,119 @@ func (r Uninstall) validation(cmd *cobra.Command, args []string) error {
 
 // run is a starting point method for executing the cluster release uninstall
 // command.
-func (r Uninstall) run(ctx context.Context, out io.Writer, opts uninstallOptions) error {
-	kubectl, err := GetK8sClientset(ctx, r.Driver, *r.Driver.Namespace())
+func (r Uninstall) run(ctx context.Context, out io.Writer, releases types.Releases, opts uninstallOptions) error {
+	// the namespace is ensured first since the release records and the
+	// lock may be kept in secrets of the namespace.
+	cluster, err := GetCluster(ctx, r.Driver, *r.Driver.Namespace())
+	if err != nil {
+		return err
+	}
+
+	records, err := openReleases(ctx, r.Driver)
+	if err != nil {
+		return err
+	}
+
+	// the lock is held until the revisions are recorded so that concurrent
+	// changes never record revisions which do not match the cluster.
+	unlock, err := lockReleases(ctx, records)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	sort.Sort(*r.Driver.Releases())
-	for _, rel := range *r.Driver.Releases() {
+	sort.Sort(releases)
+	for _, rel := range releases {
 		if opts.Name != "" && rel.Name != opts.Name ||
 			(!opts.Version.EQ(semver.Version{}) &&
 				!rel.Version.Equals(opts.Version)) {
 			continue
 		}
 
-		for _, manifest := range rel.Manifests {
-			if m, ok := manifest.(runtime.Object); ok && opts.Resource != "" {
-				resource := m.GetObjectKind().GroupVersionKind().Kind
-				if !strings.EqualFold(resource, opts.Resource) {
-					continue
-				}
-			}
-
-			switch manifest := manifest.(type) {
-			case *v1core.Namespace:
-				_, err := kubectl.CoreV1().
-					Namespaces().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					Namespaces().
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1core.Pod:
-				_, err := kubectl.CoreV1().
-					Pods(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					Pods(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1core.ServiceAccount:
-				_, err := kubectl.CoreV1().
-					ServiceAccounts(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					ServiceAccounts(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1core.ConfigMap:
-				_, err := kubectl.CoreV1().
-					ConfigMaps(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					ConfigMaps(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1core.Endpoints:
-				_, err := kubectl.CoreV1().
-					Endpoints(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					Endpoints(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1core.Service:
-				_, err := kubectl.CoreV1().
-					Services(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					Services(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1core.Secret:
-				_, err := kubectl.CoreV1().
-					Secrets(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
-
-				err = kubectl.CoreV1().
-					Secrets(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1rbac.Role:
-				_, err := kubectl.RbacV1().
-					Roles(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+		err := r.uninstallRelease(ctx, out, cluster, rel, opts)
 
-				err = kubectl.RbacV1().
-					Roles(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1rbac.RoleBinding:
-				_, err := kubectl.RbacV1().
-					RoleBindings(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+		// a revision is recorded only when the whole release was processed
+		// rather than a single resource of it.
+		if opts.Resource == "" {
+			status := state.StatusUninstalled
+			if err != nil {
+				status = state.StatusFailed
+			}
+			if rerr := recordRelease(ctx, records, rel, status); rerr != nil {
+				return rerr
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-				err = kubectl.RbacV1().
-					RoleBindings(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1rbac.ClusterRole:
-				_, err := kubectl.RbacV1().
-					ClusterRoles().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+// uninstallRelease uninstalls the manifests of a single release in the
+// reverse order of their kind priorities.
+func (r Uninstall) uninstallRelease(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	rel *types.Release,
+	opts uninstallOptions,
+) (err error) {
+	ctx, end := startRelease(ctx, rel)
+	defer func() { end(err) }()
+
+	manifests, err := reverseManifests(rel.Manifests)
+	if err != nil {
+		return err
+	}
 
-				err = kubectl.RbacV1().
-					ClusterRoles().
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1rbac.ClusterRoleBinding:
-				_, err := kubectl.RbacV1().
-					ClusterRoleBindings().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+	for _, manifest := range manifests {
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
 
-				err = kubectl.RbacV1().
-					ClusterRoleBindings().
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1policy.PodSecurityPolicy:
-				_, err := kubectl.PolicyV1beta1().
-					PodSecurityPolicies().
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+		if m, ok := manifest.(runtime.Object); ok && opts.Resource != "" {
+			resource := m.GetObjectKind().GroupVersionKind().Kind
+			if !strings.EqualFold(resource, opts.Resource) {
+				continue
+			}
+		}
 
-				err = kubectl.PolicyV1beta1().
-					PodSecurityPolicies().
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1apps.DaemonSet:
-				_, err := kubectl.AppsV1().
-					DaemonSets(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+		start := time.Now()
+		changed, err := r.uninstallManifest(ctx, cluster, manifest)
+		if rerr := report(ctx, out, rel, manifest, events.StatusUninstalled, changed, start, err); rerr != nil {
+			return rerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-				err = kubectl.AppsV1().
-					DaemonSets(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1apps.Deployment:
-				_, err := kubectl.AppsV1().
-					Deployments(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+// uninstallManifest deletes a single manifest object when it exists.
+func (r Uninstall) uninstallManifest(
+	ctx context.Context,
+	cluster *Cluster,
+	manifest runtime.Object,
+) (changed bool, err error) {
+	ctx, end := startManifest(ctx, manifest, *r.Driver.Namespace())
+	defer func() { end(err) }()
 
-				err = kubectl.AppsV1().
-					Deployments(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1apps.StatefulSet:
-				_, err := kubectl.AppsV1().
-					StatefulSets(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+	resource, obj, err := cluster.Resource(manifest)
+	if err != nil {
+		return false, err
+	}
 
-				err = kubectl.AppsV1().
-					StatefulSets(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			case *v1ext.Ingress:
-				_, err := kubectl.ExtensionsV1beta1().
-					Ingresses(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Get(ctx, manifest.Name, v1meta.GetOptions{})
-				if v1err.IsNotFound(err) {
-					continue
-				} else if err != nil {
-					return err
-				}
+	_, err = resource.Get(ctx, obj.GetName(), v1meta.GetOptions{})
+	if v1err.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
 
-				err = kubectl.ExtensionsV1beta1().
-					Ingresses(FallBackNS(manifest.Namespace, *r.Driver.Namespace())).
-					Delete(ctx, manifest.Name, v1meta.DeleteOptions{})
-				if err != nil {
-					return err
-				}
-			default:
-				return fmt.Errorf("unsupported manifest type %T", manifest)
-			}
-		}
+	if err := resource.Delete(ctx, obj.GetName(), v1meta.DeleteOptions{}); err != nil {
+		return false, err
 	}
-	return nil
+	return true, nil
 }
@@ -0,0 +1,118 @@
+package releases
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/blang/semver"
+	"gopkg.in/yaml.v3"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// loadValues returns the values from which the release manifests are
+// rendered. The values of the driver environment are overridden by the
+// values files, in the order given, followed by the individual values.
+func loadValues(d driver.WithReleases, files []string, sets []string) (types.Values, error) {
+	values := types.Values{}
+	if d, ok := d.(driver.WithValues); ok && d.Values() != nil {
+		values = values.Merge(*d.Values())
+	}
+
+	for _, file := range files {
+		rbytes, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		fileValues := types.Values{}
+		if err := yaml.Unmarshal(rbytes, &fileValues); err != nil {
+			return nil, fmt.Errorf("invalid values file %q (%s)", file, err)
+		}
+		values = values.Merge(fileValues)
+	}
+
+	overrides := types.Values{}
+	for _, expr := range sets {
+		if err := overrides.Set(expr); err != nil {
+			return nil, err
+		}
+	}
+	return values.Merge(overrides), nil
+}
+
+// renderReleases returns copies of the releases of the driver rendered from
+// the values and transformed by the overlay of the driver environment. The
+// releases of the driver are left untouched so that every command renders
+// them afresh.
+func renderReleases(d driver.WithReleases, files []string, sets []string) (types.Releases, error) {
+	if d.Releases() == nil {
+		return types.Releases{}, nil
+	}
+
+	values, err := loadValues(d, files, sets)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := make(types.Releases, 0, len(*d.Releases()))
+	for _, rel := range *d.Releases() {
+		rel, err := rel.Render(values)
+		if err != nil {
+			return nil, err
+		}
+
+		if d, ok := d.(driver.WithOverlay); ok && d.Overlay() != nil {
+			rel.Manifests, err = applyOverlay(d.Overlay(), rel.Manifests)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply overlay onto release %q version %s: %w", rel.Name, rel.Version, err)
+			}
+		}
+		rendered = append(rendered, rel)
+	}
+	return rendered, nil
+}
+
+// tryRender prints the manifests of the selected releases as they would be
+// applied, rendered from the values and labelled as managed by the release.
+func tryRender(out io.Writer, releases types.Releases, opts applyOptions) error {
+	sort.Sort(releases)
+	for _, rel := range releases {
+		if opts.Name != "" && rel.Name != opts.Name ||
+			(!opts.Version.EQ(semver.Version{}) &&
+				!rel.Version.Equals(opts.Version)) {
+			continue
+		}
+
+		manifests, err := sortManifests(rel.Manifests)
+		if err != nil {
+			return err
+		}
+
+		for _, manifest := range manifests {
+			if !matchResource(manifest, opts.Resource) {
+				continue
+			}
+
+			obj, err := toUnstructured(manifest)
+			if err != nil {
+				return err
+			}
+
+			if err := labelObject(obj, rel); err != nil {
+				return err
+			}
+
+			rbytes, err := k8syaml.Marshal(obj.Object)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "---\n# release: %s %s\n%s", rel.Name, rel.Version, rbytes)
+		}
+	}
+	return nil
+}
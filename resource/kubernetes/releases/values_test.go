@@ -0,0 +1,220 @@
+package releases
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1core "k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/testutils"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type ValuesSuite struct {
+	suite.Suite
+	Dir    string
+	Driver interface {
+		driver.WithNamespace
+		driver.WithReleases
+		driver.WithSource
+	} `json:"driver" yaml:"driver"`
+}
+
+func (r *ValuesSuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-*")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+
+	r.Driver = testutils.Kubernetes{
+		Namespace: &[]string{"unittest"}[0],
+		Values: &types.Values{
+			"replicas": 1,
+			"image":    map[string]interface{}{"name": "api", "tag": "latest"},
+		},
+		Releases: &types.Releases{
+			{
+				Name:    "unittest",
+				Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+				Manifests: []runtime.Object{
+					&v1core.ConfigMap{
+						TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+					},
+				},
+				Template: func(values types.Values) ([]runtime.Object, error) {
+					replicas, _ := values.Lookup("replicas")
+					name, _ := values.Lookup("image.name")
+					tag, _ := values.Lookup("image.tag")
+					return []runtime.Object{
+						&v1apps.Deployment{
+							TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+							ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+							Spec: v1apps.DeploymentSpec{
+								Replicas: &[]int32{int32(replicas.(int))}[0],
+								Template: v1core.PodTemplateSpec{
+									Spec: v1core.PodSpec{
+										Containers: []v1core.Container{{
+											Name:  "unittest",
+											Image: name.(string) + ":" + tag.(string),
+										}},
+									},
+								},
+							},
+						},
+					}, nil
+				},
+			},
+		},
+	}.Build()
+}
+
+func (r *ValuesSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *ValuesSuite) TestLoadValues() {
+	production := filepath.Join(r.Dir, "production.yaml")
+	assert.Nil(r.T(), ioutil.WriteFile(production, []byte("replicas: 3\nimage:\n  tag: 1.4.0\n"), 0644))
+
+	invalid := filepath.Join(r.Dir, "invalid.yaml")
+	assert.Nil(r.T(), ioutil.WriteFile(invalid, []byte("- replicas\n"), 0644))
+
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		files      []string
+		sets       []string
+		values     types.Values
+	}{
+		{
+			false, "",
+			nil,
+			nil,
+			types.Values{
+				"replicas": 1,
+				"image":    map[string]interface{}{"name": "api", "tag": "latest"},
+			},
+		},
+		{
+			false, "",
+			[]string{production},
+			nil,
+			types.Values{
+				"replicas": 3,
+				"image":    map[string]interface{}{"name": "api", "tag": "1.4.0"},
+			},
+		},
+		{
+			false, "",
+			[]string{production},
+			[]string{"replicas=5", "image.name=web"},
+			types.Values{
+				"replicas": 5,
+				"image":    map[string]interface{}{"name": "web", "tag": "1.4.0"},
+			},
+		},
+		{
+			true, `invalid values file "` + invalid + `" (yaml: unmarshal errors:` + "\n" +
+				`  line 1: cannot unmarshal !!seq into types.Values)`,
+			[]string{invalid},
+			nil,
+			nil,
+		},
+		{true, `invalid value "replicas" (expected KEY=VALUE)`, nil, []string{"replicas"}, nil},
+	}
+
+	for i, testCase := range testCases {
+		values, err := loadValues(r.Driver, testCase.files, testCase.sets)
+		if testCase.shouldFail {
+			assert.EqualError(r.T(), err, testCase.onFail, "testCase: %d", i)
+			continue
+		}
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.values, values, "testCase: %d", i)
+	}
+}
+
+func (r *ValuesSuite) TestRenderReleases() {
+	testCases := []struct {
+		sets     []string
+		replicas int32
+		image    string
+	}{
+		{nil, 1, "api:latest"},
+		{[]string{"replicas=2", "image.tag=1.4.0"}, 2, "api:1.4.0"},
+		{[]string{"image.name=web"}, 1, "web:latest"},
+	}
+
+	for i, testCase := range testCases {
+		releases, err := renderReleases(r.Driver, nil, testCase.sets)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Len(r.T(), releases[0].Manifests, 2, "testCase: %d", i)
+
+		deployment := releases[0].Manifests[1].(*v1apps.Deployment)
+		assert.Equal(r.T(), testCase.replicas, *deployment.Spec.Replicas, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.image, deployment.Spec.Template.Spec.Containers[0].Image, "testCase: %d", i)
+
+		// the registered releases keep their template and static manifests.
+		assert.NotNil(r.T(), (*r.Driver.Releases())[0].Template, "testCase: %d", i)
+		assert.Len(r.T(), (*r.Driver.Releases())[0].Manifests, 1, "testCase: %d", i)
+	}
+}
+
+func (r *ValuesSuite) TestTryRender() {
+	releases, err := renderReleases(r.Driver, nil, []string{"replicas=2", "image.tag=1.4.0"})
+	assert.Nil(r.T(), err)
+
+	rel := releases[0]
+	obj, err := toUnstructured(rel.Manifests[1])
+	assert.Nil(r.T(), err)
+	assert.Nil(r.T(), labelObject(obj, rel))
+
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), tryRender(buffer, releases, applyOptions{Resource: "deployment"}))
+	assert.Equal(r.T(), ""+
+		"---\n"+
+		"# release: unittest 0.0.1\n"+
+		"apiVersion: apps/v1\n"+
+		"kind: Deployment\n"+
+		"metadata:\n"+
+		"  annotations:\n"+
+		"    migrate.trivigy.com/manifest-hash: "+obj.GetAnnotations()[ManifestHashAnnotation]+"\n"+
+		"  labels:\n"+
+		"    app.kubernetes.io/instance: unittest\n"+
+		"    app.kubernetes.io/managed-by: migrate\n"+
+		"    app.kubernetes.io/version: 0.0.1\n"+
+		"  name: unittest\n"+
+		"spec:\n"+
+		"  replicas: 2\n"+
+		"  selector: null\n"+
+		"  strategy: {}\n"+
+		"  template:\n"+
+		"    metadata:\n"+
+		"      creationTimestamp: null\n"+
+		"    spec:\n"+
+		"      containers:\n"+
+		"      - image: api:1.4.0\n"+
+		"        name: unittest\n"+
+		"        resources: {}\n",
+		buffer.String())
+
+	buffer = bytes.NewBuffer(nil)
+	assert.Nil(r.T(), tryRender(buffer, releases, applyOptions{Name: "missing"}))
+	assert.Equal(r.T(), "", buffer.String())
+}
+
+func TestValuesSuite(t *testing.T) {
+	suite.Run(t, new(ValuesSuite))
+}
@@ -0,0 +1,332 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	v1apps "k8s.io/api/apps/v1"
+	v1batch "k8s.io/api/batch/v1"
+	v1core "k8s.io/api/core/v1"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/retry"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+// waitInterval defines how often the readiness of objects is polled.
+var waitInterval = 2 * time.Second
+
+// readiness reports whether a cluster object is ready together with a short
+// description of what is still being waited for.
+type readiness func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error)
+
+// readinessChecks defines the readiness of the kinds which take time to
+// settle after being applied. Objects of any other kind are ready as soon as
+// they are accepted by the server.
+var readinessChecks = map[schema.GroupKind]readiness{
+	{Group: "apps", Kind: "Deployment"}: func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error) {
+		result := &v1apps.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, result); err != nil {
+			return false, "", err
+		}
+
+		replicas := int32(1)
+		if result.Spec.Replicas != nil {
+			replicas = *result.Spec.Replicas
+		}
+
+		switch {
+		case result.Status.ObservedGeneration < result.Generation:
+			return false, "waiting for the rollout to be observed", nil
+		case result.Status.UpdatedReplicas < replicas:
+			return false, fmt.Sprintf("%d of %d new replicas updated", result.Status.UpdatedReplicas, replicas), nil
+		case result.Status.Replicas > result.Status.UpdatedReplicas:
+			return false, fmt.Sprintf("%d old replicas pending termination", result.Status.Replicas-result.Status.UpdatedReplicas), nil
+		case result.Status.AvailableReplicas < result.Status.UpdatedReplicas:
+			return false, fmt.Sprintf("%d of %d updated replicas available", result.Status.AvailableReplicas, result.Status.UpdatedReplicas), nil
+		}
+		return true, "", nil
+	},
+	{Group: "apps", Kind: "StatefulSet"}: func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error) {
+		result := &v1apps.StatefulSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, result); err != nil {
+			return false, "", err
+		}
+
+		replicas := int32(1)
+		if result.Spec.Replicas != nil {
+			replicas = *result.Spec.Replicas
+		}
+
+		switch {
+		case result.Status.ObservedGeneration < result.Generation:
+			return false, "waiting for the rollout to be observed", nil
+		case result.Status.ReadyReplicas < replicas:
+			return false, fmt.Sprintf("%d of %d replicas ready", result.Status.ReadyReplicas, replicas), nil
+		case result.Status.UpdateRevision != "" && result.Status.CurrentRevision != result.Status.UpdateRevision:
+			return false, fmt.Sprintf("%d of %d replicas updated", result.Status.UpdatedReplicas, replicas), nil
+		}
+		return true, "", nil
+	},
+	{Group: "apps", Kind: "DaemonSet"}: func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error) {
+		result := &v1apps.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, result); err != nil {
+			return false, "", err
+		}
+
+		desired := result.Status.DesiredNumberScheduled
+		switch {
+		case result.Status.ObservedGeneration < result.Generation:
+			return false, "waiting for the rollout to be observed", nil
+		case result.Status.UpdatedNumberScheduled < desired:
+			return false, fmt.Sprintf("%d of %d new pods updated", result.Status.UpdatedNumberScheduled, desired), nil
+		case result.Status.NumberAvailable < desired:
+			return false, fmt.Sprintf("%d of %d updated pods available", result.Status.NumberAvailable, desired), nil
+		}
+		return true, "", nil
+	},
+	{Group: "batch", Kind: "Job"}: func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error) {
+		result := &v1batch.Job{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, result); err != nil {
+			return false, "", err
+		}
+
+		for _, condition := range result.Status.Conditions {
+			if condition.Status != v1core.ConditionTrue {
+				continue
+			}
+
+			switch condition.Type {
+			case v1batch.JobComplete:
+				return true, "", nil
+			case v1batch.JobFailed:
+				return false, "", fmt.Errorf("job %q failed (%s)", result.Name, condition.Message)
+			}
+		}
+		return false, fmt.Sprintf("%d active, %d succeeded", result.Status.Active, result.Status.Succeeded), nil
+	},
+	{Group: "", Kind: "Service"}: func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error) {
+		result := &v1core.Service{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, result); err != nil {
+			return false, "", err
+		}
+
+		if result.Spec.Type == v1core.ServiceTypeExternalName || len(result.Spec.Selector) == 0 {
+			return true, "", nil
+		}
+
+		endpoints := &v1core.Endpoints{
+			TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+			ObjectMeta: v1meta.ObjectMeta{Name: result.Name, Namespace: result.Namespace},
+		}
+		resource, _, err := cluster.Resource(endpoints)
+		if err != nil {
+			return false, "", err
+		}
+
+		instance, err := resource.Get(ctx, result.Name, v1meta.GetOptions{})
+		if v1err.IsNotFound(err) {
+			return false, "waiting for endpoints", nil
+		} else if err != nil {
+			return false, "", err
+		}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(instance.Object, endpoints); err != nil {
+			return false, "", err
+		}
+
+		for _, subset := range endpoints.Subsets {
+			if len(subset.Addresses) > 0 {
+				return true, "", nil
+			}
+		}
+		return false, "waiting for endpoints", nil
+	},
+	{Group: "", Kind: "PersistentVolumeClaim"}: func(ctx context.Context, cluster *Cluster, obj *unstructured.Unstructured) (bool, string, error) {
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		if phase == string(v1core.ClaimBound) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("claim is %s", strings.ToLower(FallBackNS(phase, "pending"))), nil
+	},
+}
+
+// waitRelease blocks until every manifest object of the release is ready or
+// the timeout elapses. Progress is reported whenever the state of an object
+// changes and the reasons of failing pods are reported on timeout. An error
+// looking up an object or checking its readiness, e.g. a failed job, ends the
+// wait right away.
+func waitRelease(
+	ctx context.Context,
+	out io.Writer,
+	cluster *Cluster,
+	rel *types.Release,
+	opts applyOptions,
+) error {
+	waitCtx, cancel := context.WithTimeout(ctx, opts.WaitTimeout)
+	defer cancel()
+
+	for _, manifest := range rel.Manifests {
+		if !matchResource(manifest, opts.Resource) {
+			continue
+		}
+
+		resource, obj, err := cluster.Resource(manifest)
+		if err != nil {
+			return err
+		}
+
+		check, ok := readinessChecks[obj.GroupVersionKind().GroupKind()]
+		if !ok {
+			continue
+		}
+
+		path := obj.GetKind() + " " + obj.GetName()
+		if obj.GetNamespace() != "" {
+			path = obj.GetKind() + " " + obj.GetNamespace() + "/" + obj.GetName()
+		}
+
+		var instance *unstructured.Unstructured
+		var progress string
+		err = retry.Do(waitCtx, waitInterval, func() (bool, error) {
+			instance, err = resource.Get(waitCtx, obj.GetName(), v1meta.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			ready, message, err := check(waitCtx, cluster, instance)
+			if err != nil || ready {
+				return false, err
+			}
+
+			if message != progress {
+				progress = message
+				if !events.JSONL(ctx) {
+					fmt.Fprintf(out, "waiting for %s: %s\n", path, message)
+				}
+			}
+			return true, nil
+		})
+
+		if waitCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			if !events.JSONL(ctx) && instance != nil {
+				diagnose(ctx, out, cluster, instance)
+			}
+			return fmt.Errorf("timed out waiting for %s (%s)", path, progress)
+		} else if err != nil {
+			return err
+		}
+
+		if !events.JSONL(ctx) {
+			fmt.Fprintf(out, "%s is ready\n", path)
+		}
+	}
+	return nil
+}
+
+// diagnose writes the reasons why the pods selected by the object are not
+// ready, including the statuses of their containers and their recent events.
+func diagnose(ctx context.Context, out io.Writer, cluster *Cluster, obj *unstructured.Unstructured) {
+	matchLabels, ok, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	if !ok || len(matchLabels) == 0 {
+		return
+	}
+
+	pods, _, err := cluster.Resource(&v1core.Pod{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: v1meta.ObjectMeta{Namespace: obj.GetNamespace()},
+	})
+	if err != nil {
+		return
+	}
+
+	list, err := pods.List(ctx, v1meta.ListOptions{
+		LabelSelector: labels.SelectorFromSet(matchLabels).String(),
+	})
+	if err != nil {
+		return
+	}
+
+	for _, item := range list.Items {
+		pod := &v1core.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, pod); err != nil {
+			continue
+		}
+
+		reasons := make([]string, 0)
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			switch {
+			case status.State.Waiting != nil:
+				reasons = append(reasons, fmt.Sprintf("container %q is waiting: %s %s",
+					status.Name, status.State.Waiting.Reason, status.State.Waiting.Message))
+			case status.State.Terminated != nil && status.State.Terminated.ExitCode != 0:
+				reasons = append(reasons, fmt.Sprintf("container %q terminated: %s (exit code %d)",
+					status.Name, status.State.Terminated.Reason, status.State.Terminated.ExitCode))
+			case !status.Ready:
+				reasons = append(reasons, fmt.Sprintf("container %q is not ready", status.Name))
+			}
+		}
+
+		for _, event := range recentEvents(ctx, cluster, pod) {
+			reasons = append(reasons, fmt.Sprintf("event %s %s: %s", event.Type, event.Reason, event.Message))
+		}
+
+		if len(reasons) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(out, "pod %s/%s is %s\n", pod.Namespace, pod.Name, strings.ToLower(string(pod.Status.Phase)))
+		for _, reason := range reasons {
+			fmt.Fprintf(out, "  %s\n", strings.TrimSpace(reason))
+		}
+	}
+}
+
+// recentEvents returns the last few warning events involving the pod.
+func recentEvents(ctx context.Context, cluster *Cluster, pod *v1core.Pod) []v1core.Event {
+	resource, _, err := cluster.Resource(&v1core.Event{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Event"},
+		ObjectMeta: v1meta.ObjectMeta{Namespace: pod.Namespace},
+	})
+	if err != nil {
+		return nil
+	}
+
+	list, err := resource.List(ctx, v1meta.ListOptions{
+		FieldSelector: "involvedObject.name=" + pod.Name,
+	})
+	if err != nil {
+		return nil
+	}
+
+	recent := make([]v1core.Event, 0)
+	for _, item := range list.Items {
+		event := v1core.Event{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &event); err != nil {
+			continue
+		}
+
+		if event.InvolvedObject.Name != pod.Name || event.Type != v1core.EventTypeWarning {
+			continue
+		}
+		recent = append(recent, event)
+	}
+
+	sort.Slice(recent, func(i, j int) bool {
+		return recent[i].LastTimestamp.Before(&recent[j].LastTimestamp)
+	})
+	if len(recent) > 5 {
+		recent = recent[len(recent)-5:]
+	}
+	return recent
+}
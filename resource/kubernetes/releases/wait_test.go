@@ -0,0 +1,377 @@
+package releases
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1apps "k8s.io/api/apps/v1"
+	v1batch "k8s.io/api/batch/v1"
+	v1core "k8s.io/api/core/v1"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/types"
+)
+
+type WaitSuite struct {
+	suite.Suite
+	Cluster *Cluster
+	Release *types.Release
+}
+
+func (r *WaitSuite) SetupSuite() {
+	waitInterval = 10 * time.Millisecond
+}
+
+func (r *WaitSuite) SetupTest() {
+	r.Cluster = newFakeCluster()
+	mapper := r.Cluster.Mapper.(*meta.DefaultRESTMapper)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Event"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+
+	selector := map[string]string{"app": "unittest"}
+	r.Release = &types.Release{
+		Name:    "unittest",
+		Version: semver.Version{Major: 0, Minor: 0, Patch: 1},
+		Manifests: []runtime.Object{
+			&v1core.ConfigMap{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+			},
+			&v1core.Service{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+				Spec:       v1core.ServiceSpec{Selector: selector},
+			},
+			&v1apps.Deployment{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+				Spec: v1apps.DeploymentSpec{
+					Replicas: &[]int32{1}[0],
+					Selector: &v1meta.LabelSelector{MatchLabels: selector},
+				},
+			},
+		},
+	}
+}
+
+// update stores the object in the fake cluster as is, including its status.
+func (r *WaitSuite) update(obj runtime.Object) {
+	resource, result, err := r.Cluster.Resource(obj)
+	if !assert.Nil(r.T(), err) {
+		return
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if !assert.Nil(r.T(), err) {
+		return
+	}
+	result.Object["status"] = object["status"]
+
+	ctx := context.Background()
+	if _, err := resource.Get(ctx, result.GetName(), v1meta.GetOptions{}); err != nil {
+		_, err = resource.Create(ctx, result, v1meta.CreateOptions{})
+		assert.Nil(r.T(), err)
+		return
+	}
+	_, err = resource.Update(ctx, result, v1meta.UpdateOptions{})
+	assert.Nil(r.T(), err)
+}
+
+func (r *WaitSuite) TestReadiness() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		obj        runtime.Object
+		ready      bool
+		message    string
+	}{
+		{
+			false, "",
+			&v1apps.Deployment{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Spec:     v1apps.DeploymentSpec{Replicas: &[]int32{2}[0]},
+				Status:   v1apps.DeploymentStatus{Replicas: 2, UpdatedReplicas: 1},
+			},
+			false, "1 of 2 new replicas updated",
+		},
+		{
+			false, "",
+			&v1apps.Deployment{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				Spec:     v1apps.DeploymentSpec{Replicas: &[]int32{2}[0]},
+				Status:   v1apps.DeploymentStatus{Replicas: 3, UpdatedReplicas: 2},
+			},
+			false, "1 old replicas pending termination",
+		},
+		{
+			false, "",
+			&v1apps.Deployment{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: v1meta.ObjectMeta{Generation: 2},
+				Spec:       v1apps.DeploymentSpec{Replicas: &[]int32{2}[0]},
+				Status: v1apps.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           2,
+					UpdatedReplicas:    2,
+					AvailableReplicas:  2,
+				},
+			},
+			true, "",
+		},
+		{
+			false, "",
+			&v1apps.StatefulSet{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"},
+				Status: v1apps.StatefulSetStatus{
+					ReadyReplicas:   1,
+					UpdatedReplicas: 0,
+					CurrentRevision: "unittest-1",
+					UpdateRevision:  "unittest-2",
+				},
+			},
+			false, "0 of 1 replicas updated",
+		},
+		{
+			false, "",
+			&v1apps.DaemonSet{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"},
+				Status: v1apps.DaemonSetStatus{
+					DesiredNumberScheduled: 3,
+					UpdatedNumberScheduled: 3,
+					NumberAvailable:        1,
+				},
+			},
+			false, "1 of 3 updated pods available",
+		},
+		{
+			false, "",
+			&v1batch.Job{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				Status: v1batch.JobStatus{
+					Active:     1,
+					Conditions: []v1batch.JobCondition{{Type: v1batch.JobComplete, Status: v1core.ConditionFalse}},
+				},
+			},
+			false, "1 active, 0 succeeded",
+		},
+		{
+			false, "",
+			&v1batch.Job{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				Status: v1batch.JobStatus{
+					Conditions: []v1batch.JobCondition{{Type: v1batch.JobComplete, Status: v1core.ConditionTrue}},
+				},
+			},
+			true, "",
+		},
+		{
+			true, `job "unittest" failed (BackoffLimitExceeded)`,
+			&v1batch.Job{
+				TypeMeta:   v1meta.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+				ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+				Status: v1batch.JobStatus{
+					Conditions: []v1batch.JobCondition{{
+						Type:    v1batch.JobFailed,
+						Status:  v1core.ConditionTrue,
+						Message: "BackoffLimitExceeded",
+					}},
+				},
+			},
+			false, "",
+		},
+		{
+			false, "",
+			&v1core.Service{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "v1", Kind: "Service"},
+				Spec:     v1core.ServiceSpec{Type: v1core.ServiceTypeExternalName},
+			},
+			true, "",
+		},
+		{
+			false, "",
+			&v1core.PersistentVolumeClaim{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+			},
+			false, "claim is pending",
+		},
+		{
+			false, "",
+			&v1core.PersistentVolumeClaim{
+				TypeMeta: v1meta.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+				Status:   v1core.PersistentVolumeClaimStatus{Phase: v1core.ClaimBound},
+			},
+			true, "",
+		},
+	}
+
+	for i, testCase := range testCases {
+		object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testCase.obj)
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		obj := &unstructured.Unstructured{Object: object}
+		check := readinessChecks[obj.GroupVersionKind().GroupKind()]
+		ready, message, err := check(context.Background(), r.Cluster, obj)
+		if testCase.shouldFail {
+			assert.EqualError(r.T(), err, testCase.onFail, "testCase: %d", i)
+			continue
+		}
+
+		assert.Nil(r.T(), err, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.ready, ready, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.message, message, "testCase: %d", i)
+	}
+}
+
+func (r *WaitSuite) TestWaitRelease() {
+	ctx := context.Background()
+	opts := applyOptions{Wait: true, WaitTimeout: time.Second}
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Release, opts, events.StatusInstalled))
+
+	r.update(&v1core.Endpoints{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "v1", Kind: "Endpoints"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+		Subsets:    []v1core.EndpointSubset{{Addresses: []v1core.EndpointAddress{{IP: "10.0.0.1"}}}},
+	})
+
+	deployment := r.Release.Manifests[2].DeepCopyObject().(*v1apps.Deployment)
+	deployment.Status = v1apps.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1}
+	r.update(deployment)
+
+	buffer := bytes.NewBuffer(nil)
+	assert.Nil(r.T(), waitRelease(ctx, buffer, r.Cluster, r.Release, opts))
+	assert.Equal(r.T(), "Service unittest/unittest is ready\n"+
+		"Deployment unittest/unittest is ready\n", buffer.String())
+}
+
+func (r *WaitSuite) TestWaitTimeout() {
+	ctx := context.Background()
+	opts := applyOptions{Wait: true, WaitTimeout: 100 * time.Millisecond, Resource: "deployment"}
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Release, opts, events.StatusInstalled))
+
+	r.update(&v1core.Pod{
+		TypeMeta: v1meta.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:   "unittest-abcde",
+			Labels: map[string]string{"app": "unittest"},
+		},
+		Status: v1core.PodStatus{
+			Phase: v1core.PodPending,
+			ContainerStatuses: []v1core.ContainerStatus{{
+				Name: "unittest",
+				State: v1core.ContainerState{Waiting: &v1core.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: `Back-off pulling image "unittest:latest"`,
+				}},
+			}},
+		},
+	})
+
+	r.update(&v1core.Pod{
+		TypeMeta: v1meta.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:   "other",
+			Labels: map[string]string{"app": "other"},
+		},
+		Status: v1core.PodStatus{Phase: v1core.PodPending},
+	})
+
+	r.update(&v1core.Event{
+		TypeMeta:       v1meta.TypeMeta{APIVersion: "v1", Kind: "Event"},
+		ObjectMeta:     v1meta.ObjectMeta{Name: "unittest-abcde.1"},
+		InvolvedObject: v1core.ObjectReference{Kind: "Pod", Name: "unittest-abcde"},
+		Type:           v1core.EventTypeWarning,
+		Reason:         "Failed",
+		Message:        "Error: ErrImagePull",
+	})
+
+	buffer := bytes.NewBuffer(nil)
+	err := waitRelease(ctx, buffer, r.Cluster, r.Release, opts)
+	assert.EqualError(r.T(), err, "timed out waiting for Deployment unittest/unittest (0 of 1 new replicas updated)")
+	assert.Equal(r.T(), "waiting for Deployment unittest/unittest: 0 of 1 new replicas updated\n"+
+		"pod unittest/unittest-abcde is pending\n"+
+		"  container \"unittest\" is waiting: ImagePullBackOff Back-off pulling image \"unittest:latest\"\n"+
+		"  event Warning Failed: Error: ErrImagePull\n", buffer.String())
+}
+
+func (r *WaitSuite) TestWaitFailedJob() {
+	ctx := context.Background()
+	opts := applyOptions{Wait: true, WaitTimeout: time.Second, Resource: "job"}
+
+	job := &v1batch.Job{
+		TypeMeta:   v1meta.TypeMeta{APIVersion: "batch/v1", Kind: "Job"},
+		ObjectMeta: v1meta.ObjectMeta{Name: "unittest", Namespace: "unittest"},
+	}
+	r.Release.Manifests = append(r.Release.Manifests, job)
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Release, opts, events.StatusInstalled))
+
+	job = job.DeepCopy()
+	job.Status = v1batch.JobStatus{
+		Failed: 1,
+		Conditions: []v1batch.JobCondition{{
+			Type:    v1batch.JobFailed,
+			Status:  v1core.ConditionTrue,
+			Message: "Job has reached the specified backoff limit",
+		}},
+	}
+	r.update(job)
+
+	buffer := bytes.NewBuffer(nil)
+	err := waitRelease(ctx, buffer, r.Cluster, r.Release, opts)
+	assert.EqualError(r.T(), err, `job "unittest" failed (Job has reached the specified backoff limit)`)
+	assert.Equal(r.T(), "", buffer.String())
+}
+
+func (r *WaitSuite) TestWaitGetError() {
+	ctx := context.Background()
+	opts := applyOptions{Wait: true, WaitTimeout: time.Second, Resource: "deployment"}
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Release, opts, events.StatusInstalled))
+
+	client := r.Cluster.Client.(*dynamicfake.FakeDynamicClient)
+	client.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, v1err.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "unittest", nil)
+	})
+
+	buffer := bytes.NewBuffer(nil)
+	err := waitRelease(ctx, buffer, r.Cluster, r.Release, opts)
+	assert.True(r.T(), v1err.IsForbidden(err))
+	assert.Equal(r.T(), "", buffer.String())
+}
+
+func (r *WaitSuite) TestWaitGetDeadline() {
+	ctx := context.Background()
+	opts := applyOptions{Wait: true, WaitTimeout: 50 * time.Millisecond, Resource: "deployment"}
+	assert.Nil(r.T(), applyRelease(ctx, bytes.NewBuffer(nil), r.Cluster, r.Release, opts, events.StatusInstalled))
+
+	// the request outlives the deadline and fails with the wrapped error
+	// returned by the rest client.
+	client := r.Cluster.Client.(*dynamicfake.FakeDynamicClient)
+	client.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(100 * time.Millisecond)
+		return true, nil, &url.Error{Op: "Get", URL: "/apis/apps/v1", Err: context.DeadlineExceeded}
+	})
+
+	buffer := bytes.NewBuffer(nil)
+	err := waitRelease(ctx, buffer, r.Cluster, r.Release, opts)
+	assert.EqualError(r.T(), err, "timed out waiting for Deployment unittest/unittest ()")
+	assert.Equal(r.T(), "", buffer.String())
+}
+
+func TestWaitSuite(t *testing.T) {
+	suite.Run(t, new(WaitSuite))
+}
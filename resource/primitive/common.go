@@ -0,0 +1,50 @@
+package primitive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/trivigy/migrate/v2/internal/events"
+)
+
+// execute runs the driver action and reports it as a single step. With the
+// jsonl output the driver output is captured into the event instead of being
+// written directly.
+func execute(
+	ctx context.Context,
+	out io.Writer,
+	name string,
+	status string,
+	action func(context.Context, io.Writer) error,
+) error {
+	if !events.JSONL(ctx) {
+		return action(ctx, out)
+	}
+
+	// the command is named after the action therefore the resource is
+	// identified by the name of the parent command.
+	resource := name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		resource = name[:i]
+	}
+
+	start := time.Now()
+	buffer := bytes.NewBuffer(nil)
+	err := action(ctx, buffer)
+
+	event := events.Event{
+		Type:     events.TypePrimitive,
+		Resource: resource,
+		Status:   status,
+		Duration: time.Since(start),
+		Output:   buffer.String(),
+	}
+	event.Fail(err)
+	if eerr := events.Emit(ctx, out, event, ""); eerr != nil {
+		return eerr
+	}
+	return err
+}
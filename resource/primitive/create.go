@@ -12,6 +12,8 @@ import (
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
@@ -35,7 +37,10 @@ func (r Create) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Constructs and starts a new instance of this resource.",
 		Long:  "Constructs and starts a new instance of this resource",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			if patches, ok := r.Driver.(driver.WithPatches); ok {
 				for _, patch := range *patches.Patches(name) {
 					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
@@ -54,7 +59,7 @@ func (r Create) NewCommand(ctx context.Context, name string) *cobra.Command {
 				return nil
 			}
 
-			return r.run(ctx, cmd.OutOrStdout())
+			return r.run(ctx, cmd.OutOrStdout(), name)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -92,9 +97,6 @@ func (r Create) validation(cmd *cobra.Command, args []string) error {
 }
 
 // run is a starting point method for executing the create command.
-func (r Create) run(ctx context.Context, out io.Writer) error {
-	if err := r.Driver.Create(ctx, out); err != nil {
-		return err
-	}
-	return nil
+func (r Create) run(ctx context.Context, out io.Writer, name string) error {
+	return execute(ctx, out, name, events.StatusCreated, r.Driver.Create)
 }
@@ -11,6 +11,8 @@ import (
 
 	"github.com/trivigy/migrate/v2/driver"
 	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
 	"github.com/trivigy/migrate/v2/require"
 	"github.com/trivigy/migrate/v2/types"
 )
@@ -34,7 +36,10 @@ func (r Source) NewCommand(ctx context.Context, name string) *cobra.Command {
 		Short: "Prints the data source name as a connection string.",
 		Long:  "Prints the data source name as a connection string",
 		Args:  require.Args(r.validation),
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			ctx, end := telemetry.Start(ctx, "command", telemetry.CommandKey.String(name))
+			defer func() { end(err) }()
+
 			if patches, ok := r.Driver.(driver.WithPatches); ok {
 				for _, patch := range *patches.Patches(name) {
 					if err := patch.Do(ctx, cmd.OutOrStdout()); err != nil {
@@ -53,7 +58,7 @@ func (r Source) NewCommand(ctx context.Context, name string) *cobra.Command {
 				return nil
 			}
 
-			return r.run(ctx, cmd.OutOrStdout())
+			return r.run(ctx, cmd.OutOrStdout(), name)
 		},
 		SilenceErrors: true,
 		SilenceUsage:  true,
@@ -91,9 +96,6 @@ func (r Source) validation(cmd *cobra.Command, args []string) error {
 }
 
 // run is a starting point method for executing the source command.
-func (r Source) run(ctx context.Context, out io.Writer) error {
-	if err := r.Driver.Source(ctx, out); err != nil {
-		return err
-	}
-	return nil
+func (r Source) run(ctx context.Context, out io.Writer, name string) error {
+	return execute(ctx, out, name, events.StatusSourced, r.Driver.Source)
 }
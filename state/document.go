@@ -0,0 +1,278 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// document defines the serialized records kept by the file and kubernetes
+// stores.
+type document struct {
+	Migrations []Migration `json:"migrations"`
+	History    []Event     `json:"history"`
+	Batches    []Batch     `json:"batches"`
+	Releases   []Release   `json:"releases"`
+}
+
+// backend defines the storage primitives the document store is built upon.
+// The modify method must atomically replace the serialized document, nil
+// when missing, with the one returned. The lock method returns the record of
+// the current holder when the named lock is already held.
+type backend interface {
+	read(ctx context.Context) ([]byte, error)
+	modify(ctx context.Context, fn func([]byte) ([]byte, error)) error
+	lock(ctx context.Context, name string, record []byte) ([]byte, error)
+	unlock(ctx context.Context, name string) error
+}
+
+// documentStore implements the store operations on top of a backend which
+// keeps all of the records in a single serialized document.
+type documentStore struct {
+	backend backend
+}
+
+// load returns the current document.
+func (r documentStore) load(ctx context.Context) (*document, error) {
+	rbytes, err := r.backend.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return decode(rbytes)
+}
+
+// update applies the change to the current document and stores the result.
+func (r documentStore) update(ctx context.Context, change func(*document) error) error {
+	return r.backend.modify(ctx, func(rbytes []byte) ([]byte, error) {
+		doc, err := decode(rbytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := change(doc); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(doc, "", "  ")
+	})
+}
+
+// Migrations returns the records of the applied migrations.
+func (r documentStore) Migrations(ctx context.Context) ([]Migration, error) {
+	doc, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Migrations, nil
+}
+
+// InsertMigration records the migration as applied.
+func (r documentStore) InsertMigration(ctx context.Context, migration Migration) error {
+	return r.update(ctx, func(doc *document) error {
+		for _, record := range doc.Migrations {
+			if record.Tag == migration.Tag {
+				return fmt.Errorf("migration %q already recorded", migration.Tag)
+			}
+		}
+		doc.Migrations = append(doc.Migrations, migration)
+		return nil
+	})
+}
+
+// DeleteMigration removes the record of the migration with tag.
+func (r documentStore) DeleteMigration(ctx context.Context, tag string) error {
+	return r.update(ctx, func(doc *document) error {
+		migrations := doc.Migrations[:0]
+		for _, record := range doc.Migrations {
+			if record.Tag != tag {
+				migrations = append(migrations, record)
+			}
+		}
+		doc.Migrations = migrations
+		return nil
+	})
+}
+
+// History returns every event of the migrations history in the order they
+// were recorded.
+func (r documentStore) History(ctx context.Context) ([]Event, error) {
+	doc, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return doc.History, nil
+}
+
+// LatestEvent returns the most recent event of the migration with tag or nil
+// when the migration has no history.
+func (r documentStore) LatestEvent(ctx context.Context, tag string) (*Event, error) {
+	doc, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(doc.History) - 1; i >= 0; i-- {
+		if doc.History[i].Tag == tag {
+			return &doc.History[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// InsertEvent appends the event to the migrations history.
+func (r documentStore) InsertEvent(ctx context.Context, event Event) error {
+	return r.update(ctx, func(doc *document) error {
+		doc.History = append(doc.History, event)
+		return nil
+	})
+}
+
+// Batch returns the progress record of the batched operation with key or nil
+// when the operation is not in progress.
+func (r documentStore) Batch(ctx context.Context, key string) (*Batch, error) {
+	doc, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range doc.Batches {
+		if doc.Batches[i].Key == key {
+			return &doc.Batches[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveBatch inserts or replaces the progress record of the batched operation.
+func (r documentStore) SaveBatch(ctx context.Context, batch Batch) error {
+	return r.update(ctx, func(doc *document) error {
+		for i, record := range doc.Batches {
+			if record.Key == batch.Key {
+				doc.Batches[i] = batch
+				return nil
+			}
+		}
+		doc.Batches = append(doc.Batches, batch)
+		return nil
+	})
+}
+
+// DeleteBatch removes the progress record of the batched operation with key.
+func (r documentStore) DeleteBatch(ctx context.Context, key string) error {
+	return r.update(ctx, func(doc *document) error {
+		batches := doc.Batches[:0]
+		for _, record := range doc.Batches {
+			if record.Key != key {
+				batches = append(batches, record)
+			}
+		}
+		doc.Batches = batches
+		return nil
+	})
+}
+
+// Releases returns the records of every release revision.
+func (r documentStore) Releases(ctx context.Context) ([]Release, error) {
+	doc, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Releases, nil
+}
+
+// InsertRelease records a new release revision.
+func (r documentStore) InsertRelease(ctx context.Context, release Release) error {
+	return r.update(ctx, func(doc *document) error {
+		for _, record := range doc.Releases {
+			if record.Name == release.Name && record.Revision == release.Revision {
+				return fmt.Errorf("release %q revision %d already recorded",
+					release.Name, release.Revision,
+				)
+			}
+		}
+		doc.Releases = append(doc.Releases, release)
+		return nil
+	})
+}
+
+// UpdateRelease replaces the record of an existing release revision.
+func (r documentStore) UpdateRelease(ctx context.Context, release Release) error {
+	return r.update(ctx, func(doc *document) error {
+		for i, record := range doc.Releases {
+			if record.Name == release.Name && record.Revision == release.Revision {
+				doc.Releases[i] = release
+				return nil
+			}
+		}
+		return fmt.Errorf("release %q revision %d not recorded",
+			release.Name, release.Revision,
+		)
+	})
+}
+
+// DeleteRelease removes the record of the release revision.
+func (r documentStore) DeleteRelease(ctx context.Context, name string, revision int) error {
+	return r.update(ctx, func(doc *document) error {
+		releases := doc.Releases[:0]
+		for _, record := range doc.Releases {
+			if record.Name != name || record.Revision != revision {
+				releases = append(releases, record)
+			}
+		}
+		doc.Releases = releases
+		return nil
+	})
+}
+
+// Lock acquires the named lock on behalf of the holder.
+func (r documentStore) Lock(ctx context.Context, name, holder string) error {
+	record, err := json.Marshal(lock{Holder: holder, Timestamp: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	held, err := r.backend.lock(ctx, name, record)
+	if err != nil {
+		return err
+	}
+
+	if held != nil {
+		current := lock{}
+		if err := json.Unmarshal(held, &current); err != nil {
+			return err
+		}
+		return lockedError(name, current.Holder, current.Timestamp)
+	}
+	return nil
+}
+
+// Unlock releases the named lock.
+func (r documentStore) Unlock(ctx context.Context, name string) error {
+	return r.backend.unlock(ctx, name)
+}
+
+// decode parses the serialized document. A missing document is empty.
+func decode(rbytes []byte) (*document, error) {
+	doc := &document{}
+	if len(rbytes) > 0 {
+		if err := json.Unmarshal(rbytes, doc); err != nil {
+			return nil, err
+		}
+	}
+
+	// documents written before the history and batches were kept lack them
+	// therefore every list is initialized after decoding.
+	if doc.Migrations == nil {
+		doc.Migrations = make([]Migration, 0)
+	}
+	if doc.History == nil {
+		doc.History = make([]Event, 0)
+	}
+	if doc.Batches == nil {
+		doc.Batches = make([]Batch, 0)
+	}
+	if doc.Releases == nil {
+		doc.Releases = make([]Release, 0)
+	}
+	return doc, nil
+}
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// File implements a store which keeps the records in a local json file. Locks
+// are kept in files next to it, e.g. `state.json.migrations.lock`.
+type File struct {
+	documentStore
+}
+
+var _ Store = new(File)
+
+// NewFile creates a store kept in the file at path. The file is created once
+// the first record is inserted.
+func NewFile(path string) *File {
+	return &File{documentStore{fileBackend{path: path}}}
+}
+
+// fileBackend implements the document store primitives on top of the local
+// file system.
+type fileBackend struct {
+	path string
+}
+
+// read returns the content of the file or nil when it does not exist.
+func (r fileBackend) read(ctx context.Context) ([]byte, error) {
+	rbytes, err := ioutil.ReadFile(r.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return rbytes, err
+}
+
+// modify replaces the content of the file. The new content is written to a
+// temporary file first and renamed over so that the file is never left half
+// written.
+func (r fileBackend) modify(ctx context.Context, fn func([]byte) ([]byte, error)) error {
+	rbytes, err := r.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rbytes, err = fn(rbytes); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(r.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(rbytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// lock creates the lock file exclusively. The content of the existing lock
+// file is returned when the lock is already held.
+func (r fileBackend) lock(ctx context.Context, name string, record []byte) ([]byte, error) {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return nil, err
+	}
+
+	fd, err := os.OpenFile(r.lockPath(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return ioutil.ReadFile(r.lockPath(name))
+	} else if err != nil {
+		return nil, err
+	}
+
+	if _, err := fd.Write(record); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return nil, fd.Close()
+}
+
+// unlock removes the lock file.
+func (r fileBackend) unlock(ctx context.Context, name string) error {
+	if err := os.Remove(r.lockPath(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// lockPath returns the path of the named lock file.
+func (r fileBackend) lockPath(name string) string {
+	return r.path + "." + name + ".lock"
+}
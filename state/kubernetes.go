@@ -0,0 +1,240 @@
+package state
+
+import (
+	"context"
+
+	v1core "k8s.io/api/core/v1"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// stateKey defines the data key holding the serialized document.
+const stateKey = "state.json"
+
+// lockKey defines the data key holding the serialized lock record.
+const lockKey = "lock.json"
+
+// Kubernetes implements a store which keeps the records in a kubernetes
+// config map or secret. Locks are kept in objects of the same kind named
+// after the store and the lock, e.g. `migrate-state-migrations-lock`.
+type Kubernetes struct {
+	documentStore
+}
+
+var _ Store = new(Kubernetes)
+
+// NewConfigMap creates a store kept in the config map with name.
+func NewConfigMap(client kubernetes.Interface, namespace, name string) *Kubernetes {
+	objects := configMaps{client: client, namespace: namespace}
+	return &Kubernetes{documentStore{kubeBackend{objects: objects, name: name}}}
+}
+
+// NewSecret creates a store kept in the secret with name.
+func NewSecret(client kubernetes.Interface, namespace, name string) *Kubernetes {
+	objects := secrets{client: client, namespace: namespace}
+	return &Kubernetes{documentStore{kubeBackend{objects: objects, name: name}}}
+}
+
+// kubeObject defines the data and version of a config map or secret.
+type kubeObject struct {
+	data            map[string][]byte
+	resourceVersion string
+}
+
+// kubeObjects abstracts the config map and secret apis.
+type kubeObjects interface {
+	get(ctx context.Context, name string) (*kubeObject, error)
+	create(ctx context.Context, name string, object *kubeObject) error
+	update(ctx context.Context, name string, object *kubeObject) error
+	delete(ctx context.Context, name string) error
+}
+
+// kubeBackend implements the document store primitives on top of kubernetes
+// objects.
+type kubeBackend struct {
+	objects kubeObjects
+	name    string
+}
+
+// read returns the serialized document or nil when the object does not exist.
+func (r kubeBackend) read(ctx context.Context) ([]byte, error) {
+	object, err := r.objects.get(ctx, r.name)
+	if v1err.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return object.data[stateKey], nil
+}
+
+// modify replaces the serialized document. Concurrent modifications are
+// detected by the object resource version and retried.
+func (r kubeBackend) modify(ctx context.Context, fn func([]byte) ([]byte, error)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		object, err := r.objects.get(ctx, r.name)
+		if v1err.IsNotFound(err) {
+			rbytes, err := fn(nil)
+			if err != nil {
+				return err
+			}
+			object = &kubeObject{data: map[string][]byte{stateKey: rbytes}}
+			return r.objects.create(ctx, r.name, object)
+		} else if err != nil {
+			return err
+		}
+
+		rbytes, err := fn(object.data[stateKey])
+		if err != nil {
+			return err
+		}
+		if object.data == nil {
+			object.data = make(map[string][]byte)
+		}
+		object.data[stateKey] = rbytes
+		return r.objects.update(ctx, r.name, object)
+	})
+}
+
+// lock creates the lock object. The lock record of the existing object is
+// returned when the lock is already held.
+func (r kubeBackend) lock(ctx context.Context, name string, record []byte) ([]byte, error) {
+	object := &kubeObject{data: map[string][]byte{lockKey: record}}
+	err := r.objects.create(ctx, r.lockName(name), object)
+	if v1err.IsAlreadyExists(err) {
+		held, err := r.objects.get(ctx, r.lockName(name))
+		if err != nil {
+			return nil, err
+		}
+		return held.data[lockKey], nil
+	}
+	return nil, err
+}
+
+// unlock deletes the lock object.
+func (r kubeBackend) unlock(ctx context.Context, name string) error {
+	err := r.objects.delete(ctx, r.lockName(name))
+	if err != nil && !v1err.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// lockName returns the name of the named lock object.
+func (r kubeBackend) lockName(name string) string {
+	return r.name + "-" + name + "-lock"
+}
+
+// configMaps implements the kubernetes objects on top of config maps.
+type configMaps struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func (r configMaps) get(ctx context.Context, name string) (*kubeObject, error) {
+	configMap, err := r.client.CoreV1().
+		ConfigMaps(r.namespace).
+		Get(ctx, name, v1meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string][]byte, len(configMap.Data))
+	for key, value := range configMap.Data {
+		data[key] = []byte(value)
+	}
+	return &kubeObject{data: data, resourceVersion: configMap.ResourceVersion}, nil
+}
+
+func (r configMaps) create(ctx context.Context, name string, object *kubeObject) error {
+	_, err := r.client.CoreV1().
+		ConfigMaps(r.namespace).
+		Create(ctx, r.configMap(name, object), v1meta.CreateOptions{})
+	return err
+}
+
+func (r configMaps) update(ctx context.Context, name string, object *kubeObject) error {
+	_, err := r.client.CoreV1().
+		ConfigMaps(r.namespace).
+		Update(ctx, r.configMap(name, object), v1meta.UpdateOptions{})
+	return err
+}
+
+func (r configMaps) delete(ctx context.Context, name string) error {
+	return r.client.CoreV1().
+		ConfigMaps(r.namespace).
+		Delete(ctx, name, v1meta.DeleteOptions{})
+}
+
+func (r configMaps) configMap(name string, object *kubeObject) *v1core.ConfigMap {
+	data := make(map[string]string, len(object.data))
+	for key, value := range object.data {
+		data[key] = string(value)
+	}
+
+	return &v1core.ConfigMap{
+		TypeMeta: v1meta.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:            name,
+			Namespace:       r.namespace,
+			ResourceVersion: object.resourceVersion,
+		},
+		Data: data,
+	}
+}
+
+// secrets implements the kubernetes objects on top of secrets.
+type secrets struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+func (r secrets) get(ctx context.Context, name string) (*kubeObject, error) {
+	secret, err := r.client.CoreV1().
+		Secrets(r.namespace).
+		Get(ctx, name, v1meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &kubeObject{data: secret.Data, resourceVersion: secret.ResourceVersion}, nil
+}
+
+func (r secrets) create(ctx context.Context, name string, object *kubeObject) error {
+	_, err := r.client.CoreV1().
+		Secrets(r.namespace).
+		Create(ctx, r.secret(name, object), v1meta.CreateOptions{})
+	return err
+}
+
+func (r secrets) update(ctx context.Context, name string, object *kubeObject) error {
+	_, err := r.client.CoreV1().
+		Secrets(r.namespace).
+		Update(ctx, r.secret(name, object), v1meta.UpdateOptions{})
+	return err
+}
+
+func (r secrets) delete(ctx context.Context, name string) error {
+	return r.client.CoreV1().
+		Secrets(r.namespace).
+		Delete(ctx, name, v1meta.DeleteOptions{})
+}
+
+func (r secrets) secret(name string, object *kubeObject) *v1core.Secret {
+	return &v1core.Secret{
+		TypeMeta: v1meta.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:            name,
+			Namespace:       r.namespace,
+			ResourceVersion: object.resourceVersion,
+		},
+		Type: v1core.SecretTypeOpaque,
+		Data: object.data,
+	}
+}
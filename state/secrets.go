@@ -0,0 +1,159 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	v1core "k8s.io/api/core/v1"
+	v1err "k8s.io/apimachinery/pkg/api/errors"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Labels set on the secrets holding release revisions.
+const (
+	ReleaseLabel  = "migrate.trivigy.com/release"
+	RevisionLabel = "migrate.trivigy.com/revision"
+	StatusLabel   = "migrate.trivigy.com/status"
+)
+
+// releaseKey defines the data key holding the serialized release revision.
+const releaseKey = "release.json"
+
+// releaseSecretType defines the type of the secrets holding release
+// revisions.
+const releaseSecretType = "migrate.trivigy.com/release.v1"
+
+// ReleaseSecrets implements a release store which keeps every revision of a
+// release in its own secret, labelled by the release name, the revision and
+// the status. No database is required for tracking releases this way.
+type ReleaseSecrets struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+var _ ReleaseStore = new(ReleaseSecrets)
+
+// NewReleaseSecrets creates a release store kept in secrets of the namespace.
+func NewReleaseSecrets(client kubernetes.Interface, namespace string) *ReleaseSecrets {
+	return &ReleaseSecrets{client: client, namespace: namespace}
+}
+
+// Releases returns the records of every release revision.
+func (r *ReleaseSecrets) Releases(ctx context.Context) ([]Release, error) {
+	list, err := r.client.CoreV1().
+		Secrets(r.namespace).
+		List(ctx, v1meta.ListOptions{LabelSelector: ReleaseLabel})
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(list.Items))
+	for _, secret := range list.Items {
+		release := Release{}
+		if err := json.Unmarshal(secret.Data[releaseKey], &release); err != nil {
+			return nil, fmt.Errorf("invalid release secret %q (%s)", secret.Name, err)
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+// InsertRelease records a new release revision.
+func (r *ReleaseSecrets) InsertRelease(ctx context.Context, release Release) error {
+	secret, err := r.secret(release)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.CoreV1().
+		Secrets(r.namespace).
+		Create(ctx, secret, v1meta.CreateOptions{})
+	if v1err.IsAlreadyExists(err) {
+		return fmt.Errorf("release %q revision %d already recorded",
+			release.Name, release.Revision,
+		)
+	}
+	return err
+}
+
+// UpdateRelease replaces the record of an existing release revision.
+func (r *ReleaseSecrets) UpdateRelease(ctx context.Context, release Release) error {
+	secret, err := r.secret(release)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.CoreV1().
+		Secrets(r.namespace).
+		Update(ctx, secret, v1meta.UpdateOptions{})
+	if v1err.IsNotFound(err) {
+		return fmt.Errorf("release %q revision %d not recorded",
+			release.Name, release.Revision,
+		)
+	}
+	return err
+}
+
+// DeleteRelease removes the record of the release revision.
+func (r *ReleaseSecrets) DeleteRelease(ctx context.Context, name string, revision int) error {
+	err := r.client.CoreV1().
+		Secrets(r.namespace).
+		Delete(ctx, secretName(name, revision), v1meta.DeleteOptions{})
+	if err != nil && !v1err.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Lock acquires the named lock on behalf of the holder. Locks are kept in
+// secrets of the namespace named after the lock, e.g.
+// `migrate-releases-lock`.
+func (r *ReleaseSecrets) Lock(ctx context.Context, name, holder string) error {
+	return r.locks().Lock(ctx, name, holder)
+}
+
+// Unlock releases the named lock.
+func (r *ReleaseSecrets) Unlock(ctx context.Context, name string) error {
+	return r.locks().Unlock(ctx, name)
+}
+
+// locks returns the store whose lock operations are shared with the
+// kubernetes store.
+func (r *ReleaseSecrets) locks() documentStore {
+	objects := secrets{client: r.client, namespace: r.namespace}
+	return documentStore{kubeBackend{objects: objects, name: "migrate"}}
+}
+
+// secret returns the secret holding the release revision.
+func (r *ReleaseSecrets) secret(release Release) (*v1core.Secret, error) {
+	rbytes, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1core.Secret{
+		TypeMeta: v1meta.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      secretName(release.Name, release.Revision),
+			Namespace: r.namespace,
+			Labels: map[string]string{
+				ReleaseLabel:  release.Name,
+				RevisionLabel: strconv.Itoa(release.Revision),
+				StatusLabel:   release.Status,
+			},
+		},
+		Type: releaseSecretType,
+		Data: map[string][]byte{releaseKey: rbytes},
+	}, nil
+}
+
+// secretName returns the name of the secret holding the release revision.
+func secretName(name string, revision int) string {
+	return fmt.Sprintf("migrate.release.%s.v%d", name, revision)
+}
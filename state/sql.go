@@ -0,0 +1,304 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/store/model"
+)
+
+// SQL implements a store which keeps the records in bookkeeping tables of the
+// target database itself.
+type SQL struct {
+	db    *store.Context
+	owned bool
+}
+
+var _ interface {
+	Store
+	BatchTx
+} = new(SQL)
+
+// NewSQL creates a store on top of an already open database connection. The
+// connection remains owned by the caller.
+func NewSQL(db *store.Context) *SQL {
+	return &SQL{db: db}
+}
+
+// OpenSQL opens a new database connection and creates a store on top of it.
+func OpenSQL(driver, source string) (*SQL, error) {
+	db, err := store.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	return &SQL{db: db, owned: true}, nil
+}
+
+// Close terminates the database connection when it was opened by the store.
+func (r *SQL) Close() error {
+	if !r.owned {
+		return nil
+	}
+	return r.db.Close()
+}
+
+// Migrations returns the records of the applied migrations.
+func (r *SQL) Migrations(ctx context.Context) ([]Migration, error) {
+	if err := r.db.Migrations.CreateTableIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	records, err := r.db.Migrations.GetMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(records))
+	for _, record := range records {
+		migrations = append(migrations, Migration(record))
+	}
+	return migrations, nil
+}
+
+// InsertMigration records the migration as applied.
+func (r *SQL) InsertMigration(ctx context.Context, migration Migration) error {
+	if err := r.db.Migrations.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+
+	record := model.Migration(migration)
+	return r.db.Migrations.Insert(&record)
+}
+
+// DeleteMigration removes the record of the migration with tag.
+func (r *SQL) DeleteMigration(ctx context.Context, tag string) error {
+	if err := r.db.Migrations.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return r.db.Migrations.Delete(&model.Migration{Tag: tag})
+}
+
+// History returns every event of the migrations history in the order they
+// were recorded.
+func (r *SQL) History(ctx context.Context) ([]Event, error) {
+	if err := r.db.History.CreateTableIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	records, err := r.db.History.GetHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(records))
+	for _, record := range records {
+		events = append(events, toEvent(record))
+	}
+	return events, nil
+}
+
+// LatestEvent returns the most recent event of the migration with tag or nil
+// when the migration has no history.
+func (r *SQL) LatestEvent(ctx context.Context, tag string) (*Event, error) {
+	if err := r.db.History.CreateTableIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	record, err := r.db.History.GetLatest(tag)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	event := toEvent(*record)
+	return &event, nil
+}
+
+// InsertEvent appends the event to the migrations history.
+func (r *SQL) InsertEvent(ctx context.Context, event Event) error {
+	if err := r.db.History.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+
+	return r.db.History.Insert(&model.History{
+		Tag:       event.Tag,
+		Name:      event.Name,
+		Action:    event.Action,
+		Direction: event.Direction,
+		Actor:     event.Actor,
+		Duration:  int64(event.Duration / time.Millisecond),
+		Error:     event.Error,
+		Timestamp: event.Timestamp,
+	})
+}
+
+// Batch returns the progress record of the batched operation with key or nil
+// when the operation is not in progress.
+func (r *SQL) Batch(ctx context.Context, key string) (*Batch, error) {
+	if err := r.db.Batches.CreateTableIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	record, err := r.db.Batches.Get(key)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	batch := Batch(*record)
+	return &batch, nil
+}
+
+// SaveBatch inserts or replaces the progress record of the batched operation.
+func (r *SQL) SaveBatch(ctx context.Context, batch Batch) error {
+	if err := r.db.Batches.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return sqlBatches{db: r.db, executor: r.db.GetDBMap().Db}.SaveBatch(ctx, batch)
+}
+
+// DeleteBatch removes the progress record of the batched operation with key.
+func (r *SQL) DeleteBatch(ctx context.Context, key string) error {
+	if err := r.db.Batches.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return sqlBatches{db: r.db, executor: r.db.GetDBMap().Db}.DeleteBatch(ctx, key)
+}
+
+// BatchTx returns the store recording progress through the executor when the
+// records are kept on the database of the context.
+func (r *SQL) BatchTx(db *store.Context, executor store.Executor) (BatchStore, bool) {
+	if db != r.db {
+		return nil, false
+	}
+	return sqlBatches{db: r.db, executor: executor}, true
+}
+
+// sqlBatches implements the batch store operations through an executor. The
+// progress table must already exist since it is never created within a
+// transaction.
+type sqlBatches struct {
+	db       *store.Context
+	executor store.Executor
+}
+
+// Batch returns the progress record of the batched operation with key or nil
+// when the operation is not in progress.
+func (r sqlBatches) Batch(ctx context.Context, key string) (*Batch, error) {
+	return (&SQL{db: r.db}).Batch(ctx, key)
+}
+
+// SaveBatch inserts or replaces the progress record of the batched operation.
+func (r sqlBatches) SaveBatch(ctx context.Context, batch Batch) error {
+	record := model.Batch(batch)
+	return r.db.Batches.Save(ctx, r.executor, &record)
+}
+
+// DeleteBatch removes the progress record of the batched operation with key.
+func (r sqlBatches) DeleteBatch(ctx context.Context, key string) error {
+	return r.db.Batches.Remove(ctx, r.executor, key)
+}
+
+// Releases returns the records of every release revision.
+func (r *SQL) Releases(ctx context.Context) ([]Release, error) {
+	if err := r.db.Releases.CreateTableIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	records, err := r.db.Releases.GetReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]Release, 0, len(records))
+	for _, record := range records {
+		releases = append(releases, Release{
+			Name:      record.Name,
+			Revision:  record.Revision,
+			Version:   record.Tag,
+			Status:    record.Status,
+			Digest:    record.Digest,
+			Timestamp: record.Timestamp,
+		})
+	}
+	return releases, nil
+}
+
+// InsertRelease records a new release revision.
+func (r *SQL) InsertRelease(ctx context.Context, release Release) error {
+	if err := r.db.Releases.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return r.db.Releases.Insert(toRecord(release))
+}
+
+// UpdateRelease replaces the record of an existing release revision.
+func (r *SQL) UpdateRelease(ctx context.Context, release Release) error {
+	if err := r.db.Releases.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return r.db.Releases.Update(toRecord(release))
+}
+
+// DeleteRelease removes the record of the release revision.
+func (r *SQL) DeleteRelease(ctx context.Context, name string, revision int) error {
+	if err := r.db.Releases.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return r.db.Releases.Delete(&model.Release{Name: name, Revision: revision})
+}
+
+// Lock acquires the named lock on behalf of the holder.
+func (r *SQL) Lock(ctx context.Context, name, holder string) error {
+	if err := r.db.Locks.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+
+	err := r.db.Locks.Insert(&model.Lock{
+		Name:      name,
+		Holder:    holder,
+		Timestamp: time.Now(),
+	})
+	if err == nil {
+		return nil
+	}
+
+	record, gerr := r.db.Locks.Get(name)
+	if gerr != nil || record == nil {
+		return err
+	}
+	return lockedError(name, record.Holder, record.Timestamp)
+}
+
+// Unlock releases the named lock.
+func (r *SQL) Unlock(ctx context.Context, name string) error {
+	if err := r.db.Locks.CreateTableIfNotExists(); err != nil {
+		return err
+	}
+	return r.db.Locks.Delete(&model.Lock{Name: name})
+}
+
+// toEvent converts the history table record into an event.
+func toEvent(record model.History) Event {
+	return Event{
+		Tag:       record.Tag,
+		Name:      record.Name,
+		Action:    record.Action,
+		Direction: record.Direction,
+		Actor:     record.Actor,
+		Duration:  time.Duration(record.Duration) * time.Millisecond,
+		Error:     record.Error,
+		Timestamp: record.Timestamp,
+	}
+}
+
+// toRecord converts the release into a releases table record.
+func toRecord(release Release) *model.Release {
+	return &model.Release{
+		Name:      release.Name,
+		Revision:  release.Revision,
+		Tag:       release.Version,
+		Status:    release.Status,
+		Digest:    release.Digest,
+		Timestamp: release.Timestamp,
+	}
+}
@@ -0,0 +1,146 @@
+// Package state implements pluggable stores for the bookkeeping records of
+// applied migrations, installed releases and the locks guarding them.
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/trivigy/migrate/v2/global"
+	"github.com/trivigy/migrate/v2/internal/store"
+)
+
+// ErrLocked is returned when acquiring a lock which is already held.
+var ErrLocked = errors.New("state is locked")
+
+// Store defines the bookkeeping operations every state backend implements.
+type Store interface {
+	MigrationStore
+	HistoryStore
+	BatchStore
+	ReleaseStore
+	Locker
+}
+
+// MigrationStore defines the bookkeeping operations of applied migrations.
+type MigrationStore interface {
+	Migrations(ctx context.Context) ([]Migration, error)
+	InsertMigration(ctx context.Context, migration Migration) error
+	DeleteMigration(ctx context.Context, tag string) error
+}
+
+// HistoryStore defines the bookkeeping operations of the append-only
+// migrations history.
+type HistoryStore interface {
+	History(ctx context.Context) ([]Event, error)
+	LatestEvent(ctx context.Context, tag string) (*Event, error)
+	InsertEvent(ctx context.Context, event Event) error
+}
+
+// BatchStore defines the bookkeeping operations of the progress of batched
+// operations which are in progress.
+type BatchStore interface {
+	Batch(ctx context.Context, key string) (*Batch, error)
+	SaveBatch(ctx context.Context, batch Batch) error
+	DeleteBatch(ctx context.Context, key string) error
+}
+
+// BatchTx is implemented by stores which keep the records on the migrated
+// database itself. The returned store records progress through the executor,
+// i.e. within the transaction of the batch, and is only available when the
+// records are kept on the database of the context.
+type BatchTx interface {
+	BatchTx(db *store.Context, executor store.Executor) (BatchStore, bool)
+}
+
+// ReleaseStore defines the bookkeeping operations of release revisions.
+type ReleaseStore interface {
+	Releases(ctx context.Context) ([]Release, error)
+	InsertRelease(ctx context.Context, release Release) error
+	UpdateRelease(ctx context.Context, release Release) error
+	DeleteRelease(ctx context.Context, name string, revision int) error
+	Locker
+}
+
+// Locker defines the operations of named locks guarding the records.
+type Locker interface {
+	Lock(ctx context.Context, name, holder string) error
+	Unlock(ctx context.Context, name string) error
+}
+
+// Statuses of release revisions.
+const (
+	StatusDeployed    = "deployed"
+	StatusFailed      = "failed"
+	StatusSuperseded  = "superseded"
+	StatusUninstalled = "uninstalled"
+)
+
+// Migration defines the record of an applied migration.
+type Migration struct {
+	Tag       string    `json:"tag" yaml:"tag"`
+	Name      string    `json:"name" yaml:"name"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// Event defines a single record of the migrations history.
+type Event struct {
+	Tag       string        `json:"tag" yaml:"tag"`
+	Name      string        `json:"name" yaml:"name"`
+	Action    string        `json:"action" yaml:"action"`
+	Direction string        `json:"direction" yaml:"direction"`
+	Actor     string        `json:"actor" yaml:"actor"`
+	Duration  time.Duration `json:"duration" yaml:"duration"`
+	Error     string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Timestamp time.Time     `json:"timestamp" yaml:"timestamp"`
+}
+
+// Batch defines the progress record of a batched operation. A record exists
+// only while the batched operation is in progress.
+type Batch struct {
+	Key       string    `json:"key" yaml:"key"`
+	Batch     int64     `json:"batch" yaml:"batch"`
+	Rows      int64     `json:"rows" yaml:"rows"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// Release defines the record of a single release revision. Every install,
+// update and uninstall of a release is recorded as a new revision.
+type Release struct {
+	Name      string    `json:"name" yaml:"name"`
+	Revision  int       `json:"revision" yaml:"revision"`
+	Version   string    `json:"version" yaml:"version"`
+	Status    string    `json:"status" yaml:"status"`
+	Digest    string    `json:"digest" yaml:"digest"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// lock defines the record of a held lock.
+type lock struct {
+	Holder    string    `json:"holder"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Actor returns the name of whoever is running the command. The MIGRATE_ACTOR
+// environment variable takes precedence over the operating system user.
+func Actor() string {
+	if name := os.Getenv("MIGRATE_ACTOR"); name != "" {
+		return name
+	}
+
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return global.UnknownStr
+}
+
+// lockedError returns the error describing who holds the named lock.
+func lockedError(name string, holder string, since time.Time) error {
+	return fmt.Errorf("%w (%q held by %s since %s)",
+		ErrLocked, name, holder, since.Format(time.RFC3339),
+	)
+}
@@ -0,0 +1,227 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type StateSuite struct {
+	suite.Suite
+	Dir string
+}
+
+func (r *StateSuite) SetupTest() {
+	dir, err := ioutil.TempDir(os.TempDir(), "migrate-state-*")
+	if err != nil {
+		panic(err)
+	}
+	r.Dir = dir
+}
+
+func (r *StateSuite) TearDownTest() {
+	assert.Nil(r.T(), os.RemoveAll(r.Dir))
+}
+
+func (r *StateSuite) stores() map[string]Store {
+	sqlStore, err := OpenSQL("sqlite3", "sqlite3://"+filepath.Join(r.Dir, "state.db"))
+	if err != nil {
+		panic(err)
+	}
+	r.T().Cleanup(func() { sqlStore.Close() })
+
+	return map[string]Store{
+		"sql":       sqlStore,
+		"file":      NewFile(filepath.Join(r.Dir, "state", "state.json")),
+		"configmap": NewConfigMap(fake.NewSimpleClientset(), "default", "migrate-state"),
+		"secret":    NewSecret(fake.NewSimpleClientset(), "default", "migrate-state"),
+	}
+}
+
+func (r *StateSuite) TestMigrations() {
+	ctx := context.Background()
+	timestamp := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	for name, store := range r.stores() {
+		failMsg := fmt.Sprintf("store: %s", name)
+
+		migrations, err := store.Migrations(ctx)
+		assert.Nil(r.T(), err, failMsg)
+		assert.Empty(r.T(), migrations, failMsg)
+
+		for _, tag := range []string{"0.0.1", "0.0.2"} {
+			err := store.InsertMigration(ctx, Migration{Tag: tag, Name: "m" + tag, Timestamp: timestamp})
+			assert.Nil(r.T(), err, failMsg)
+		}
+		assert.NotNil(r.T(), store.InsertMigration(ctx, Migration{Tag: "0.0.1"}), failMsg)
+		assert.Nil(r.T(), store.DeleteMigration(ctx, "0.0.1"), failMsg)
+
+		migrations, err = store.Migrations(ctx)
+		assert.Nil(r.T(), err, failMsg)
+		assert.Len(r.T(), migrations, 1, failMsg)
+		assert.Equal(r.T(), "0.0.2", migrations[0].Tag, failMsg)
+		assert.Equal(r.T(), "m0.0.2", migrations[0].Name, failMsg)
+		assert.True(r.T(), timestamp.Equal(migrations[0].Timestamp), failMsg)
+	}
+}
+
+func (r *StateSuite) TestHistory() {
+	ctx := context.Background()
+	timestamp := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	for name, store := range r.stores() {
+		failMsg := fmt.Sprintf("store: %s", name)
+
+		latest, err := store.LatestEvent(ctx, "0.0.1")
+		assert.Nil(r.T(), err, failMsg)
+		assert.Nil(r.T(), latest, failMsg)
+
+		for _, action := range []string{"failure", "retry"} {
+			err := store.InsertEvent(ctx, Event{
+				Tag:       "0.0.1",
+				Name:      "m0.0.1",
+				Action:    action,
+				Direction: "up",
+				Actor:     "alice",
+				Duration:  1500 * time.Millisecond,
+				Timestamp: timestamp,
+			})
+			assert.Nil(r.T(), err, failMsg)
+		}
+		assert.Nil(r.T(), store.InsertEvent(ctx, Event{Tag: "0.0.2", Action: "up"}), failMsg)
+
+		latest, err = store.LatestEvent(ctx, "0.0.1")
+		assert.Nil(r.T(), err, failMsg)
+		assert.Equal(r.T(), "retry", latest.Action, failMsg)
+		assert.Equal(r.T(), 1500*time.Millisecond, latest.Duration, failMsg)
+		assert.True(r.T(), timestamp.Equal(latest.Timestamp), failMsg)
+
+		history, err := store.History(ctx)
+		assert.Nil(r.T(), err, failMsg)
+		assert.Len(r.T(), history, 3, failMsg)
+		assert.Equal(r.T(), "failure", history[0].Action, failMsg)
+		assert.Equal(r.T(), "0.0.2", history[2].Tag, failMsg)
+	}
+}
+
+func (r *StateSuite) TestBatches() {
+	ctx := context.Background()
+	for name, store := range r.stores() {
+		failMsg := fmt.Sprintf("store: %s", name)
+
+		batch, err := store.Batch(ctx, "backfill")
+		assert.Nil(r.T(), err, failMsg)
+		assert.Nil(r.T(), batch, failMsg)
+
+		for i := int64(1); i <= 2; i++ {
+			err := store.SaveBatch(ctx, Batch{Key: "backfill", Batch: i, Rows: i * 100})
+			assert.Nil(r.T(), err, failMsg)
+		}
+
+		batch, err = store.Batch(ctx, "backfill")
+		assert.Nil(r.T(), err, failMsg)
+		assert.Equal(r.T(), int64(2), batch.Batch, failMsg)
+		assert.Equal(r.T(), int64(200), batch.Rows, failMsg)
+
+		assert.Nil(r.T(), store.DeleteBatch(ctx, "backfill"), failMsg)
+		batch, err = store.Batch(ctx, "backfill")
+		assert.Nil(r.T(), err, failMsg)
+		assert.Nil(r.T(), batch, failMsg)
+	}
+}
+
+func (r *StateSuite) TestReleases() {
+	ctx := context.Background()
+	stores := map[string]ReleaseStore{
+		"secrets": NewReleaseSecrets(fake.NewSimpleClientset(), "default"),
+	}
+	for name, store := range r.stores() {
+		stores[name] = store
+	}
+
+	for name, store := range stores {
+		failMsg := fmt.Sprintf("store: %s", name)
+
+		for i, version := range []string{"1.0.0", "1.1.0"} {
+			err := store.InsertRelease(ctx, Release{
+				Name:     "api",
+				Revision: i + 1,
+				Version:  version,
+				Status:   StatusDeployed,
+				Digest:   "sha256:" + version,
+			})
+			assert.Nil(r.T(), err, failMsg)
+		}
+		assert.NotNil(r.T(), store.InsertRelease(ctx, Release{Name: "api", Revision: 1}), failMsg)
+
+		err := store.UpdateRelease(ctx, Release{
+			Name:     "api",
+			Revision: 1,
+			Version:  "1.0.0",
+			Status:   StatusSuperseded,
+			Digest:   "sha256:1.0.0",
+		})
+		assert.Nil(r.T(), err, failMsg)
+		assert.Nil(r.T(), store.DeleteRelease(ctx, "api", 2), failMsg)
+
+		releases, err := store.Releases(ctx)
+		assert.Nil(r.T(), err, failMsg)
+		assert.Len(r.T(), releases, 1, failMsg)
+		assert.Equal(r.T(), "api", releases[0].Name, failMsg)
+		assert.Equal(r.T(), 1, releases[0].Revision, failMsg)
+		assert.Equal(r.T(), "1.0.0", releases[0].Version, failMsg)
+		assert.Equal(r.T(), StatusSuperseded, releases[0].Status, failMsg)
+		assert.Equal(r.T(), "sha256:1.0.0", releases[0].Digest, failMsg)
+	}
+}
+
+func (r *StateSuite) TestReleaseSecrets() {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	store := NewReleaseSecrets(client, "default")
+	assert.Nil(r.T(), store.InsertRelease(ctx, Release{Name: "api", Revision: 3, Status: StatusFailed}))
+
+	secret, err := client.CoreV1().Secrets("default").Get(ctx, "migrate.release.api.v3", v1meta.GetOptions{})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), map[string]string{
+		ReleaseLabel:  "api",
+		RevisionLabel: "3",
+		StatusLabel:   StatusFailed,
+	}, secret.Labels)
+}
+
+func (r *StateSuite) TestLock() {
+	ctx := context.Background()
+	lockers := map[string]Locker{
+		"secrets": NewReleaseSecrets(fake.NewSimpleClientset(), "default"),
+	}
+	for name, store := range r.stores() {
+		lockers[name] = store
+	}
+
+	for name, store := range lockers {
+		failMsg := fmt.Sprintf("store: %s", name)
+
+		assert.Nil(r.T(), store.Lock(ctx, "migrations", "alice"), failMsg)
+		err := store.Lock(ctx, "migrations", "bob")
+		assert.True(r.T(), errors.Is(err, ErrLocked), failMsg)
+		assert.Contains(r.T(), err.Error(), `"migrations" held by alice`, failMsg)
+
+		assert.Nil(r.T(), store.Lock(ctx, "releases", "bob"), failMsg)
+		assert.Nil(r.T(), store.Unlock(ctx, "migrations"), failMsg)
+		assert.Nil(r.T(), store.Unlock(ctx, "migrations"), failMsg)
+		assert.Nil(r.T(), store.Lock(ctx, "migrations", "bob"), failMsg)
+	}
+}
+
+func TestStateSuite(t *testing.T) {
+	suite.Run(t, new(StateSuite))
+}
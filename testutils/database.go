@@ -2,15 +2,19 @@ package testutils
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
 // Database implements a test database driver.
 type Database struct {
 	Migrations *types.Migrations `json:"migrations" yaml:"migrations"`
+	Seeds      *types.Seeds      `json:"seeds" yaml:"seeds"`
+	State      state.Store       `json:"state" yaml:"state"`
 	Driver     interface {
 		driver.WithCreate
 		driver.WithDestroy
@@ -23,16 +27,27 @@ func (r Database) Build() interface {
 	driver.WithCreate
 	driver.WithDestroy
 	driver.WithMigrations
+	driver.WithSeeds
+	driver.WithSnapshots
 	driver.WithSource
 } {
-	return &databaseImpl{
+	impl := &databaseImpl{
 		migrations: r.Migrations,
+		seeds:      r.Seeds,
 		driver:     r.Driver,
 	}
+
+	// the state capability is detected by type therefore it is only present
+	// when a state store was configured.
+	if r.State != nil {
+		return &statefulDatabaseImpl{databaseImpl: impl, state: r.State}
+	}
+	return impl
 }
 
 type databaseImpl struct {
 	migrations *types.Migrations
+	seeds      *types.Seeds
 	driver     interface {
 		driver.WithCreate
 		driver.WithDestroy
@@ -44,6 +59,8 @@ var _ interface {
 	driver.WithCreate
 	driver.WithDestroy
 	driver.WithMigrations
+	driver.WithSeeds
+	driver.WithSnapshots
 	driver.WithSource
 } = new(databaseImpl)
 
@@ -51,6 +68,10 @@ func (r databaseImpl) Migrations() *types.Migrations {
 	return r.migrations
 }
 
+func (r databaseImpl) Seeds() *types.Seeds {
+	return r.seeds
+}
+
 // Create executes the resource creation process.
 func (r databaseImpl) Create(ctx context.Context, out io.Writer) error {
 	return r.driver.Create(ctx, out)
@@ -65,3 +86,59 @@ func (r databaseImpl) Destroy(ctx context.Context, out io.Writer) error {
 func (r databaseImpl) Source(ctx context.Context, out io.Writer) error {
 	return r.driver.Source(ctx, out)
 }
+
+// Snapshot takes a point-in-time copy of the database.
+func (r databaseImpl) Snapshot(ctx context.Context, out io.Writer, name string) error {
+	snapshots, err := r.snapshots()
+	if err != nil {
+		return err
+	}
+	return snapshots.Snapshot(ctx, out, name)
+}
+
+// Snapshots returns the list of available database snapshots.
+func (r databaseImpl) Snapshots(ctx context.Context) (types.Snapshots, error) {
+	snapshots, err := r.snapshots()
+	if err != nil {
+		return nil, err
+	}
+	return snapshots.Snapshots(ctx)
+}
+
+// RestoreSnapshot replaces the database with the content of the snapshot.
+func (r databaseImpl) RestoreSnapshot(ctx context.Context, out io.Writer, name string) error {
+	snapshots, err := r.snapshots()
+	if err != nil {
+		return err
+	}
+	return snapshots.RestoreSnapshot(ctx, out, name)
+}
+
+// DeleteSnapshot removes the snapshot.
+func (r databaseImpl) DeleteSnapshot(ctx context.Context, out io.Writer, name string) error {
+	snapshots, err := r.snapshots()
+	if err != nil {
+		return err
+	}
+	return snapshots.DeleteSnapshot(ctx, out, name)
+}
+
+type statefulDatabaseImpl struct {
+	*databaseImpl
+	state state.Store
+}
+
+var _ driver.WithState = new(statefulDatabaseImpl)
+
+// State returns the store keeping the bookkeeping records.
+func (r statefulDatabaseImpl) State(ctx context.Context) (state.Store, error) {
+	return r.state, nil
+}
+
+func (r databaseImpl) snapshots() (driver.WithSnapshots, error) {
+	snapshots, ok := r.driver.(driver.WithSnapshots)
+	if !ok {
+		return nil, fmt.Errorf("driver %T does not support snapshots", r.driver)
+	}
+	return snapshots, nil
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/trivigy/migrate/v2/driver"
+	"github.com/trivigy/migrate/v2/state"
 	"github.com/trivigy/migrate/v2/types"
 )
 
@@ -12,6 +13,9 @@ import (
 type Kubernetes struct {
 	Namespace *string         `json:"namespace" yaml:"namespace"`
 	Releases  *types.Releases `json:"releases" yaml:"releases"`
+	Values    *types.Values   `json:"values" yaml:"values"`
+	Overlay   *types.Overlay  `json:"overlay" yaml:"overlay"`
+	State     state.Store     `json:"state" yaml:"state"`
 	Driver    interface {
 		driver.WithCreate
 		driver.WithDestroy
@@ -27,16 +31,27 @@ func (r Kubernetes) Build() interface {
 	driver.WithReleases
 	driver.WithSource
 } {
-	return &kubernetesImpl{
+	impl := &kubernetesImpl{
 		namespace: r.Namespace,
 		releases:  r.Releases,
+		values:    r.Values,
+		overlay:   r.Overlay,
 		driver:    r.Driver,
 	}
+
+	// the state capability is detected by type therefore it is only present
+	// when a state store was configured.
+	if r.State != nil {
+		return &statefulKubernetesImpl{kubernetesImpl: impl, state: r.State}
+	}
+	return impl
 }
 
 type kubernetesImpl struct {
 	namespace *string
 	releases  *types.Releases
+	values    *types.Values
+	overlay   *types.Overlay
 	driver    interface {
 		driver.WithCreate
 		driver.WithDestroy
@@ -48,8 +63,10 @@ var _ interface {
 	driver.WithCreate
 	driver.WithDestroy
 	driver.WithNamespace
+	driver.WithOverlay
 	driver.WithReleases
 	driver.WithSource
+	driver.WithValues
 } = new(kubernetesImpl)
 
 func (r kubernetesImpl) Namespace() *string {
@@ -60,6 +77,14 @@ func (r kubernetesImpl) Releases() *types.Releases {
 	return r.releases
 }
 
+func (r kubernetesImpl) Values() *types.Values {
+	return r.values
+}
+
+func (r kubernetesImpl) Overlay() *types.Overlay {
+	return r.overlay
+}
+
 // Create executes the resource creation process.
 func (r kubernetesImpl) Create(ctx context.Context, out io.Writer) error {
 	return r.driver.Create(ctx, out)
@@ -74,3 +99,15 @@ func (r kubernetesImpl) Destroy(ctx context.Context, out io.Writer) error {
 func (r kubernetesImpl) Source(ctx context.Context, out io.Writer) error {
 	return r.driver.Source(ctx, out)
 }
+
+type statefulKubernetesImpl struct {
+	*kubernetesImpl
+	state state.Store
+}
+
+var _ driver.WithState = new(statefulKubernetesImpl)
+
+// State returns the store keeping the bookkeeping records.
+func (r statefulKubernetesImpl) State(ctx context.Context) (state.Store, error) {
+	return r.state, nil
+}
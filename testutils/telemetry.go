@@ -0,0 +1,54 @@
+package testutils
+
+import (
+	"context"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/trivigy/migrate/v2/global"
+)
+
+// Telemetry implements an in memory exporter of the spans and metrics
+// recorded by commands.
+type Telemetry struct {
+	recorder *tracetest.SpanRecorder
+	reader   *sdkmetric.ManualReader
+	tracer   *sdktrace.TracerProvider
+	meter    *sdkmetric.MeterProvider
+}
+
+// NewTelemetry creates a new in memory telemetry exporter.
+func NewTelemetry() *Telemetry {
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	return &Telemetry{
+		recorder: recorder,
+		reader:   reader,
+		tracer:   sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		meter:    sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+}
+
+// Context returns a copy of the parent which carries the tracer and meter
+// providers of the exporter.
+func (r *Telemetry) Context(parent context.Context) context.Context {
+	ctx := context.WithValue(parent, global.RefTracerProvider, r.tracer)
+	return context.WithValue(ctx, global.RefMeterProvider, r.meter)
+}
+
+// Spans returns the spans which have ended in the order they ended.
+func (r *Telemetry) Spans() []sdktrace.ReadOnlySpan {
+	return r.recorder.Ended()
+}
+
+// Metrics collects and returns the metrics recorded so far.
+func (r *Telemetry) Metrics(ctx context.Context) (metricdata.ResourceMetrics, error) {
+	var rm metricdata.ResourceMetrics
+	if err := r.reader.Collect(ctx, &rm); err != nil {
+		return metricdata.ResourceMetrics{}, err
+	}
+	return rm, nil
+}
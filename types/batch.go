@@ -0,0 +1,186 @@
+package types
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/trivigy/migrate/v2/internal/events"
+	"github.com/trivigy/migrate/v2/internal/interrupt"
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/state"
+)
+
+// Batch defines an operation whose query is repeated until it no longer
+// affects any rows. It is meant for large backfills which update a limited
+// number of rows at a time, e.g.
+//
+//	UPDATE users SET active = true WHERE id IN (
+//		SELECT id FROM users WHERE active IS NULL LIMIT 10000
+//	)
+//
+// Every batch runs in its own transaction unless transactions are disabled
+// for the operation. Progress is recorded on the state store so that an
+// interrupted operation resumes from the last completed batch.
+type Batch struct {
+	Pause time.Duration `json:"pause,omitempty" yaml:"pause,omitempty"`
+}
+
+// executeBatch repeatedly runs the batched query operation until a batch
+// affects zero rows. The operation stops between batches when interrupted.
+func (r Operation) executeBatch(
+	ctx context.Context,
+	db *store.Context,
+	records state.BatchStore,
+	out io.Writer,
+	kind, label string,
+) error {
+	key := batchKey(kind, label, r.Query)
+	record := &state.Batch{Key: key}
+	resumed := false
+	progress, err := records.Batch(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if progress != nil {
+		events.Notice(ctx, out, "resuming %s %s from batch %d (%d rows)\n",
+			kind, label, progress.Batch+1, progress.Rows,
+		)
+		record, resumed = progress, true
+	}
+
+	start := time.Now()
+	var rows int64
+	for {
+		// a batch is never interrupted midway, progress of the completed
+		// batches is kept so that the operation resumes later on.
+		if err := interrupt.Err(ctx); err != nil {
+			return err
+		}
+
+		var tx *sql.Tx
+		var executor store.Executor
+		conn := db.GetDBMap().Db
+		batches := records
+		if r.DisableTx {
+			executor = conn
+		} else {
+			var err error
+			if tx, err = conn.BeginTx(ctx, nil); err != nil {
+				return fmt.Errorf("transaction begin failed %s", label)
+			}
+			executor = tx
+
+			// stores keeping the records on the database itself record the
+			// progress together with the batch changes.
+			if records, ok := records.(state.BatchTx); ok {
+				if txBatches, ok := records.BatchTx(db, tx); ok {
+					batches = txBatches
+				}
+			}
+		}
+
+		batch := record.Batch + 1
+		affected, err := r.executeStep(ctx, executor, batches, record, resumed)
+		if err != nil {
+			if tx != nil {
+				if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+					return fmt.Errorf("transaction rollback failed %s", label)
+				}
+			}
+
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s query aborted %s batch %d (%s)", kind, label, batch, ctx.Err())
+			}
+			return fmt.Errorf("%s query failed %s batch %d\n%s",
+				kind, label, batch, r.Query,
+			)
+		}
+
+		if tx != nil {
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("transaction commit failed %s", label)
+			}
+		}
+
+		if affected == 0 {
+			return nil
+		}
+
+		resumed = true
+		rows += affected
+		rate := float64(rows) / time.Since(start).Seconds()
+		events.Notice(ctx, out, "batch %d of %s %s affected %d rows (%d total, %.0f rows/s)\n",
+			batch, kind, label, affected, record.Rows, rate,
+		)
+
+		select {
+		case <-time.After(r.Batch.Pause):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// executeStep runs a single batch and records the progress. The progress
+// record is removed once a batch affects zero rows.
+func (r Operation) executeStep(
+	ctx context.Context,
+	executor store.Executor,
+	batches state.BatchStore,
+	record *state.Batch,
+	exists bool,
+) (int64, error) {
+	result, err := executor.ExecContext(ctx, r.Query)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if affected == 0 {
+		if exists {
+			if err := batches.DeleteBatch(ctx, record.Key); err != nil {
+				return 0, err
+			}
+		}
+		return 0, nil
+	}
+
+	record.Batch++
+	record.Rows += affected
+	record.Timestamp = time.Now()
+	if err := batches.SaveBatch(ctx, *record); err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// batchKey returns the key of the progress record of the batched query.
+func batchKey(kind, label, query string) string {
+	return fmt.Sprintf("%s %s %x", kind, label, sha256.Sum256([]byte(query)))
+}
+
+// BatchKeys returns the keys of the progress records of the batched
+// operations of the migration applied in the direction.
+func (r Migration) BatchKeys(d Direction) []string {
+	ops := r.Up
+	if d == DirectionDown {
+		ops = r.Down
+	}
+
+	keys := make([]string, 0)
+	for _, op := range ops {
+		if op.Batch != nil {
+			keys = append(keys, batchKey("migration", r.label(d), op.Query))
+		}
+	}
+	return keys
+}
@@ -1,13 +1,162 @@
 package types
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
 	"github.com/blang/semver"
+	"gopkg.in/yaml.v3"
 )
 
 // Migration defines a set of operations to run on the database.
 type Migration struct {
-	Name string         `json:"name,omitempty" yaml:"name,omitempty"`
-	Tag  semver.Version `json:"tag,omitempty" yaml:"tag,omitempty"`
-	Up   []Operation    `json:"up,omitempty" yaml:"up,omitempty"`
-	Down []Operation    `json:"down,omitempty" yaml:"down,omitempty"`
+	Name        string         `json:"name,omitempty" yaml:"name,omitempty"`
+	Tag         semver.Version `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Description string         `json:"description,omitempty" yaml:"description,omitempty"`
+	DependsOn   []string       `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+	Phase       Phase          `json:"phase,omitempty" yaml:"phase,omitempty"`
+	Up          []Operation    `json:"up,omitempty" yaml:"up,omitempty"`
+	Down        []Operation    `json:"down,omitempty" yaml:"down,omitempty"`
+}
+
+// label returns the description of the migration applied in the direction
+// used by errors and progress output.
+func (r Migration) label(d Direction) string {
+	return fmt.Sprintf("%q (%s)", r.Tag.String()+"_"+r.Name, d)
+}
+
+// ParseMigration decodes a migration from the contents of a generated sql or
+// yaml migration file. Such files are loaded into a registry with StoreFS.
+func ParseMigration(format string, rbytes []byte) (*Migration, error) {
+	switch format {
+	case "yaml":
+		return parseMigrationYAML(rbytes)
+	case "sql":
+		return parseMigrationSQL(rbytes)
+	default:
+		return nil, fmt.Errorf("format %q not supported", format)
+	}
+}
+
+func parseMigrationYAML(rbytes []byte) (*Migration, error) {
+	obj := struct {
+		Name        string      `yaml:"name"`
+		Tag         string      `yaml:"tag"`
+		Description string      `yaml:"description"`
+		DependsOn   []string    `yaml:"dependsOn"`
+		Phase       Phase       `yaml:"phase"`
+		Up          []Operation `yaml:"up"`
+		Down        []Operation `yaml:"down"`
+	}{}
+	if err := yaml.Unmarshal(rbytes, &obj); err != nil {
+		return nil, err
+	}
+
+	tag, err := semver.Parse(obj.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		Name:        obj.Name,
+		Tag:         tag,
+		Description: obj.Description,
+		DependsOn:   obj.DependsOn,
+		Phase:       obj.Phase,
+		Up:          obj.Up,
+		Down:        obj.Down,
+	}, nil
+}
+
+// parseMigrationSQL reads a sql migration file. Metadata and sections are
+// marked with `-- +migrate` comments, for example:
+//
+//	-- +migrate Name: create-users-table
+//	-- +migrate Tag: 0.0.1
+//	-- +migrate Up
+//	CREATE TABLE users (id integer);
+//	-- +migrate Down notransaction
+//	DROP TABLE users;
+func parseMigrationSQL(rbytes []byte) (*Migration, error) {
+	const prefix = "-- +migrate "
+
+	migration := &Migration{}
+	sections := map[string]*Operation{}
+	var current *Operation
+	var body *bytes.Buffer
+	bodies := map[*Operation]*bytes.Buffer{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(rbytes))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(strings.TrimSpace(line), prefix) {
+			if body != nil {
+				body.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		directive := strings.TrimPrefix(strings.TrimSpace(line), prefix)
+		if parts := strings.SplitN(directive, ":", 2); len(parts) == 2 {
+			value := strings.TrimSpace(parts[1])
+			switch strings.TrimSpace(parts[0]) {
+			case "Name":
+				migration.Name = value
+			case "Tag":
+				tag, err := semver.Parse(value)
+				if err != nil {
+					return nil, err
+				}
+				migration.Tag = tag
+			case "Description":
+				migration.Description = value
+			case "Phase":
+				migration.Phase = Phase(value)
+			case "DependsOn":
+				for _, tag := range strings.Split(value, ",") {
+					if tag = strings.TrimSpace(tag); tag != "" {
+						migration.DependsOn = append(migration.DependsOn, tag)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("unknown directive %q", line)
+			}
+			continue
+		}
+
+		fields := strings.Fields(directive)
+		if len(fields) == 0 || (fields[0] != "Up" && fields[0] != "Down") {
+			return nil, fmt.Errorf("unknown directive %q", line)
+		}
+
+		current = &Operation{}
+		for _, option := range fields[1:] {
+			if option != "notransaction" {
+				return nil, fmt.Errorf("unknown directive %q", line)
+			}
+			current.DisableTx = true
+		}
+		sections[fields[0]] = current
+		body = bytes.NewBuffer(nil)
+		bodies[current] = body
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for key, op := range sections {
+		op.Query = strings.TrimSpace(bodies[op].String())
+		if op.Query == "" {
+			continue
+		}
+
+		if key == "Up" {
+			migration.Up = []Operation{*op}
+		} else {
+			migration.Down = []Operation{*op}
+		}
+	}
+	return migration, nil
 }
@@ -3,7 +3,9 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"testing"
+	"testing/fstest"
 
 	"github.com/blang/semver"
 	"github.com/davecgh/go-spew/spew"
@@ -61,6 +63,119 @@ func (r *MigrationSuite) TestMigration_MarshalUnmarshal() {
 	}
 }
 
+func (r *MigrationSuite) TestParseMigration() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		format     string
+		content    string
+		migration  *Migration
+	}{
+		{
+			false, "",
+			"sql",
+			"-- +migrate Name: unittest\n" +
+				"-- +migrate Tag: 0.0.1\n" +
+				"-- +migrate Description: creates the unittest table\n" +
+				"-- +migrate Phase: pre-deploy\n" +
+				"\n" +
+				"-- +migrate Up\n" +
+				"CREATE TABLE unittests (value text);\n" +
+				"\n" +
+				"-- +migrate Down notransaction\n" +
+				"DROP TABLE unittests;\n",
+			&Migration{
+				Name:        "unittest",
+				Tag:         semver.MustParse("0.0.1"),
+				Description: "creates the unittest table",
+				Phase:       PhasePreDeploy,
+				Up: []Operation{
+					{Query: `CREATE TABLE unittests (value text);`},
+				},
+				Down: []Operation{
+					{Query: `DROP TABLE unittests;`, DisableTx: true},
+				},
+			},
+		},
+		{
+			false, "",
+			"yaml",
+			"name: unittest\n" +
+				"tag: 0.0.2\n" +
+				"dependsOn: [0.0.1]\n" +
+				"up:\n" +
+				"  - query: \"CREATE TABLE unittests (value text)\"\n" +
+				"down: []\n",
+			&Migration{
+				Name:      "unittest",
+				Tag:       semver.MustParse("0.0.2"),
+				DependsOn: []string{"0.0.1"},
+				Up: []Operation{
+					{Query: `CREATE TABLE unittests (value text)`},
+				},
+				Down: []Operation{},
+			},
+		},
+		{
+			true, "format \"go\" not supported",
+			"go",
+			"",
+			nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			actual, err := ParseMigration(tc.format, []byte(tc.content))
+			if err != nil {
+				panic(err.Error())
+			}
+
+			assert.EqualValues(r.T(), tc.migration, actual)
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func (r *MigrationSuite) TestRegistry_StoreFS() {
+	fsys := fstest.MapFS{
+		"migrations/0.0.1_create-users.sql": {Data: []byte("" +
+			"-- +migrate Name: create-users\n" +
+			"-- +migrate Tag: 0.0.1\n" +
+			"-- +migrate Up\n" +
+			"CREATE TABLE users (id int);\n" +
+			"-- +migrate Down\n" +
+			"DROP TABLE users;\n")},
+		"migrations/0.0.2_create-posts.yml": {Data: []byte("" +
+			"name: create-posts\n" +
+			"tag: 0.0.2\n" +
+			"up:\n" +
+			"  - query: CREATE TABLE posts (id int)\n")},
+		"migrations/README.md": {Data: []byte("# migrations\n")},
+	}
+
+	registry := Registry{}
+	assert.Nil(r.T(), registry.StoreFS(fsys, "migrations/*"))
+
+	migrations := Migrations{}
+	registry.Collect("migrations/*", &migrations)
+	sort.Sort(migrations)
+	assert.Len(r.T(), migrations, 2)
+	assert.Equal(r.T(), "create-users", migrations[0].Name)
+	assert.Equal(r.T(), "DROP TABLE users;", migrations[0].Down[0].Query)
+	assert.Equal(r.T(), "create-posts", migrations[1].Name)
+
+	fsys["migrations/0.0.3_invalid.yaml"] = &fstest.MapFile{Data: []byte("tag: invalid\n")}
+	assert.EqualError(r.T(), registry.StoreFS(fsys, "migrations/*"),
+		`invalid migration file "migrations/0.0.3_invalid.yaml" (No Major.Minor.Patch elements found)`)
+}
+
 func TestMigrationSuite(t *testing.T) {
 	suite.Run(t, new(MigrationSuite))
 }
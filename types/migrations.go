@@ -1,5 +1,11 @@
 package types
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // Migrations represents multiple database migrations.
 type Migrations []*Migration
 
@@ -17,3 +23,88 @@ func (r Migrations) Swap(i, j int) {
 func (r Migrations) Less(i, j int) bool {
 	return r[i].Tag.LT(r[j].Tag)
 }
+
+// HasDependencies reports whether any of the migrations declares explicit
+// dependencies. Migrations are planned in dependency graph order rather than
+// in strict tag order when this is the case.
+func (r Migrations) HasDependencies() bool {
+	for _, migration := range r {
+		if len(migration.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPhases reports whether any of the migrations belongs to a deployment
+// phase. Phased migrations are not necessarily applied in tag order.
+func (r Migrations) HasPhases() bool {
+	for _, migration := range r {
+		if migration.Phase != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TopologicalSort returns the migrations ordered such that every migration
+// comes after all of its dependencies. Migrations which do not depend on each
+// other are ordered by tag.
+func (r Migrations) TopologicalSort() (Migrations, error) {
+	sorted := make(Migrations, len(r))
+	copy(sorted, r)
+	sort.Sort(sorted)
+
+	index := make(map[string]*Migration, len(sorted))
+	for _, migration := range sorted {
+		index[migration.Tag.String()] = migration
+	}
+
+	pending := make(map[*Migration]int, len(sorted))
+	dependents := make(map[*Migration][]*Migration, len(sorted))
+	for _, migration := range sorted {
+		for _, tag := range migration.DependsOn {
+			dependency, ok := index[tag]
+			if !ok {
+				return nil, fmt.Errorf(
+					"migration %q depends on missing %q",
+					migration.Tag.String()+"_"+migration.Name, tag,
+				)
+			}
+			pending[migration]++
+			dependents[dependency] = append(dependents[dependency], migration)
+		}
+	}
+
+	order := make(Migrations, 0, len(sorted))
+	done := make(map[*Migration]bool, len(sorted))
+	for len(order) < len(sorted) {
+		var next *Migration
+		for _, migration := range sorted {
+			if !done[migration] && pending[migration] == 0 {
+				next = migration
+				break
+			}
+		}
+
+		if next == nil {
+			cycle := make([]string, 0)
+			for _, migration := range sorted {
+				if !done[migration] {
+					cycle = append(cycle, migration.Tag.String())
+				}
+			}
+			return nil, fmt.Errorf(
+				"migration dependency cycle between %q",
+				strings.Join(cycle, ", "),
+			)
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+	return order, nil
+}
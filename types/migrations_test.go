@@ -0,0 +1,87 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationsSuite struct {
+	suite.Suite
+}
+
+func (r *MigrationsSuite) TestMigrations_TopologicalSort() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		migrations Migrations
+		order      []string
+	}{
+		{
+			false, "",
+			Migrations{
+				{Name: "c", Tag: semver.MustParse("0.0.3")},
+				{Name: "a", Tag: semver.MustParse("0.0.1")},
+				{Name: "b", Tag: semver.MustParse("0.0.2")},
+			},
+			[]string{"0.0.1", "0.0.2", "0.0.3"},
+		},
+		{
+			false, "",
+			Migrations{
+				{Name: "users", Tag: semver.MustParse("0.0.1")},
+				{Name: "emails", Tag: semver.MustParse("0.0.2"), DependsOn: []string{"0.0.4"}},
+				{Name: "billing", Tag: semver.MustParse("0.0.3")},
+				{Name: "accounts", Tag: semver.MustParse("0.0.4"), DependsOn: []string{"0.0.1"}},
+			},
+			[]string{"0.0.1", "0.0.3", "0.0.4", "0.0.2"},
+		},
+		{
+			true, "migration \"0.0.2_b\" depends on missing \"0.0.9\"",
+			Migrations{
+				{Name: "a", Tag: semver.MustParse("0.0.1")},
+				{Name: "b", Tag: semver.MustParse("0.0.2"), DependsOn: []string{"0.0.9"}},
+			},
+			nil,
+		},
+		{
+			true, "migration dependency cycle between \"0.0.2, 0.0.3\"",
+			Migrations{
+				{Name: "a", Tag: semver.MustParse("0.0.1")},
+				{Name: "b", Tag: semver.MustParse("0.0.2"), DependsOn: []string{"0.0.3"}},
+				{Name: "c", Tag: semver.MustParse("0.0.3"), DependsOn: []string{"0.0.2"}},
+			},
+			nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		failMsg := fmt.Sprintf("test: %d %v", i, spew.Sprint(tc))
+		runner := func() {
+			order, err := tc.migrations.TopologicalSort()
+			if err != nil {
+				panic(err.Error())
+			}
+
+			tags := make([]string, 0, len(order))
+			for _, migration := range order {
+				tags = append(tags, migration.Tag.String())
+			}
+			assert.Equal(r.T(), tc.order, tags)
+		}
+
+		if tc.shouldFail {
+			assert.PanicsWithValue(r.T(), tc.onFail, runner, failMsg)
+		} else {
+			assert.NotPanics(r.T(), runner, failMsg)
+		}
+	}
+}
+
+func TestMigrationsSuite(t *testing.T) {
+	suite.Run(t, new(MigrationsSuite))
+}
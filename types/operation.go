@@ -1,56 +1,125 @@
 package types
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"io"
 
-	"gopkg.in/gorp.v1"
-
+	"github.com/trivigy/migrate/v2/internal/sqlsplit"
 	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/state"
 )
 
 // Operation defines a single query operation to run on the database.
 type Operation struct {
 	Query     string `json:"query,omitempty" yaml:"query,omitempty"`
 	DisableTx bool   `json:"disableTx,omitempty" yaml:"disableTx,omitempty"`
+	Batch     *Batch `json:"batch,omitempty" yaml:"batch,omitempty"`
+}
+
+// Execute runs the query operation on the database. Progress of batched
+// operations is written to the output and recorded on the state store.
+func (r Operation) Execute(
+	ctx context.Context,
+	db *store.Context,
+	records state.BatchStore,
+	out io.Writer,
+	migration *Migration,
+	d Direction,
+) error {
+	label := migration.label(d)
+	ctx, end := telemetry.Start(ctx, "operation",
+		telemetry.TagKey.String(migration.Tag.String()),
+		telemetry.NameKey.String(migration.Name),
+		telemetry.DirectionKey.String(d.String()),
+	)
+	err := r.execute(ctx, db, records, out, "migration", label)
+	end(err)
+	return err
+}
+
+// Statements splits the query into the individual statements which are
+// executed one after the other for the dialect.
+func (r Operation) Statements(dialect string) []sqlsplit.Statement {
+	return sqlsplit.Split(dialect, r.Query)
+}
+
+// Print writes the query for simulation purposes. When the query consists of
+// several statements, the boundaries of each statement are marked.
+func (r Operation) Print(out io.Writer, dialect string) {
+	if r.Batch != nil {
+		fmt.Fprintf(out, "-- batch (pause %s)\n%s;\n", r.Batch.Pause, r.Query)
+		return
+	}
+
+	statements := r.Statements(dialect)
+	if len(statements) <= 1 {
+		fmt.Fprintf(out, "%s;\n", r.Query)
+		return
+	}
+
+	for i, statement := range statements {
+		fmt.Fprintf(out, "-- statement %d (line %d)\n%s;\n", i+1, statement.Line, statement.Query)
+	}
 }
 
-// Execute runs the query operation on the database.
-func (r Operation) Execute(db *store.Context, migration *Migration, d Direction) error {
+// execute runs the query operation on the database. The kind and label are
+// used for describing the failing change in returned errors. Cancelling the
+// context aborts the running statement and rolls back the transaction.
+func (r Operation) execute(
+	ctx context.Context,
+	db *store.Context,
+	records state.BatchStore,
+	out io.Writer,
+	kind, label string,
+) error {
+	if r.Batch != nil {
+		return r.executeBatch(ctx, db, records, out, kind, label)
+	}
+
 	var err error
-	var executor OpExecutor
+	var tx *sql.Tx
+	var executor interface {
+		ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	}
 
-	dbMap := db.GetDBMap()
+	conn := db.GetDBMap().Db
 	if r.DisableTx {
-		executor = dbMap
+		executor = conn
 	} else {
-		if executor, err = dbMap.Begin(); err != nil {
-			return fmt.Errorf(
-				"transaction begin failed %q (%s)",
-				migration.Tag.String()+"_"+migration.Name, d,
-			)
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			return fmt.Errorf("transaction begin failed %s", label)
 		}
+		executor = tx
 	}
 
-	if _, err := executor.Exec(r.Query); err != nil {
-		if tx, ok := executor.(*gorp.Transaction); ok {
-			if err = tx.Rollback(); err != nil {
-				return fmt.Errorf(
-					"transaction rollback failed %q (%s)",
-					migration.Tag.String()+"_"+migration.Name, d,
-				)
+	statements := r.Statements(db.Dialect())
+	for i, statement := range statements {
+		if _, err := executor.ExecContext(ctx, statement.Query); err != nil {
+			if tx != nil {
+				if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+					return fmt.Errorf("transaction rollback failed %s", label)
+				}
 			}
+
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s query aborted %s (%s)", kind, label, ctx.Err())
+			}
+			if len(statements) == 1 {
+				return fmt.Errorf("%s query failed %s\n%s", kind, label, r.Query)
+			}
+			return fmt.Errorf(
+				"%s query failed %s statement %d (line %d)\n%s",
+				kind, label, i+1, statement.Line, statement.Query,
+			)
 		}
-		return fmt.Errorf("migration query failed %q (%s)\n%s",
-			migration.Tag.String()+"_"+migration.Name, d, r.Query,
-		)
 	}
 
-	if tx, ok := executor.(*gorp.Transaction); ok {
+	if tx != nil {
 		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("transaction commit failed %q (%s)",
-				migration.Tag.String()+"_"+migration.Name, d,
-			)
+			return fmt.Errorf("transaction commit failed %s", label)
 		}
 	}
 	return nil
@@ -0,0 +1,57 @@
+package types
+
+// Overlay defines kustomize like transformations applied onto the manifests of
+// every release. Overlays keep a single base release while adjusting it for
+// each environment.
+type Overlay struct {
+	// Namespace overrides the namespace of every namespaced object. The
+	// namespace is created before applying unless it already exists.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+
+	// NamePrefix and NameSuffix are added to the name of every object other
+	// than namespaces. References to renamed objects from pod templates are
+	// updated accordingly.
+	NamePrefix string `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty"`
+	NameSuffix string `json:"nameSuffix,omitempty" yaml:"nameSuffix,omitempty"`
+
+	// CommonLabels are added to every object as well as to the selectors and
+	// pod templates of services and workloads.
+	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty"`
+
+	// Images replace the names, tags and digests of container images.
+	Images []Image `json:"images,omitempty" yaml:"images,omitempty"`
+
+	// PatchesStrategicMerge are yaml or json documents identifying the object
+	// they patch by their apiVersion, kind, name and namespace.
+	PatchesStrategicMerge []string `json:"patchesStrategicMerge,omitempty" yaml:"patchesStrategicMerge,omitempty"`
+
+	// PatchesJSON6902 are json patch operations applied onto the targeted
+	// objects.
+	PatchesJSON6902 []JSON6902Patch `json:"patchesJson6902,omitempty" yaml:"patchesJson6902,omitempty"`
+}
+
+// Image defines the replacement of a container image. Containers whose image
+// name equals Name have their image name, tag or digest replaced.
+type Image struct {
+	Name    string `json:"name" yaml:"name"`
+	NewName string `json:"newName,omitempty" yaml:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty" yaml:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// JSON6902Patch defines a list of json patch operations, written as yaml or
+// json, and the object they are applied onto.
+type JSON6902Patch struct {
+	Target PatchTarget `json:"target" yaml:"target"`
+	Patch  string      `json:"patch" yaml:"patch"`
+}
+
+// PatchTarget selects the object a patch is applied onto. Empty fields match
+// any value.
+type PatchTarget struct {
+	Group     string `json:"group,omitempty" yaml:"group,omitempty"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`
+	Kind      string `json:"kind" yaml:"kind"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
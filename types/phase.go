@@ -0,0 +1,37 @@
+package types
+
+// Phase defines the deployment phase during which a migration is applied.
+// Expanding migrations run before the new application version is rolled out
+// while contracting migrations run only after the rollout is complete.
+type Phase string
+
+const (
+	// PhasePreDeploy indicates the migration runs before the deployment.
+	PhasePreDeploy Phase = "pre-deploy"
+
+	// PhasePostDeploy indicates the migration runs after the deployment.
+	PhasePostDeploy Phase = "post-deploy"
+)
+
+// Rank returns the relative order of the phase. Migrations without a phase
+// rank between the pre-deploy and post-deploy phases.
+func (r Phase) Rank() int {
+	switch r {
+	case PhasePreDeploy:
+		return 0
+	case PhasePostDeploy:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Valid reports whether the phase is one of the known deployment phases.
+func (r Phase) Valid() bool {
+	switch r {
+	case PhasePreDeploy, PhasePostDeploy:
+		return true
+	default:
+		return false
+	}
+}
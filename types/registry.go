@@ -2,13 +2,15 @@ package types
 
 import (
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 )
 
-// Registry holds stored changes of either database migrations or kubernetes
-// releases.
+// Registry holds stored changes of either database migrations, database seeds
+// or kubernetes releases.
 type Registry struct {
 	db sync.Map
 }
@@ -51,6 +53,16 @@ func (r *Registry) Collect(glob string, collection interface{}) {
 				))
 			}
 
+			*col = append(*col, elm)
+		case *Seed:
+			col, ok := collection.(*Seeds)
+			if !ok {
+				panic(fmt.Errorf(
+					"collection type %T missmatch element type %T",
+					collection, element,
+				))
+			}
+
 			*col = append(*col, elm)
 		default:
 			panic(fmt.Errorf("element type %T not supported", elm))
@@ -78,3 +90,42 @@ func (r *Registry) Store(value interface{}) {
 	_, filename, _, _ := runtime.Caller(1)
 	r.db.Store(filename, value)
 }
+
+// StoreFS parses the sql and yaml migration files of the file system matching
+// the glob and stores them inside of the registry. The files are keyed
+// relative to the directory of the caller, same as go migrations, so that
+// embedding the migrations directory alongside the go migrations allows
+// collecting both with a single glob.
+func (r *Registry) StoreFS(fsys fs.FS, glob string) error {
+	_, filename, _, _ := runtime.Caller(1)
+	dir, _ := filepath.Split(filename)
+
+	matches, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		var format string
+		switch strings.ToLower(filepath.Ext(match)) {
+		case ".sql":
+			format = "sql"
+		case ".yaml", ".yml":
+			format = "yaml"
+		default:
+			continue
+		}
+
+		rbytes, err := fs.ReadFile(fsys, match)
+		if err != nil {
+			return err
+		}
+
+		migration, err := ParseMigration(format, rbytes)
+		if err != nil {
+			return fmt.Errorf("invalid migration file %q (%s)", match, err)
+		}
+		r.db.Store(filepath.Join(dir, match), migration)
+	}
+	return nil
+}
@@ -11,11 +11,35 @@ import (
 )
 
 // Release defines a collection of kubernetes manifests which can be released
-// together as a logical unit.
+// together as a logical unit. Manifests which differ between environments are
+// generated by the template from the values of the environment.
 type Release struct {
-	Name      string           `json:"name,omitempty" yaml:"name,omitempty"`
-	Version   semver.Version   `json:"version,omitempty" yaml:"version,omitempty"`
-	Manifests []runtime.Object `json:"manifests,omitempty" yaml:"manifests,omitempty"`
+	Name      string                                        `json:"name,omitempty" yaml:"name,omitempty"`
+	Version   semver.Version                                `json:"version,omitempty" yaml:"version,omitempty"`
+	Manifests []runtime.Object                              `json:"manifests,omitempty" yaml:"manifests,omitempty"`
+	Template  func(values Values) ([]runtime.Object, error) `json:"-" yaml:"-"`
+}
+
+// Render returns a copy of the release whose manifests are followed by the
+// manifests generated by the template from the values. The copy has no
+// template and therefore renders onto itself.
+func (r Release) Render(values Values) (*Release, error) {
+	rel := &Release{
+		Name:      r.Name,
+		Version:   r.Version,
+		Manifests: append([]runtime.Object{}, r.Manifests...),
+	}
+
+	if r.Template == nil {
+		return rel, nil
+	}
+
+	manifests, err := r.Template(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render release %q version %s: %w", r.Name, r.Version, err)
+	}
+	rel.Manifests = append(rel.Manifests, manifests...)
+	return rel, nil
 }
 
 // String implements the Stringer interface for Release.
@@ -121,6 +121,42 @@ func (r *ReleaseSuite) TestRelease_MarshalUnmarshal() {
 	}
 }
 
+func (r *ReleaseSuite) TestRelease_Render() {
+	release := &Release{
+		Name:    "coredb",
+		Version: semver.MustParse("0.0.1"),
+		Manifests: []runtime.Object{
+			&v1core.Namespace{ObjectMeta: v1meta.ObjectMeta{Name: "unittest"}},
+		},
+		Template: func(values Values) ([]runtime.Object, error) {
+			replicas, ok := values.Lookup("replicas")
+			if !ok {
+				return nil, fmt.Errorf("missing replicas")
+			}
+			return []runtime.Object{
+				&v1core.ReplicationController{
+					ObjectMeta: v1meta.ObjectMeta{Name: "unittest"},
+					Spec:       v1core.ReplicationControllerSpec{Replicas: &[]int32{int32(replicas.(int))}[0]},
+				},
+			}, nil
+		},
+	}
+
+	rendered, err := release.Render(Values{"replicas": 3})
+	assert.Nil(r.T(), err)
+	assert.Nil(r.T(), rendered.Template)
+	assert.Len(r.T(), rendered.Manifests, 2)
+	assert.Len(r.T(), release.Manifests, 1)
+	assert.Equal(r.T(), int32(3), *rendered.Manifests[1].(*v1core.ReplicationController).Spec.Replicas)
+
+	again, err := rendered.Render(Values{"replicas": 5})
+	assert.Nil(r.T(), err)
+	assert.Equal(r.T(), rendered, again)
+
+	_, err = release.Render(Values{})
+	assert.EqualError(r.T(), err, `failed to render release "coredb" version 0.0.1: missing replicas`)
+}
+
 func TestReleaseSuite(t *testing.T) {
 	suite.Run(t, new(ReleaseSuite))
 }
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/trivigy/migrate/v2/internal/store"
+	"github.com/trivigy/migrate/v2/internal/telemetry"
+	"github.com/trivigy/migrate/v2/state"
+)
+
+// Seed defines a set of operations which populate the database with data
+// independently of the schema migrations.
+type Seed struct {
+	Name         string      `json:"name,omitempty" yaml:"name,omitempty"`
+	Environments []string    `json:"environments,omitempty" yaml:"environments,omitempty"`
+	Apply        []Operation `json:"apply,omitempty" yaml:"apply,omitempty"`
+	Reset        []Operation `json:"reset,omitempty" yaml:"reset,omitempty"`
+}
+
+// Allows reports whether the seed is permitted to run in the environment. A
+// seed with no environments listed is permitted everywhere.
+func (r Seed) Allows(env string) bool {
+	if len(r.Environments) == 0 {
+		return true
+	}
+
+	for _, allowed := range r.Environments {
+		if allowed == env {
+			return true
+		}
+	}
+	return false
+}
+
+// Checksum returns a digest of the apply operations. A change in checksum
+// indicates that the seed needs to be applied again.
+func (r Seed) Checksum() string {
+	hash := sha256.New()
+	for _, op := range r.Apply {
+		fmt.Fprintf(hash, "%t:%s\n", op.DisableTx, op.Query)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Execute runs either the apply or the reset operations of the seed.
+func (r Seed) Execute(
+	ctx context.Context,
+	db *store.Context,
+	out io.Writer,
+	action string,
+) (err error) {
+	ctx, end := telemetry.Start(ctx, "seed",
+		telemetry.NameKey.String(r.Name),
+		telemetry.DirectionKey.String(action),
+	)
+	defer func() { end(err) }()
+
+	ops := r.Apply
+	if action == "reset" {
+		ops = r.Reset
+	}
+
+	// seeds are recorded on the database itself and so is the progress of
+	// their batched operations.
+	records := state.NewSQL(db)
+
+	for _, op := range ops {
+		octx, oend := telemetry.Start(ctx, "operation",
+			telemetry.NameKey.String(r.Name),
+			telemetry.DirectionKey.String(action),
+		)
+		err := op.execute(octx, db, records, out, "seed", fmt.Sprintf("%q (%s)", r.Name, action))
+		oend(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
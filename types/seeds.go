@@ -0,0 +1,19 @@
+package types
+
+// Seeds represents multiple database seeds.
+type Seeds []*Seed
+
+// Len returns length of seeds collection
+func (r Seeds) Len() int {
+	return len(r)
+}
+
+// Swap swaps two seeds inside the collection by its indices
+func (r Seeds) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+// Less checks if seed at index i is less than seed at index j
+func (r Seeds) Less(i, j int) bool {
+	return r[i].Name < r[j].Name
+}
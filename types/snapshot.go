@@ -0,0 +1,30 @@
+package types
+
+import (
+	"time"
+)
+
+// Snapshot defines a point-in-time copy of a database.
+type Snapshot struct {
+	Name      string    `json:"name" yaml:"name"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+}
+
+// Snapshots represents multiple database snapshots.
+type Snapshots []Snapshot
+
+// Len returns length of snapshots collection
+func (r Snapshots) Len() int {
+	return len(r)
+}
+
+// Swap swaps two snapshots inside the collection by its indices
+func (r Snapshots) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+// Less checks if snapshot at index i was taken before snapshot at index j
+func (r Snapshots) Less(i, j int) bool {
+	return r[i].Timestamp.Before(r[j].Timestamp) ||
+		(r[i].Timestamp.Equal(r[j].Timestamp) && r[i].Name < r[j].Name)
+}
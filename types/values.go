@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Values represents a tree of configuration values from which the manifests
+// of a release are rendered. Nested values are addressed with dot separated
+// paths, e.g. `image.tag`.
+type Values map[string]interface{}
+
+// Merge returns the deep merge of the values with the other values. Nested
+// maps are merged recursively while any other value of the other values
+// replaces the value found at the same path.
+func (r Values) Merge(other Values) Values {
+	merged := make(Values, len(r))
+	for key, value := range r {
+		merged[key] = value
+	}
+
+	for key, value := range other {
+		current, ok := asMap(merged[key])
+		next, isMap := asMap(value)
+		if ok && isMap {
+			merged[key] = map[string]interface{}(Values(current).Merge(next))
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// Set assigns the value of a `KEY=VALUE` expression. The value is parsed as a
+// yaml scalar so that numbers and booleans keep their types. Missing parent
+// maps along the key path are created.
+func (r Values) Set(expr string) error {
+	parts := strings.SplitN(expr, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid value %q (expected KEY=VALUE)", expr)
+	}
+
+	var value interface{} = parts[1]
+	if parts[1] != "" {
+		if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
+			return fmt.Errorf("invalid value %q (%s)", expr, err)
+		}
+	}
+
+	keys := strings.Split(parts[0], ".")
+	current := map[string]interface{}(r)
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := asMap(current[key])
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+	return nil
+}
+
+// Lookup returns the value found at the dot separated path.
+func (r Values) Lookup(path string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(r)
+	for _, key := range strings.Split(path, ".") {
+		values, ok := asMap(current)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = values[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// asMap returns the value as a map when it is a nested tree of values.
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return value, true
+	case Values:
+		return value, true
+	}
+	return nil, false
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type ValuesSuite struct {
+	suite.Suite
+}
+
+func (r *ValuesSuite) TestValues_Merge() {
+	base := Values{
+		"replicas": 1,
+		"image":    map[string]interface{}{"name": "api", "tag": "latest"},
+		"ingress":  Values{"host": "api.local"},
+	}
+
+	merged := base.Merge(Values{
+		"replicas": 3,
+		"image":    map[string]interface{}{"tag": "1.4.0"},
+		"ingress":  "disabled",
+	})
+
+	assert.Equal(r.T(), Values{
+		"replicas": 3,
+		"image":    map[string]interface{}{"name": "api", "tag": "1.4.0"},
+		"ingress":  "disabled",
+	}, merged)
+	assert.Equal(r.T(), map[string]interface{}{"name": "api", "tag": "latest"}, base["image"])
+}
+
+func (r *ValuesSuite) TestValues_Set() {
+	testCases := []struct {
+		shouldFail bool
+		onFail     string
+		expr       string
+		path       string
+		value      interface{}
+	}{
+		{false, "", "replicas=3", "replicas", 3},
+		{false, "", "image.tag=1.4.0", "image.tag", "1.4.0"},
+		{false, "", "ingress.enabled=true", "ingress.enabled", true},
+		{false, "", "ingress.host=", "ingress.host", ""},
+		{false, "", "url=http://api.local?a=b", "url", "http://api.local?a=b"},
+		{true, `invalid value "replicas" (expected KEY=VALUE)`, "replicas", "", nil},
+		{true, `invalid value "=3" (expected KEY=VALUE)`, "=3", "", nil},
+	}
+
+	for i, testCase := range testCases {
+		values := Values{"image": map[string]interface{}{"name": "api"}}
+		err := values.Set(testCase.expr)
+		if testCase.shouldFail {
+			assert.EqualError(r.T(), err, testCase.onFail, "testCase: %d", i)
+			continue
+		}
+		assert.Nil(r.T(), err, "testCase: %d", i)
+
+		value, ok := values.Lookup(testCase.path)
+		assert.True(r.T(), ok, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.value, value, "testCase: %d", i)
+
+		name, _ := values.Lookup("image.name")
+		assert.Equal(r.T(), "api", name, "testCase: %d", i)
+	}
+}
+
+func (r *ValuesSuite) TestValues_Lookup() {
+	values := Values{"image": Values{"tag": "1.4.0"}, "replicas": 3}
+	testCases := []struct {
+		path  string
+		value interface{}
+		ok    bool
+	}{
+		{"image.tag", "1.4.0", true},
+		{"replicas", 3, true},
+		{"replicas.count", nil, false},
+		{"image.name", nil, false},
+	}
+
+	for i, testCase := range testCases {
+		value, ok := values.Lookup(testCase.path)
+		assert.Equal(r.T(), testCase.ok, ok, "testCase: %d", i)
+		assert.Equal(r.T(), testCase.value, value, "testCase: %d", i)
+	}
+}
+
+func TestValuesSuite(t *testing.T) {
+	suite.Run(t, new(ValuesSuite))
+}